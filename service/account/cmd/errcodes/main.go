@@ -0,0 +1,19 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	_ "github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"os"
+)
+
+// Prints the error codes of the account service, as a markdown table or with -format json.
+func main() {
+	format := flag.String("format", "markdown", "listing format, markdown or json")
+	flag.Parse()
+	if err := errs.DefaultCatalog.WriteListing(os.Stdout, *format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
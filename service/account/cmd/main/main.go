@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
 	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
 )
 
 func r() error {
@@ -10,6 +13,9 @@ func r() error {
 }
 
 func main() {
+	if err := usecase.LoadTranslations(); err != nil {
+		logger.FromContext(context.Background()).Panic("unable to load translations", logger.Field("error", err))
+	}
 	fmt.Println(" Hello From Account Service")
 	err := r()
 	e := errs.HandleError(err)
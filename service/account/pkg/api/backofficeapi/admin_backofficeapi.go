@@ -0,0 +1,13 @@
+package backofficeapi
+
+import (
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/api"
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewAdminBackofficeApi mounts the admin routes of the account service under /v1/backoffice/admin,
+// see api.Admin.Mount for what they expose and the permissions they require.
+func NewAdminBackofficeApi(app *fiber.App, toolkit *shared.Toolkit, admin api.AdminConfig) {
+	shared.Api.Admin.Mount(app.Group("/v1/backoffice/admin"), toolkit.Cache, admin)
+}
@@ -0,0 +1,47 @@
+package backofficeapi
+
+import (
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/api/openapi"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuthBackofficeApi interface {
+	SetupV1(app *fiber.App)
+	UnlockUser(ctx *fiber.Ctx) error
+}
+
+func NewAuthBackofficeApi(app *fiber.App, toolkit *shared.Toolkit, auth usecase.AuthUseCase) {
+	api := &authBackofficeApi{
+		toolkit: toolkit,
+		auth:    auth,
+	}
+	api.SetupV1(app)
+}
+
+type authBackofficeApi struct {
+	toolkit *shared.Toolkit
+	auth    usecase.AuthUseCase
+}
+
+func (api *authBackofficeApi) SetupV1(app *fiber.App) {
+	v1group := app.Group("/v1/backoffice/auth")
+	cache := api.toolkit.Cache
+	openapi.Post(v1group, "/users/:id/unlock", openapi.Operation{
+		Summary:     "Unlock a user locked out after too many failed attempts",
+		Description: "Requires the " + usecase.PermUsersUnlock + " permission.",
+		Tags:        []string{"backoffice"},
+		Errors:      []errs.Entry{openapi.Kind(errs.ErrForbidden)},
+		Security:    shared.Api.Docs.SessionSecurity(),
+	}, shared.Api.Auth.AuthorizeBackofficeMiddleware(cache), shared.Api.Auth.RequirePermission(usecase.PermUsersUnlock), api.UnlockUser)
+}
+
+// UnlockUser lifts the lockout a user's account got after too many failed logins.
+func (api *authBackofficeApi) UnlockUser(ctx *fiber.Ctx) error {
+	if err := api.auth.UnlockUser(ctx.Params("id")); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(nil))
+}
@@ -0,0 +1,58 @@
+package serviceapi
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/api"
+	"github.com/abdelrahman146/zard/shared/api/openapi"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WorkspaceServiceApi interface {
+	SetupV1(app *fiber.App)
+	GetWorkspace(ctx *fiber.Ctx) error
+}
+
+func NewWorkspaceServiceApi(app *fiber.App, toolkit *shared.Toolkit, usecases *usecase.AccountUseCases) {
+	api := &workspaceServiceApi{
+		toolkit:  toolkit,
+		usecases: usecases,
+	}
+	api.SetupV1(app)
+}
+
+type workspaceServiceApi struct {
+	toolkit  *shared.Toolkit
+	usecases *usecase.AccountUseCases
+}
+
+// SetupV1 registers the routes workspaces call with their API key, under /v1/service/:workspaceId.
+func (api *workspaceServiceApi) SetupV1(app *fiber.App) {
+	v1group := app.Group("/v1/service/:workspaceId")
+	authorize := shared.Api.Auth.AuthorizeServiceMiddleware(newApiKeyResolver(api.usecases.AuthUseCase))
+	openapi.Get(v1group, "/", openapi.Operation{
+		Summary:  "Get the workspace of the API key",
+		Tags:     []string{"service"},
+		Result:   model.Workspace{},
+		Errors:   []errs.Entry{usecase.ErrMalformedApiKey, usecase.ErrInvalidApiKey, openapi.Kind(errs.ErrForbidden)},
+		Security: shared.Api.Docs.ApiKeySecurity(),
+	}, authorize, shared.Api.Auth.RequirePermission(usecase.PermWorkspaceRead), api.GetWorkspace)
+}
+
+// newApiKeyResolver resolves API keys in process, other services go through api.NewRpcApiKeyResolver.
+func newApiKeyResolver(authUseCase usecase.AuthUseCase) api.ApiKeyResolver {
+	return api.ApiKeyResolverFunc(func(ctx context.Context, apiKey string) (string, error) {
+		return authUseCase.AuthenticateWorkspaceByApiKey(apiKey)
+	})
+}
+
+func (api *workspaceServiceApi) GetWorkspace(ctx *fiber.Ctx) error {
+	workspace, err := api.usecases.WorkspaceUseCase.GetWorkSpaceByID(ctx.Params("workspaceId"))
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(workspace))
+}
@@ -3,6 +3,8 @@ package userapi
 import (
 	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
 	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/api/openapi"
+	"github.com/abdelrahman146/zard/shared/auth"
 	"github.com/abdelrahman146/zard/shared/errs"
 	"github.com/gofiber/fiber/v2"
 )
@@ -17,10 +19,11 @@ type AuthUserApi interface {
 	GetUserSession(ctx *fiber.Ctx) error
 }
 
-func NewAuthUserApi(app *fiber.App, toolkit *shared.Toolkit, auth usecase.AuthUseCase) {
+func NewAuthUserApi(app *fiber.App, toolkit *shared.Toolkit, auth usecase.AuthUseCase, users usecase.UserUseCase) {
 	api := &authUserApi{
 		toolkit: toolkit,
 		auth:    auth,
+		users:   users,
 	}
 	api.SetupV1(app)
 }
@@ -28,17 +31,55 @@ func NewAuthUserApi(app *fiber.App, toolkit *shared.Toolkit, auth usecase.AuthUs
 type authUserApi struct {
 	toolkit *shared.Toolkit
 	auth    usecase.AuthUseCase
+	users   usecase.UserUseCase
 }
 
 func (api *authUserApi) SetupV1(app *fiber.App) {
 	v1group := app.Group("/v1/auth")
 	cache := api.toolkit.Cache
-	v1group.Get("/user", shared.Api.Auth.AuthorizeUserMiddleware(cache), api.GetUserSession)
-	v1group.Post("/login", api.LoginWithEmailAndPassword)
-	v1group.Post("/otp/email", api.GenerateOTPForEmail)
-	v1group.Post("/otp/verify", api.VerifyOTP)
-	v1group.Post("/logout", shared.Api.Auth.AuthorizeUserMiddleware(cache), api.Logout)
-	v1group.Post("/logout/all", shared.Api.Auth.AuthorizeUserMiddleware(cache), api.LogoutFromAllUserSessions)
+	rateLimit := shared.Api.RateLimit
+	session := shared.Api.Docs.SessionSecurity()
+	tooManyRequests := openapi.Kind(errs.ErrTooManyRequests)
+	openapi.Get(v1group, "/user", openapi.Operation{
+		Summary:  "Get the user of the session",
+		Tags:     []string{"auth"},
+		Result:   usecase.UserStruct{},
+		Errors:   []errs.Entry{openapi.Kind(errs.ErrNotFound)},
+		Security: session,
+	}, shared.Api.Auth.AuthorizeUserMiddleware(cache), api.GetUserSession)
+	openapi.Post(v1group, "/login", openapi.Operation{
+		Summary:     "Log in with email and password",
+		Description: "Starts a session, whose token is set in the token cookie.",
+		Tags:        []string{"auth"},
+		Body:        LoginWithEmailAndPasswordRequest{},
+		Result:      usecase.UserStruct{},
+		Errors:      []errs.Entry{usecase.ErrInvalidCredentials, usecase.ErrInactiveUser, usecase.ErrAccountLocked, usecase.ErrTooManyAttempts, tooManyRequests},
+	}, rateLimit.Middleware(newRateLimiter(api.toolkit, loginRateLimit), rateLimit.ByIP()), api.LoginWithEmailAndPassword)
+	openapi.Post(v1group, "/otp/email", openapi.Operation{
+		Summary: "Send an OTP by email",
+		Tags:    []string{"auth"},
+		Body:    GenerateOTPForEmailRequest{},
+		Result: struct {
+			MaxAge int `json:"maxAge"` // seconds the OTP is valid for
+		}{},
+		Errors: []errs.Entry{usecase.ErrOtpAlreadySent, tooManyRequests},
+	}, rateLimit.Middleware(newRateLimiter(api.toolkit, otpRateLimit), rateLimit.ByIP()), api.GenerateOTPForEmail)
+	openapi.Post(v1group, "/otp/verify", openapi.Operation{
+		Summary: "Verify an OTP",
+		Tags:    []string{"auth"},
+		Body:    VerifyOTPForEmailRequest{},
+		Errors:  []errs.Entry{usecase.ErrInvalidOtp, usecase.ErrOtpInvalidated, usecase.ErrAccountLocked, usecase.ErrTooManyAttempts, tooManyRequests},
+	}, rateLimit.Middleware(newRateLimiter(api.toolkit, otpVerifyRateLimit), rateLimit.ByIP()), api.VerifyOTP)
+	openapi.Post(v1group, "/logout", openapi.Operation{
+		Summary:  "Log out of the session",
+		Tags:     []string{"auth"},
+		Security: session,
+	}, shared.Api.Auth.AuthorizeUserMiddleware(cache), api.Logout)
+	openapi.Post(v1group, "/logout/all", openapi.Operation{
+		Summary:  "Log out of every session of the user",
+		Tags:     []string{"auth"},
+		Security: session,
+	}, shared.Api.Auth.AuthorizeUserMiddleware(cache), api.LogoutFromAllUserSessions)
 }
 
 func (api *authUserApi) LoginWithEmailAndPassword(ctx *fiber.Ctx) error {
@@ -54,16 +95,19 @@ func (api *authUserApi) LoginWithEmailAndPassword(ctx *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	maxAge := api.toolkit.Conf.GetInt("app.auth.tokenTTL")
-	shared.Api.Auth.InitSession(ctx, token, maxAge)
+	shared.Api.Auth.InitSession(ctx, token, int(api.auth.TokenTTL().Seconds()))
 	resp := shared.Api.Response.NewSuccessResponse(user)
 	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
 
 func (api *authUserApi) Logout(ctx *fiber.Ctx) error {
-	token := ctx.Cookies("token")
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
 	ctx.ClearCookie("token")
-	if err := api.auth.RevokeToken(token); err != nil {
+	// the session may have been presented as a bearer token rather than the cookie
+	if err := api.auth.RevokeToken(principal.Credential); err != nil {
 		return err
 	}
 	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(nil))
@@ -82,7 +126,7 @@ func (api *authUserApi) GenerateOTPForEmail(ctx *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	resp := shared.Api.Response.NewSuccessResponse(fiber.Map{"maxAge": maxAge})
+	resp := shared.Api.Response.NewSuccessResponse(fiber.Map{"maxAge": int(maxAge.Seconds())})
 	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
 
@@ -103,18 +147,22 @@ func (api *authUserApi) VerifyOTP(ctx *fiber.Ctx) error {
 }
 
 func (api *authUserApi) LogoutFromAllUserSessions(ctx *fiber.Ctx) error {
-	user, err := shared.Api.Auth.GetUserFromContext(ctx.UserContext())
+	principal, err := auth.Current(ctx.UserContext())
 	if err != nil {
 		return err
 	}
-	if err := api.auth.RevokeAllUserTokens(user.ID); err != nil {
+	if err := api.auth.RevokeAllUserTokens(principal.ID); err != nil {
 		return err
 	}
 	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(nil))
 }
 
 func (api *authUserApi) GetUserSession(ctx *fiber.Ctx) error {
-	user, err := shared.Api.Auth.GetUserFromContext(ctx.UserContext())
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	user, err := api.users.GetUserByID(principal.ID)
 	if err != nil {
 		return err
 	}
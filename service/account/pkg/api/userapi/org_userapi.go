@@ -0,0 +1,234 @@
+package userapi
+
+import (
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/service/account/pkg/repo"
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/api/openapi"
+	"github.com/abdelrahman146/zard/shared/auth"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/query"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OrgUserApi interface {
+	Setup(app *fiber.App)
+	GetOrg(ctx *fiber.Ctx) error
+	UpdateOrg(ctx *fiber.Ctx) error
+	GetMembers(ctx *fiber.Ctx) error
+	AddMember(ctx *fiber.Ctx) error
+	ChangeMemberRole(ctx *fiber.Ctx) error
+	RemoveMember(ctx *fiber.Ctx) error
+	GetRoles(ctx *fiber.Ctx) error
+	CreateRole(ctx *fiber.Ctx) error
+	DeleteRole(ctx *fiber.Ctx) error
+}
+
+func NewOrgUserApi(app *fiber.App, toolkit *shared.Toolkit, usecases *usecase.AccountUseCases) {
+	api := &orgUserApi{
+		toolkit:  toolkit,
+		usecases: usecases,
+	}
+	api.Setup(app)
+}
+
+type orgUserApi struct {
+	toolkit  *shared.Toolkit
+	usecases *usecase.AccountUseCases
+}
+
+// Setup registers the routes managing an organization. Each route requires a permission on the
+// organization of its path, and the members and roles it acts on are authorized again in their
+// own organization by usecase.AccessUseCase.
+func (api *orgUserApi) Setup(app *fiber.App) {
+	v1group := app.Group("/v1/orgs/:orgId")
+	authorize := shared.Api.Auth.AuthorizeUserMiddleware(api.toolkit.Cache)
+	requirePermission := shared.Api.Auth.RequirePermission
+	session := shared.Api.Docs.SessionSecurity()
+	forbidden := openapi.Kind(errs.ErrForbidden)
+	openapi.Get(v1group, "/", openapi.Operation{
+		Summary:  "Get the organization",
+		Tags:     []string{"org"},
+		Result:   model.Organization{},
+		Errors:   []errs.Entry{forbidden, openapi.Kind(errs.ErrNotFound)},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermOrgRead), api.GetOrg)
+	openapi.Put(v1group, "/", openapi.Operation{
+		Summary:  "Update the organization",
+		Tags:     []string{"org"},
+		Body:     usecase.UpdateOrgStruct{},
+		Result:   model.Organization{},
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermOrgUpdate), api.UpdateOrg)
+	openapi.Get(v1group, "/members", openapi.Operation{
+		Summary:  "List the members of the organization",
+		Tags:     []string{"org"},
+		Query:    ListQuery{},
+		Result:   shared.List[model.Membership]{},
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermOrgRead), api.GetMembers)
+	openapi.Post(v1group, "/members", openapi.Operation{
+		Summary:  "Add a member to the organization or one of its workspaces",
+		Tags:     []string{"org"},
+		Body:     usecase.AddMemberStruct{},
+		Result:   model.Membership{},
+		Status:   fiber.StatusCreated,
+		Errors:   []errs.Entry{forbidden, usecase.ErrPermissionNotHeld, usecase.ErrUnknownRole, usecase.ErrAlreadyMember},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermOrgMembersManage), api.AddMember)
+	openapi.Put(v1group, "/members/:memberId", openapi.Operation{
+		Summary:  "Change the role of a member",
+		Tags:     []string{"org"},
+		Body:     ChangeMemberRoleRequest{},
+		Result:   model.Membership{},
+		Errors:   []errs.Entry{forbidden, usecase.ErrPermissionNotHeld, usecase.ErrUnknownRole, usecase.ErrLastOwner},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermOrgMembersManage), api.ChangeMemberRole)
+	openapi.Delete(v1group, "/members/:memberId", openapi.Operation{
+		Summary:  "Remove a member",
+		Tags:     []string{"org"},
+		Errors:   []errs.Entry{forbidden, usecase.ErrPermissionNotHeld, usecase.ErrLastOwner},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermOrgMembersManage), api.RemoveMember)
+	openapi.Get(v1group, "/roles", openapi.Operation{
+		Summary:  "List the custom roles of the organization",
+		Tags:     []string{"org"},
+		Result:   []model.Role{},
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermOrgRead), api.GetRoles)
+	openapi.Post(v1group, "/roles", openapi.Operation{
+		Summary:  "Create a custom role",
+		Tags:     []string{"org"},
+		Body:     usecase.CreateRoleStruct{},
+		Result:   model.Role{},
+		Status:   fiber.StatusCreated,
+		Errors:   []errs.Entry{forbidden, usecase.ErrPermissionNotHeld, usecase.ErrUnknownPermission, usecase.ErrReservedRole, usecase.ErrRoleAlreadyExists},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermOrgRolesManage), api.CreateRole)
+	openapi.Delete(v1group, "/roles/:roleId", openapi.Operation{
+		Summary:  "Delete a custom role no member holds",
+		Tags:     []string{"org"},
+		Errors:   []errs.Entry{forbidden, usecase.ErrRoleInUse},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermOrgRolesManage), api.DeleteRole)
+}
+
+func (api *orgUserApi) GetOrg(ctx *fiber.Ctx) error {
+	org, err := api.usecases.OrgUseCase.GetOrgByID(ctx.Params("orgId"))
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(org))
+}
+
+func (api *orgUserApi) UpdateOrg(ctx *fiber.Ctx) error {
+	body := usecase.UpdateOrgStruct{}
+	if err := ctx.BodyParser(&body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	org, err := api.usecases.OrgUseCase.UpdateOrg(ctx.Params("orgId"), body)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(org))
+}
+
+func (api *orgUserApi) GetMembers(ctx *fiber.Ctx) error {
+	spec, err := query.FromRequest(ctx, repo.MembershipListOptions)
+	if err != nil {
+		return err
+	}
+	members, err := api.usecases.AccessUseCase.GetMembersByOrgID(ctx.Params("orgId"), spec)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(members))
+}
+
+func (api *orgUserApi) AddMember(ctx *fiber.Ctx) error {
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	body := &usecase.AddMemberStruct{}
+	if err := ctx.BodyParser(body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	body.OrgID = ctx.Params("orgId")
+	member, err := api.usecases.AccessUseCase.AddMember(principal, body)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(shared.Api.Response.NewSuccessResponse(member))
+}
+
+func (api *orgUserApi) ChangeMemberRole(ctx *fiber.Ctx) error {
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	body := &ChangeMemberRoleRequest{}
+	if err := ctx.BodyParser(body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	if err := api.toolkit.Validator.ValidateStruct(body); err != nil {
+		fields := api.toolkit.Validator.GetValidationErrors(err)
+		return errs.NewValidationError("Invalid request body", fields)
+	}
+	member, err := api.usecases.AccessUseCase.ChangeMemberRole(principal, ctx.Params("memberId"), body.Role)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(member))
+}
+
+func (api *orgUserApi) RemoveMember(ctx *fiber.Ctx) error {
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	if err := api.usecases.AccessUseCase.RemoveMember(principal, ctx.Params("memberId")); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(nil))
+}
+
+func (api *orgUserApi) GetRoles(ctx *fiber.Ctx) error {
+	roles, err := api.usecases.AccessUseCase.GetRolesByOrgID(ctx.Params("orgId"))
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(roles))
+}
+
+func (api *orgUserApi) CreateRole(ctx *fiber.Ctx) error {
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	body := &usecase.CreateRoleStruct{}
+	if err := ctx.BodyParser(body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	body.OrgID = ctx.Params("orgId")
+	role, err := api.usecases.AccessUseCase.CreateRole(principal, body)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(shared.Api.Response.NewSuccessResponse(role))
+}
+
+func (api *orgUserApi) DeleteRole(ctx *fiber.Ctx) error {
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	if err := api.usecases.AccessUseCase.DeleteRole(principal, ctx.Params("roleId")); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(nil))
+}
@@ -3,17 +3,20 @@ package userapi
 import (
 	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
 	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/api/openapi"
+	"github.com/abdelrahman146/zard/shared/auth"
 	"github.com/abdelrahman146/zard/shared/errs"
 	"github.com/gofiber/fiber/v2"
 )
 
 type UserUserApi interface {
 	Setup(app *fiber.App)
-	Register(ctx *fiber.Ctx) error
+	RegisterWithEmailAndPassword(ctx *fiber.Ctx) error
 	UpdateUser(ctx *fiber.Ctx) error
 	UpdateUserEmail(ctx *fiber.Ctx) error
 	VerifyUserEmailSendOtp(ctx *fiber.Ctx) error
-	VerifyUserEmailSubmitOtp(ctx *fiber.Ctx) error
+	VerifyUserEmailValidateOtp(ctx *fiber.Ctx) error
+	VerifyUserEmailValidateHash(ctx *fiber.Ctx) error
 	UpdateUserPassword(ctx *fiber.Ctx) error
 }
 
@@ -33,13 +36,59 @@ type userUserApi struct {
 func (api *userUserApi) Setup(app *fiber.App) {
 	v1group := app.Group("/v1/user")
 	cache := api.toolkit.Cache
-	v1group.Post("/register", api.RegisterWithEmailAndPassword)
-	v1group.Post("/email/verify/otp", shared.Api.Auth.AuthorizeUserMiddleware(cache), api.VerifyUserEmailSendOtp)
-	v1group.Post("/email/verify/otp/verify", shared.Api.Auth.AuthorizeUserMiddleware(cache), api.VerifyUserEmailValidateOtp)
-	v1group.Get("/email/verify/otp/verify/:hash", shared.Api.Auth.AuthorizeUserMiddleware(cache), api.VerifyUserEmailValidateHash)
-	v1group.Put("/", shared.Api.Auth.AuthorizeUserMiddleware(cache), api.UpdateUser)
-	v1group.Put("/email", shared.Api.Auth.AuthorizeUserMiddleware(cache), api.UpdateUserEmail)
-	v1group.Put("/password", shared.Api.Auth.AuthorizeUserMiddleware(cache), api.UpdateUserPassword)
+	rateLimit := shared.Api.RateLimit
+	session := shared.Api.Docs.SessionSecurity()
+	tooManyRequests := openapi.Kind(errs.ErrTooManyRequests)
+	openapi.Post(v1group, "/register", openapi.Operation{
+		Summary:     "Register with email and password",
+		Description: "Creates the user, sends an OTP to verify its email and starts a session.",
+		Tags:        []string{"user"},
+		Body:        usecase.CreateUserStruct{},
+		Result:      usecase.UserStruct{},
+		Status:      fiber.StatusCreated,
+		Errors:      []errs.Entry{usecase.ErrEmailAlreadyExists, usecase.ErrPhoneAlreadyExists, usecase.ErrUserAlreadyExists, tooManyRequests},
+	}, rateLimit.Middleware(newRateLimiter(api.toolkit, registerRateLimit), rateLimit.ByIP()), api.RegisterWithEmailAndPassword)
+	openapi.Post(v1group, "/email/verify/otp", openapi.Operation{
+		Summary:  "Send an OTP to verify the email of the user",
+		Tags:     []string{"user"},
+		Errors:   []errs.Entry{usecase.ErrOtpAlreadySent, tooManyRequests},
+		Security: session,
+	}, shared.Api.Auth.AuthorizeUserMiddleware(cache), rateLimit.Middleware(newRateLimiter(api.toolkit, otpRateLimit), rateLimit.ByUser()), api.VerifyUserEmailSendOtp)
+	openapi.Post(v1group, "/email/verify/otp/verify", openapi.Operation{
+		Summary:  "Verify the email of the user with an OTP",
+		Tags:     []string{"user"},
+		Body:     SubmitOTPRequest{},
+		Errors:   []errs.Entry{usecase.ErrInvalidOtp, usecase.ErrOtpInvalidated},
+		Security: session,
+	}, shared.Api.Auth.AuthorizeUserMiddleware(cache), api.VerifyUserEmailValidateOtp)
+	openapi.Get(v1group, "/email/verify/otp/verify/:hash", openapi.Operation{
+		Summary:  "Verify the email of the user with the link sent by email",
+		Tags:     []string{"user"},
+		Errors:   []errs.Entry{usecase.ErrInvalidOtp, usecase.ErrOtpInvalidated},
+		Security: session,
+	}, shared.Api.Auth.AuthorizeUserMiddleware(cache), api.VerifyUserEmailValidateHash)
+	openapi.Put(v1group, "/", openapi.Operation{
+		Summary:  "Update the user",
+		Tags:     []string{"user"},
+		Body:     usecase.UpdateUserStruct{},
+		Result:   usecase.UserStruct{},
+		Security: session,
+	}, shared.Api.Auth.AuthorizeUserMiddleware(cache), api.UpdateUser)
+	openapi.Put(v1group, "/email", openapi.Operation{
+		Summary:  "Change the email of the user",
+		Tags:     []string{"user"},
+		Body:     UpdateUserEmailRequest{},
+		Result:   usecase.UserStruct{},
+		Errors:   []errs.Entry{usecase.ErrEmailAlreadyExists},
+		Security: session,
+	}, shared.Api.Auth.AuthorizeUserMiddleware(cache), api.UpdateUserEmail)
+	openapi.Put(v1group, "/password", openapi.Operation{
+		Summary:  "Change the password of the user",
+		Tags:     []string{"user"},
+		Body:     UpdateUserPasswordRequest{},
+		Errors:   []errs.Entry{usecase.ErrInvalidCredentials},
+		Security: session,
+	}, shared.Api.Auth.AuthorizeUserMiddleware(cache), api.UpdateUserPassword)
 }
 
 func (api *userUserApi) RegisterWithEmailAndPassword(ctx *fiber.Ctx) error {
@@ -53,7 +102,7 @@ func (api *userUserApi) RegisterWithEmailAndPassword(ctx *fiber.Ctx) error {
 	}
 	_, _ = api.usecases.AuthUseCase.CreateAndSendOTP("email", "verify", user.Email)
 	token, err := api.usecases.AuthUseCase.CreateUserToken(user)
-	shared.Api.Auth.InitSession(ctx, token, api.toolkit.Conf.GetInt("app.auth.tokenTTL"))
+	shared.Api.Auth.InitSession(ctx, token, int(api.usecases.AuthUseCase.TokenTTL().Seconds()))
 	if err != nil {
 		return err
 	}
@@ -61,13 +110,115 @@ func (api *userUserApi) RegisterWithEmailAndPassword(ctx *fiber.Ctx) error {
 }
 
 func (api *userUserApi) VerifyUserEmailSendOtp(ctx *fiber.Ctx) error {
-	user, err := shared.Api.Auth.GetUserFromContext(ctx.UserContext())
+	principal, err := auth.Current(ctx.UserContext())
 	if err != nil {
 		return err
 	}
-	_, err := api.usecases.AuthUseCase.CreateAndSendOTP("email", "verify", user.Email)
+	user, err := api.usecases.UserUseCase.GetUserByID(principal.ID)
 	if err != nil {
 		return err
 	}
+	_, err = api.usecases.AuthUseCase.CreateAndSendOTP("email", "verify", user.Email)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(nil))
+}
+
+func (api *userUserApi) VerifyUserEmailValidateOtp(ctx *fiber.Ctx) error {
+	body := &SubmitOTPRequest{}
+	if err := ctx.BodyParser(body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	if err := api.toolkit.Validator.ValidateStruct(body); err != nil {
+		fields := api.toolkit.Validator.GetValidationErrors(err)
+		return errs.NewValidationError("Invalid request body", fields)
+	}
+	return api.verifyUserEmail(ctx, func(email string) error {
+		return api.usecases.AuthUseCase.VerifyOTP(email, body.Otp)
+	})
+}
+
+func (api *userUserApi) VerifyUserEmailValidateHash(ctx *fiber.Ctx) error {
+	hash := ctx.Params("hash")
+	return api.verifyUserEmail(ctx, func(email string) error {
+		return api.usecases.AuthUseCase.VerifyOTPHash(email, hash)
+	})
+}
+
+// verifyUserEmail marks the email of the current user verified once verify accepts it.
+func (api *userUserApi) verifyUserEmail(ctx *fiber.Ctx, verify func(email string) error) error {
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	user, err := api.usecases.UserUseCase.GetUserByID(principal.ID)
+	if err != nil {
+		return err
+	}
+	if err := verify(user.Email); err != nil {
+		return err
+	}
+	if _, err := api.usecases.UserUseCase.SetUserEmailVerified(user.ID, true); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(nil))
+}
+
+func (api *userUserApi) UpdateUser(ctx *fiber.Ctx) error {
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	body := &usecase.UpdateUserStruct{}
+	if err := ctx.BodyParser(body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	user, err := api.usecases.UserUseCase.UpdateUser(principal.ID, body)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(user))
+}
+
+func (api *userUserApi) UpdateUserEmail(ctx *fiber.Ctx) error {
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	body := &UpdateUserEmailRequest{}
+	if err := ctx.BodyParser(body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	if err := api.toolkit.Validator.ValidateStruct(body); err != nil {
+		fields := api.toolkit.Validator.GetValidationErrors(err)
+		return errs.NewValidationError("Invalid request body", fields)
+	}
+	user, err := api.usecases.UserUseCase.UpdateUserEmail(principal.ID, body.Email)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(user))
+}
+
+func (api *userUserApi) UpdateUserPassword(ctx *fiber.Ctx) error {
+	principal, err := auth.Current(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	body := &UpdateUserPasswordRequest{}
+	if err := ctx.BodyParser(body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	if err := api.toolkit.Validator.ValidateStruct(body); err != nil {
+		fields := api.toolkit.Validator.GetValidationErrors(err)
+		return errs.NewValidationError("Invalid request body", fields)
+	}
+	if err := api.usecases.AuthUseCase.VerifyUserPassword(principal.ID, body.CurrentPassword); err != nil {
+		return err
+	}
+	if _, err := api.usecases.UserUseCase.UpdateUserPassword(principal.ID, body.Password); err != nil {
+		return err
+	}
 	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(nil))
 }
@@ -1,8 +1,32 @@
 package userapi
 
+import (
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/abdelrahman146/zard/shared/ratelimit"
+	"time"
+)
+
+// Rate limits of the unauthenticated routes, each can be overridden with
+// app.rateLimit.<name>.limit and app.rateLimit.<name>.window.
+var (
+	loginRateLimit     = ratelimit.Policy{Name: "login", Limit: 10, Window: time.Minute}
+	otpRateLimit       = ratelimit.Policy{Name: "otp", Limit: 3, Window: 5 * time.Minute}
+	otpVerifyRateLimit = ratelimit.Policy{Name: "otpVerify", Limit: 10, Window: 5 * time.Minute}
+	registerRateLimit  = ratelimit.Policy{Name: "register", Limit: 5, Window: time.Hour}
+)
+
+func newRateLimiter(toolkit *shared.Toolkit, policy ratelimit.Policy) ratelimit.Limiter {
+	policy, err := ratelimit.LoadPolicy(toolkit.Conf, policy)
+	if err != nil {
+		logger.GetLogger().Panic("invalid rate limit configuration", logger.Field("error", err))
+	}
+	return ratelimit.NewLimiter(toolkit.Cache, policy)
+}
+
 type LoginWithEmailAndPasswordRequest struct {
 	Email    string `json:"email,omitempty" validate:"required,email"`
-	Password string `json:"password,omitempty" validate:"required"`
+	Password string `json:"password,omitempty" validate:"required" sensitive:"true"`
 }
 
 type GenerateOTPForEmailRequest struct {
@@ -15,5 +39,31 @@ type GenerateOTPForPhoneRequest struct {
 
 type VerifyOTPForEmailRequest struct {
 	Value string `json:"value,omitempty" validate:"required"`
-	Otp   string `json:"otp,omitempty" validate:"required"`
+	Otp   string `json:"otp,omitempty" validate:"required" sensitive:"true"`
+}
+
+type SubmitOTPRequest struct {
+	Otp string `json:"otp,omitempty" validate:"required" sensitive:"true"`
+}
+
+type UpdateUserEmailRequest struct {
+	Email string `json:"email,omitempty" validate:"required,email"`
+}
+
+type UpdateUserPasswordRequest struct {
+	CurrentPassword string `json:"currentPassword,omitempty" validate:"required" sensitive:"true"`
+	Password        string `json:"password,omitempty" validate:"required,min=8" sensitive:"true"`
+}
+
+type ChangeMemberRoleRequest struct {
+	Role string `json:"role,omitempty" validate:"required"`
+}
+
+// ListQuery documents the query parameters of list routes, see query.Parse.
+type ListQuery struct {
+	Page   int    `query:"page" validate:"omitempty,min=1"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+	Sort   string `query:"sort"`
+	Q      string `query:"q"`
 }
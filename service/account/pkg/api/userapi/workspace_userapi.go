@@ -0,0 +1,153 @@
+package userapi
+
+import (
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/service/account/pkg/repo"
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/api/openapi"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/query"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WorkspaceUserApi interface {
+	Setup(app *fiber.App)
+	GetWorkspaces(ctx *fiber.Ctx) error
+	CreateWorkspace(ctx *fiber.Ctx) error
+	GetWorkspace(ctx *fiber.Ctx) error
+	UpdateWorkspace(ctx *fiber.Ctx) error
+	DeleteWorkspace(ctx *fiber.Ctx) error
+	ResetApiKey(ctx *fiber.Ctx) error
+}
+
+func NewWorkspaceUserApi(app *fiber.App, toolkit *shared.Toolkit, workspaces usecase.WorkspaceUseCase) {
+	api := &workspaceUserApi{
+		toolkit:    toolkit,
+		workspaces: workspaces,
+	}
+	api.Setup(app)
+}
+
+type workspaceUserApi struct {
+	toolkit    *shared.Toolkit
+	workspaces usecase.WorkspaceUseCase
+}
+
+// Setup registers the routes managing the workspaces of an organization. Each route requires a
+// permission on the workspace of its path, which has to be in the organization of the path.
+func (api *workspaceUserApi) Setup(app *fiber.App) {
+	v1group := app.Group("/v1/orgs/:orgId/workspaces")
+	authorize := shared.Api.Auth.AuthorizeUserMiddleware(api.toolkit.Cache)
+	requirePermission := shared.Api.Auth.RequirePermission
+	session := shared.Api.Docs.SessionSecurity()
+	forbidden := openapi.Kind(errs.ErrForbidden)
+	openapi.Get(v1group, "/", openapi.Operation{
+		Summary:  "List the workspaces of the organization",
+		Tags:     []string{"workspace"},
+		Query:    ListQuery{},
+		Result:   shared.List[model.Workspace]{},
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermWorkspaceRead), api.GetWorkspaces)
+	openapi.Post(v1group, "/", openapi.Operation{
+		Summary:  "Create a workspace",
+		Tags:     []string{"workspace"},
+		Body:     usecase.CreateWorkspaceStruct{},
+		Result:   model.Workspace{},
+		Status:   fiber.StatusCreated,
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermWorkspaceCreate), api.CreateWorkspace)
+	openapi.Get(v1group, "/:workspaceId", openapi.Operation{
+		Summary:  "Get a workspace",
+		Tags:     []string{"workspace"},
+		Result:   model.Workspace{},
+		Errors:   []errs.Entry{forbidden, openapi.Kind(errs.ErrNotFound)},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermWorkspaceRead), api.GetWorkspace)
+	openapi.Put(v1group, "/:workspaceId", openapi.Operation{
+		Summary:  "Update a workspace",
+		Tags:     []string{"workspace"},
+		Body:     usecase.UpdateWorkspaceStruct{},
+		Result:   model.Workspace{},
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermWorkspaceUpdate), api.UpdateWorkspace)
+	openapi.Delete(v1group, "/:workspaceId", openapi.Operation{
+		Summary:  "Delete a workspace",
+		Tags:     []string{"workspace"},
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermWorkspaceDelete), api.DeleteWorkspace)
+	openapi.Post(v1group, "/:workspaceId/apikey", openapi.Operation{
+		Summary:     "Reset the API key of a workspace",
+		Description: "The previous API key stops working at once.",
+		Tags:        []string{"workspace"},
+		Result: struct {
+			ApiKey string `json:"apiKey"`
+		}{},
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission(usecase.PermWorkspaceApiKeyReset), api.ResetApiKey)
+}
+
+func (api *workspaceUserApi) GetWorkspaces(ctx *fiber.Ctx) error {
+	spec, err := query.FromRequest(ctx, repo.WorkspaceListOptions)
+	if err != nil {
+		return err
+	}
+	workspaces, err := api.workspaces.GetAllByOrgID(ctx.Params("orgId"), spec)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(workspaces))
+}
+
+func (api *workspaceUserApi) CreateWorkspace(ctx *fiber.Ctx) error {
+	body := &usecase.CreateWorkspaceStruct{}
+	if err := ctx.BodyParser(body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	body.OrgID = ctx.Params("orgId")
+	workspace, err := api.workspaces.CreateWorkSpace(body)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(shared.Api.Response.NewSuccessResponse(workspace))
+}
+
+func (api *workspaceUserApi) GetWorkspace(ctx *fiber.Ctx) error {
+	workspace, err := api.workspaces.GetWorkSpaceByID(ctx.Params("workspaceId"))
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(workspace))
+}
+
+func (api *workspaceUserApi) UpdateWorkspace(ctx *fiber.Ctx) error {
+	body := &usecase.UpdateWorkspaceStruct{}
+	if err := ctx.BodyParser(body); err != nil {
+		return errs.NewBadRequestError("Request body is not provided or invalid", err)
+	}
+	workspace, err := api.workspaces.UpdateWorkSpace(ctx.Params("workspaceId"), body)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(workspace))
+}
+
+func (api *workspaceUserApi) DeleteWorkspace(ctx *fiber.Ctx) error {
+	if err := api.workspaces.DeleteWorkSpace(ctx.Params("workspaceId")); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(nil))
+}
+
+func (api *workspaceUserApi) ResetApiKey(ctx *fiber.Ctx) error {
+	apiKey, err := api.workspaces.ResetApiKey(ctx.Params("workspaceId"))
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(shared.Api.Response.NewSuccessResponse(fiber.Map{"apiKey": apiKey}))
+}
@@ -0,0 +1,64 @@
+package userapi
+
+import (
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/api"
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeWorkspaceUseCase struct {
+	usecase.WorkspaceUseCase
+	deleted []string
+}
+
+func (f *fakeWorkspaceUseCase) GetWorkSpaceByID(id string) (*model.Workspace, error) {
+	return &model.Workspace{ID: id, OrgID: "org_1"}, nil
+}
+
+func (f *fakeWorkspaceUseCase) DeleteWorkSpace(id string) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func TestWorkspaceUserApi_RequiresPermissions(t *testing.T) {
+	c := cache.NewMemoryCache()
+	sessions := map[string]string{
+		"admin":  `{"id":"usr_1","orgId":"org_1","workspaceId":"wrk_1","role":"admin"}`,
+		"viewer": `{"id":"usr_2","orgId":"org_1","workspaceId":"wrk_1","role":"viewer"}`,
+		"other":  `{"id":"usr_3","orgId":"org_2","workspaceId":"wrk_1","role":"owner"}`,
+	}
+	for token, s := range sessions {
+		require.NoError(t, c.Set([]string{"account", "auth", "user", "tokens", token}, []byte(s), time.Minute))
+	}
+	workspaces := &fakeWorkspaceUseCase{}
+	app := fiber.New(fiber.Config{ErrorHandler: shared.Api.Response.ErrorHandler(api.ErrorHandlerConfig{})})
+	NewWorkspaceUserApi(app, &shared.Toolkit{Cache: c}, workspaces)
+
+	for _, tc := range []struct {
+		method, token string
+		status        int
+	}{
+		{fiber.MethodGet, "", fiber.StatusUnauthorized},
+		{fiber.MethodGet, "viewer", fiber.StatusOK},
+		{fiber.MethodDelete, "viewer", fiber.StatusForbidden},
+		{fiber.MethodGet, "other", fiber.StatusForbidden},
+		{fiber.MethodDelete, "admin", fiber.StatusOK},
+	} {
+		req := httptest.NewRequest(tc.method, "/v1/orgs/org_1/workspaces/wrk_1", nil)
+		if tc.token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tc.token)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, tc.status, resp.StatusCode, "%s as %q", tc.method, tc.token)
+	}
+	assert.Equal(t, []string{"wrk_1"}, workspaces.deleted)
+}
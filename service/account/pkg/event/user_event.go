@@ -1,6 +1,7 @@
 package event
 
 import (
+	"context"
 	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
 	"github.com/abdelrahman146/zard/shared"
 	"github.com/abdelrahman146/zard/shared/pubsub/messages"
@@ -23,6 +24,6 @@ type userEvent struct {
 	usecases *usecase.AccountUseCases
 }
 
-func (e *userEvent) UserCreated(received []byte) error {
+func (e *userEvent) UserCreated(ctx context.Context, received []byte) error {
 	return nil
 }
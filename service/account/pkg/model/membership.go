@@ -0,0 +1,23 @@
+package model
+
+import (
+	"github.com/abdelrahman146/zard/shared"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Membership gives a user a role in an organization, or in one of its workspaces.
+type Membership struct {
+	ID          string    `json:"id" gorm:"column:id;type:text;primaryKey"`
+	UserID      string    `json:"userId" gorm:"column:userId;type:text;not null;uniqueIndex:idx_memberships_user_scope"`
+	OrgID       string    `json:"orgId" gorm:"column:orgId;type:text;not null;uniqueIndex:idx_memberships_user_scope"`
+	WorkspaceID string    `json:"workspaceId" gorm:"column:workspaceId;type:text;not null;default:'';uniqueIndex:idx_memberships_user_scope"` // empty for the whole organization
+	Role        string    `json:"role" gorm:"column:role;type:text;not null"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"column:createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" gorm:"column:updatedAt"`
+}
+
+func (m *Membership) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = "mbr_" + shared.Utils.Strings.Cuid()
+	return
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"github.com/abdelrahman146/zard/shared"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Role is a custom role of an organization, on top of the built-in roles of auth.DefaultRoles.
+type Role struct {
+	ID          string    `json:"id" gorm:"column:id;type:text;primaryKey"`
+	OrgID       string    `json:"orgId" gorm:"column:orgId;type:text;not null;uniqueIndex:idx_roles_org_name"`
+	Name        string    `json:"name" gorm:"column:name;type:text;not null;uniqueIndex:idx_roles_org_name"`
+	Permissions []string  `json:"permissions" gorm:"column:permissions;type:jsonb;serializer:json"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"column:createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" gorm:"column:updatedAt"`
+}
+
+func (r *Role) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = "rol_" + shared.Utils.Strings.Cuid()
+	return
+}
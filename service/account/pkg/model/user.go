@@ -11,7 +11,7 @@ type User struct {
 	Name            string         `json:"name" gorm:"column:name;type:text;not null"`
 	Email           string         `json:"email" gorm:"column:email;uniqueIndex;type:text;not null"`
 	Phone           *string        `json:"phone" gorm:"column:phone;uniqueIndex;type:text"`
-	Password        *string        `json:"password" gorm:"column:website;type:text"`
+	Password        *string        `json:"password" gorm:"column:website;type:text" sensitive:"true"`
 	IsEmailVerified bool           `json:"isEmailVerified" gorm:"column:isEmailVerified;type:boolean;default:false"`
 	IsPhoneVerified bool           `json:"isPhoneVerified" gorm:"column:isPhoneVerified;type:boolean;default:false"`
 	Active          bool           `json:"active" gorm:"column:active;type:boolean"`
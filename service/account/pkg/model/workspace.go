@@ -10,7 +10,7 @@ type Workspace struct {
 	ID           string         `json:"id" gorm:"column:id;type:text;primaryKey"`
 	Name         string         `json:"name" gorm:"column:name;uniqueIndex;type:text;not null"`
 	Website      *string        `json:"website" gorm:"column:website;type:text"`
-	ApiKey       string         `json:"apiKey" gorm:"column:apiKey;type:text;not null"`
+	ApiKey       string         `json:"apiKey" gorm:"column:apiKey;type:text;not null" sensitive:"true"`
 	Organization Organization   `json:"organization,omitempty" gorm:"foreignKey:OrgID;references:ID"`
 	OrgID        string         `json:"orgId" gorm:"column:orgId;type:text"`
 	CreatedAt    time.Time      `json:"createdAt" gorm:"column:createdAt"`
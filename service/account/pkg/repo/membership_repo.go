@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/shared/errs/dberr"
+	"github.com/abdelrahman146/zard/shared/query"
+	"gorm.io/gorm"
+)
+
+type MembershipRepo interface {
+	Create(membership *model.Membership) error
+	Save(membership *model.Membership) error
+	Delete(id string) error
+	GetOneByID(id string) (*model.Membership, error)
+	GetAllByUserID(userID string) ([]model.Membership, error)
+	GetAllByOrgID(orgID string, spec query.Spec) ([]model.Membership, int64, error)
+	GetAllByWorkspaceID(workspaceID string, spec query.Spec) ([]model.Membership, int64, error)
+	CountByRole(orgID string, role string) (int64, error)
+}
+
+// MembershipListOptions is what memberships can be sorted and filtered by.
+var MembershipListOptions = query.Options{
+	DefaultSort: "createdAt",
+	Sortable:    map[string]string{"id": "id", "role": "role", "createdAt": "createdAt", "updatedAt": "updatedAt"},
+	Filterable:  map[string]string{"userId": "userId", "workspaceId": "workspaceId", "role": "role"},
+}
+
+type membershipRepo struct {
+	db *gorm.DB
+}
+
+func NewMembershipRepo(db *gorm.DB) MembershipRepo {
+	return &membershipRepo{
+		db: db,
+	}
+}
+
+func (r *membershipRepo) Create(membership *model.Membership) error {
+	return dberr.Translate(r.db.Create(membership).Error)
+}
+
+func (r *membershipRepo) Save(membership *model.Membership) error {
+	return dberr.Translate(r.db.Save(membership).Error)
+}
+
+func (r *membershipRepo) Delete(id string) error {
+	return dberr.Translate(r.db.Delete(&model.Membership{}, "id = ?", id).Error)
+}
+
+func (r *membershipRepo) GetOneByID(id string) (*model.Membership, error) {
+	var membership model.Membership
+	if err := r.db.Where("id = ?", id).First(&membership).Error; err != nil {
+		return nil, dberr.Translate(err)
+	}
+	return &membership, nil
+}
+
+func (r *membershipRepo) GetAllByUserID(userID string) ([]model.Membership, error) {
+	var memberships []model.Membership
+	if err := r.db.Where(`"userId" = ?`, userID).Find(&memberships).Error; err != nil {
+		return nil, dberr.Translate(err)
+	}
+	return memberships, nil
+}
+
+func (r *membershipRepo) GetAllByOrgID(orgID string, spec query.Spec) ([]model.Membership, int64, error) {
+	var memberships []model.Membership
+	var total int64
+	if err := r.db.Model(&model.Membership{}).Where(`"orgId" = ?`, orgID).Scopes(query.Where(spec)).Count(&total).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
+	}
+	if err := r.db.Where(`"orgId" = ?`, orgID).Scopes(query.Scope(spec)).Find(&memberships).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
+	}
+	return memberships, total, nil
+}
+
+func (r *membershipRepo) GetAllByWorkspaceID(workspaceID string, spec query.Spec) ([]model.Membership, int64, error) {
+	var memberships []model.Membership
+	var total int64
+	if err := r.db.Model(&model.Membership{}).Where(`"workspaceId" = ?`, workspaceID).Scopes(query.Where(spec)).Count(&total).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
+	}
+	if err := r.db.Where(`"workspaceId" = ?`, workspaceID).Scopes(query.Scope(spec)).Find(&memberships).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
+	}
+	return memberships, total, nil
+}
+
+func (r *membershipRepo) CountByRole(orgID string, role string) (int64, error) {
+	var total int64
+	if err := r.db.Model(&model.Membership{}).Where(`"orgId" = ? AND role = ?`, orgID, role).Count(&total).Error; err != nil {
+		return 0, dberr.Translate(err)
+	}
+	return total, nil
+}
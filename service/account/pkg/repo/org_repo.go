@@ -3,6 +3,8 @@ package repo
 import (
 	"github.com/abdelrahman146/zard/service/account/pkg/model"
 	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/abdelrahman146/zard/shared/errs/dberr"
+	"github.com/abdelrahman146/zard/shared/query"
 	"gorm.io/gorm"
 )
 
@@ -13,11 +15,18 @@ type OrgRepo interface {
 	GetOneByID(id string) (*model.Organization, error)
 	GetOneByName(name string) (*model.Organization, error)
 	GetOneByEmail(email string) (*model.Organization, error)
-	Search(keyword string, page int, limit int) ([]model.Organization, int64, error)
-	GetAll(page int, limit int) ([]model.Organization, int64, error)
+	GetAll(spec query.Spec) ([]model.Organization, int64, error)
 	Total() (int64, error)
 }
 
+// OrgListOptions is what organizations can be sorted, filtered and searched by.
+var OrgListOptions = query.Options{
+	DefaultSort: "-createdAt",
+	Sortable:    map[string]string{"id": "id", "name": "name", "email": "email", "country": "country", "createdAt": "createdAt", "updatedAt": "updatedAt"},
+	Filterable:  map[string]string{"country": "country", "city": "city"},
+	Searchable:  []string{"name", "email", "phone", "id"},
+}
+
 type orgRepo struct {
 	db   *gorm.DB
 	conf config.Config
@@ -31,21 +40,21 @@ func NewOrgRepo(db *gorm.DB, conf config.Config) OrgRepo {
 }
 
 func (r *orgRepo) Create(org *model.Organization) error {
-	return r.db.Create(org).Error
+	return dberr.Translate(r.db.Create(org).Error)
 }
 
 func (r *orgRepo) Save(org *model.Organization) error {
-	return r.db.Save(org).Error
+	return dberr.Translate(r.db.Save(org).Error)
 }
 
 func (r *orgRepo) Delete(id string) error {
-	return r.db.Delete(&model.Organization{}, "id = ?", id).Error
+	return dberr.Translate(r.db.Delete(&model.Organization{}, "id = ?", id).Error)
 }
 
 func (r *orgRepo) GetOneByID(id string) (*model.Organization, error) {
 	var org model.Organization
 	if err := r.db.Where("id = ?", id).First(&org).Error; err != nil {
-		return nil, err
+		return nil, dberr.Translate(err)
 	}
 	return &org, nil
 }
@@ -53,7 +62,7 @@ func (r *orgRepo) GetOneByID(id string) (*model.Organization, error) {
 func (r *orgRepo) GetOneByName(name string) (*model.Organization, error) {
 	var org model.Organization
 	if err := r.db.Where("name = ?", name).First(&org).Error; err != nil {
-		return nil, err
+		return nil, dberr.Translate(err)
 	}
 	return &org, nil
 }
@@ -61,32 +70,19 @@ func (r *orgRepo) GetOneByName(name string) (*model.Organization, error) {
 func (r *orgRepo) GetOneByEmail(email string) (*model.Organization, error) {
 	var org model.Organization
 	if err := r.db.Where("email = ?", email).First(&org).Error; err != nil {
-		return nil, err
+		return nil, dberr.Translate(err)
 	}
 	return &org, nil
 }
 
-func (r *orgRepo) Search(keyword string, page int, limit int) ([]model.Organization, int64, error) {
-	var orgs []model.Organization
-	var total int64
-	query := r.db.Model(&model.Organization{}).Where("name LIKE ?", "%"+keyword+"%").Or("email LIKE ?", "%"+keyword+"%").Or("phone LIKE ?", "%"+keyword+"%").Or("id LIKE ?", "%"+keyword+"%")
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-	if err := query.Offset((page - 1) * limit).Limit(limit).Find(&orgs).Error; err != nil {
-		return nil, 0, err
-	}
-	return orgs, total, nil
-}
-
-func (r *orgRepo) GetAll(page int, limit int) ([]model.Organization, int64, error) {
+func (r *orgRepo) GetAll(spec query.Spec) ([]model.Organization, int64, error) {
 	var orgs []model.Organization
 	var total int64
-	if err := r.db.Model(&model.Organization{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Model(&model.Organization{}).Scopes(query.Where(spec)).Count(&total).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
-	if err := r.db.Offset((page - 1) * limit).Limit(limit).Find(&orgs).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Scopes(query.Scope(spec)).Find(&orgs).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
 	return orgs, total, nil
 }
@@ -94,7 +90,7 @@ func (r *orgRepo) GetAll(page int, limit int) ([]model.Organization, int64, erro
 func (r *orgRepo) Total() (int64, error) {
 	var total int64
 	if err := r.db.Model(&model.Organization{}).Count(&total).Error; err != nil {
-		return 0, err
+		return 0, dberr.Translate(err)
 	}
 	return total, nil
 }
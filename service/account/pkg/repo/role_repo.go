@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/shared/errs/dberr"
+	"gorm.io/gorm"
+)
+
+type RoleRepo interface {
+	Create(role *model.Role) error
+	Save(role *model.Role) error
+	Delete(id string) error
+	GetOneByID(id string) (*model.Role, error)
+	GetOneByName(orgID string, name string) (*model.Role, error)
+	GetAllByOrgID(orgID string) ([]model.Role, error)
+}
+
+type roleRepo struct {
+	db *gorm.DB
+}
+
+func NewRoleRepo(db *gorm.DB) RoleRepo {
+	return &roleRepo{
+		db: db,
+	}
+}
+
+func (r *roleRepo) Create(role *model.Role) error {
+	return dberr.Translate(r.db.Create(role).Error)
+}
+
+func (r *roleRepo) Save(role *model.Role) error {
+	return dberr.Translate(r.db.Save(role).Error)
+}
+
+func (r *roleRepo) Delete(id string) error {
+	return dberr.Translate(r.db.Delete(&model.Role{}, "id = ?", id).Error)
+}
+
+func (r *roleRepo) GetOneByID(id string) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.Where("id = ?", id).First(&role).Error; err != nil {
+		return nil, dberr.Translate(err)
+	}
+	return &role, nil
+}
+
+func (r *roleRepo) GetOneByName(orgID string, name string) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.Where(`"orgId" = ? AND name = ?`, orgID, name).First(&role).Error; err != nil {
+		return nil, dberr.Translate(err)
+	}
+	return &role, nil
+}
+
+func (r *roleRepo) GetAllByOrgID(orgID string) ([]model.Role, error) {
+	var roles []model.Role
+	if err := r.db.Where(`"orgId" = ?`, orgID).Order("name").Find(&roles).Error; err != nil {
+		return nil, dberr.Translate(err)
+	}
+	return roles, nil
+}
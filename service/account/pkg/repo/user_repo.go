@@ -3,7 +3,10 @@ package repo
 import (
 	"github.com/abdelrahman146/zard/service/account/pkg/model"
 	"github.com/abdelrahman146/zard/shared/cache"
-	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/errs/dberr"
+	"github.com/abdelrahman146/zard/shared/query"
+	"github.com/abdelrahman146/zard/shared/secrets"
 	"github.com/abdelrahman146/zard/shared/utils"
 	"gorm.io/gorm"
 )
@@ -16,56 +19,74 @@ type UserRepo interface {
 	GetOneByID(id string) (*model.User, error)
 	GetOneByEmail(email string) (*model.User, error)
 	GetOneByPhone(phone string) (*model.User, error)
-	Search(keyword string, page int, limit int) ([]model.User, int64, error)
-	GetAll(page int, limit int) ([]model.User, int64, error)
-	GetAllByOrgID(orgID string, page int, limit int) ([]model.User, int64, error)
+	GetAllByIDs(ids []string) ([]model.User, error)
+	GetAll(spec query.Spec) ([]model.User, int64, error)
+	GetAllByOrgID(orgID string, spec query.Spec) ([]model.User, int64, error)
 	Total() (int64, error)
 }
 
+// UserListOptions is what users can be sorted, filtered and searched by.
+var UserListOptions = query.Options{
+	DefaultSort: "-createdAt",
+	Sortable:    map[string]string{"id": "id", "name": "name", "email": "email", "createdAt": "createdAt", "updatedAt": "updatedAt"},
+	Filterable:  map[string]string{"orgId": "orgId", "active": "active", "isEmailVerified": "isEmailVerified", "isPhoneVerified": "isPhoneVerified"},
+	Searchable:  []string{"name", "email", "phone"},
+}
+
 type userRepo struct {
 	db          *gorm.DB
 	cacheClient cache.Cache
-	conf        config.Config
+	secrets     secrets.Provider
 }
 
-func NewUserRepo(db *gorm.DB, cacheClient cache.Cache, conf config.Config) UserRepo {
+func NewUserRepo(db *gorm.DB, cacheClient cache.Cache, secretsProvider secrets.Provider) UserRepo {
 	return &userRepo{
 		db:          db,
 		cacheClient: cacheClient,
-		conf:        conf,
+		secrets:     secretsProvider,
 	}
 }
 
-func (r *userRepo) hashPassword(password *string) *string {
-	if password != nil {
-		hashedPassword := utils.Utils.Auth.Encrypt(*password, r.conf.GetString("app.secret"))
-		password = &hashedPassword
+// hashPassword hashes password with the current app.secret, which authUseCase verifies it against.
+func (r *userRepo) hashPassword(password string) (string, error) {
+	secret, err := secrets.Current(r.secrets, "app.secret")
+	if err != nil {
+		return "", errs.NewInternalError("unable to hash password", err)
 	}
-	return password
+	return utils.Utils.Auth.Encrypt(password, secret), nil
 }
 
 func (r *userRepo) Create(user *model.User) error {
-	user.Password = r.hashPassword(user.Password)
-	return r.db.Create(user).Error
+	if user.Password != nil {
+		hashedPassword, err := r.hashPassword(*user.Password)
+		if err != nil {
+			return err
+		}
+		user.Password = &hashedPassword
+	}
+	return dberr.Translate(r.db.Create(user).Error)
 }
 
 func (r *userRepo) Save(user *model.User) error {
-	return r.db.Save(user).Error
+	return dberr.Translate(r.db.Save(user).Error)
 }
 
 func (r *userRepo) UpdatePassword(id string, password string) error {
-	hashedPassword := utils.Utils.Auth.Encrypt(password, r.conf.GetString("app.secret"))
-	return r.db.Model(&model.User{}).Where("id = ?", id).Update("password", hashedPassword).Error
+	hashedPassword, err := r.hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return dberr.Translate(r.db.Model(&model.User{}).Where("id = ?", id).Update("password", hashedPassword).Error)
 }
 
 func (r *userRepo) Delete(id string) error {
-	return r.db.Delete(&model.User{}, "id = ?", id).Error
+	return dberr.Translate(r.db.Delete(&model.User{}, "id = ?", id).Error)
 }
 
 func (r *userRepo) GetOneByID(id string) (*model.User, error) {
 	var user model.User
 	if err := r.db.Where("id = ?", id).First(&user).Error; err != nil {
-		return nil, err
+		return nil, dberr.Translate(err)
 	}
 	return &user, nil
 }
@@ -73,7 +94,7 @@ func (r *userRepo) GetOneByID(id string) (*model.User, error) {
 func (r *userRepo) GetOneByEmail(email string) (*model.User, error) {
 	var user model.User
 	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
-		return nil, err
+		return nil, dberr.Translate(err)
 	}
 	return &user, nil
 }
@@ -81,44 +102,39 @@ func (r *userRepo) GetOneByEmail(email string) (*model.User, error) {
 func (r *userRepo) GetOneByPhone(phone string) (*model.User, error) {
 	var user model.User
 	if err := r.db.Where("phone = ?", phone).First(&user).Error; err != nil {
-		return nil, err
+		return nil, dberr.Translate(err)
 	}
 	return &user, nil
 }
 
-func (r *userRepo) Search(keyword string, page int, limit int) ([]model.User, int64, error) {
+func (r *userRepo) GetAllByIDs(ids []string) ([]model.User, error) {
 	var users []model.User
-	var total int64
-	query := r.db.Model(&model.User{}).Where("name LIKE ?", "%"+keyword+"%").Or("email LIKE ?", "%"+keyword+"%").Or("phone LIKE ?", "%"+keyword+"%")
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-	if err := query.Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, dberr.Translate(err)
 	}
-	return users, total, nil
+	return users, nil
 }
 
-func (r *userRepo) GetAll(page int, limit int) ([]model.User, int64, error) {
+func (r *userRepo) GetAll(spec query.Spec) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
-	if err := r.db.Model(&model.User{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Model(&model.User{}).Scopes(query.Where(spec)).Count(&total).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
-	if err := r.db.Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Scopes(query.Scope(spec)).Find(&users).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
 	return users, total, nil
 }
 
-func (r *userRepo) GetAllByOrgID(orgID string, page int, limit int) ([]model.User, int64, error) {
+func (r *userRepo) GetAllByOrgID(orgID string, spec query.Spec) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
-	if err := r.db.Model(&model.User{}).Where("orgId = ?", orgID).Count(&total).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Model(&model.User{}).Where(`"orgId" = ?`, orgID).Scopes(query.Where(spec)).Count(&total).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
-	if err := r.db.Where("orgId = ?", orgID).Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Where(`"orgId" = ?`, orgID).Scopes(query.Scope(spec)).Find(&users).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
 	return users, total, nil
 }
@@ -126,7 +142,7 @@ func (r *userRepo) GetAllByOrgID(orgID string, page int, limit int) ([]model.Use
 func (r *userRepo) Total() (int64, error) {
 	var total int64
 	if err := r.db.Model(&model.User{}).Count(&total).Error; err != nil {
-		return 0, err
+		return 0, dberr.Translate(err)
 	}
 	return total, nil
 }
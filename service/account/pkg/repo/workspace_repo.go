@@ -3,7 +3,10 @@ package repo
 import (
 	"github.com/abdelrahman146/zard/service/account/pkg/model"
 	"github.com/abdelrahman146/zard/shared"
-	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/errs/dberr"
+	"github.com/abdelrahman146/zard/shared/query"
+	"github.com/abdelrahman146/zard/shared/secrets"
 	"gorm.io/gorm"
 )
 
@@ -14,44 +17,64 @@ type WorkspaceRepo interface {
 	Delete(id string) error
 	GetOneByID(id string) (*model.Workspace, error)
 	GetOneByApiKey(apiKey string) (*model.Workspace, error)
-	Search(keyword string, page int, limit int) ([]model.Workspace, int64, error)
-	GetAll(page int, limit int) ([]model.Workspace, int64, error)
-	GetAllByOrgID(orgID string, page int, limit int) ([]model.Workspace, int64, error)
+	GetAll(spec query.Spec) ([]model.Workspace, int64, error)
+	GetAllByOrgID(orgID string, spec query.Spec) ([]model.Workspace, int64, error)
 	Total() (int64, error)
 }
 
+// WorkspaceListOptions is what workspaces can be sorted, filtered and searched by.
+var WorkspaceListOptions = query.Options{
+	DefaultSort: "-createdAt",
+	Sortable:    map[string]string{"id": "id", "name": "name", "createdAt": "createdAt", "updatedAt": "updatedAt"},
+	Filterable:  map[string]string{"orgId": "orgId"},
+	Searchable:  []string{"name", "id"},
+}
+
 type workspaceRepo struct {
-	db   *gorm.DB
-	conf config.Config
+	db      *gorm.DB
+	secrets secrets.Provider
 }
 
-func NewWorkspaceRepo(db *gorm.DB, conf config.Config) WorkspaceRepo {
+func NewWorkspaceRepo(db *gorm.DB, secretsProvider secrets.Provider) WorkspaceRepo {
 	return &workspaceRepo{
-		db:   db,
-		conf: conf,
+		db:      db,
+		secrets: secretsProvider,
 	}
 }
 
-func (r *workspaceRepo) generateApiKey(workspace *model.Workspace) {
-	apikey := shared.Utils.Auth.CreateToken("zky", workspace.ID, r.conf.GetString("app.secret"))
-	workspace.ApiKey = shared.Utils.Auth.Encrypt(apikey, r.conf.GetString("app.secret"))
-}
-
-func (r *workspaceRepo) decryptApiKey(apiKey string) (string, error) {
-	return shared.Utils.Auth.Decrypt(apiKey, r.conf.GetString("app.secret"))
+func (r *workspaceRepo) generateApiKey(workspace *model.Workspace) error {
+	secret, err := secrets.Current(r.secrets, "app.secret")
+	if err != nil {
+		return errs.NewInternalError("unable to generate api key", err)
+	}
+	apikey := shared.Utils.Auth.CreateToken("zky", workspace.ID, secret)
+	workspace.ApiKey = shared.Utils.Auth.Encrypt(apikey, secret)
+	return nil
 }
 
-func (r *workspaceRepo) encryptApiKey(apiKey string) string {
-	return shared.Utils.Auth.Encrypt(apiKey, r.conf.GetString("app.secret"))
+// encryptApiKey returns apiKey encrypted with every version of app.secret, so that keys issued
+// before a rotation are still found.
+func (r *workspaceRepo) encryptApiKey(apiKey string) ([]string, error) {
+	versions, err := r.secrets.Get("app.secret")
+	if err != nil {
+		return nil, errs.NewInternalError("unable to encrypt api key", err)
+	}
+	var encrypted []string
+	for _, secret := range versions.All() {
+		encrypted = append(encrypted, shared.Utils.Auth.Encrypt(apiKey, secret.Value))
+	}
+	return encrypted, nil
 }
 
 func (r *workspaceRepo) Create(workspace *model.Workspace) error {
-	r.generateApiKey(workspace)
-	return r.db.Create(workspace).Error
+	if err := r.generateApiKey(workspace); err != nil {
+		return err
+	}
+	return dberr.Translate(r.db.Create(workspace).Error)
 }
 
 func (r *workspaceRepo) Save(workspace *model.Workspace) error {
-	return r.db.Save(workspace).Error
+	return dberr.Translate(r.db.Save(workspace).Error)
 }
 
 func (r *workspaceRepo) ResetApiKey(id string) (*model.Workspace, error) {
@@ -59,7 +82,9 @@ func (r *workspaceRepo) ResetApiKey(id string) (*model.Workspace, error) {
 	if err != nil {
 		return nil, err
 	}
-	r.generateApiKey(workspace)
+	if err = r.generateApiKey(workspace); err != nil {
+		return nil, err
+	}
 	err = r.Save(workspace)
 	if err != nil {
 		return nil, err
@@ -68,68 +93,57 @@ func (r *workspaceRepo) ResetApiKey(id string) (*model.Workspace, error) {
 }
 
 func (r *workspaceRepo) Delete(id string) error {
-	return r.db.Delete(&model.Workspace{}, "id = ?", id).Error
+	return dberr.Translate(r.db.Delete(&model.Workspace{}, "id = ?", id).Error)
 }
 
 func (r *workspaceRepo) GetOneByID(id string) (*model.Workspace, error) {
 	var workspace model.Workspace
 	if err := r.db.Where("id = ?", id).First(&workspace).Error; err != nil {
-		return nil, err
+		return nil, dberr.Translate(err)
 	}
 	return &workspace, nil
 }
 
 func (r *workspaceRepo) GetOneByApiKey(apiKey string) (*model.Workspace, error) {
 	var workspace model.Workspace
-	encryptedApiKey := r.encryptApiKey(apiKey)
-	if err := r.db.Where("apiKey = ?", encryptedApiKey).First(&workspace).Error; err != nil {
+	encryptedApiKeys, err := r.encryptApiKey(apiKey)
+	if err != nil {
 		return nil, err
 	}
-	return &workspace, nil
-}
-
-func (r *workspaceRepo) Search(keyword string, page int, limit int) ([]model.Workspace, int64, error) {
-	var workspaces []model.Workspace
-	var total int64
-	query := r.db.Where("name LIKE ?", "%"+keyword+"%").Or("id LIKE ?", "%"+keyword+"%")
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-	if err := query.Offset((page - 1) * limit).Limit(limit).Find(&workspaces).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Where(`"apiKey" IN ?`, encryptedApiKeys).First(&workspace).Error; err != nil {
+		return nil, dberr.Translate(err)
 	}
-	return workspaces, total, nil
+	return &workspace, nil
 }
 
-func (r *workspaceRepo) GetAll(page int, limit int) ([]model.Workspace, int64, error) {
+func (r *workspaceRepo) GetAll(spec query.Spec) ([]model.Workspace, int64, error) {
 	var workspaces []model.Workspace
 	var total int64
-	if err := r.db.Model(&model.Workspace{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Model(&model.Workspace{}).Scopes(query.Where(spec)).Count(&total).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
-	if err := r.db.Offset((page - 1) * limit).Limit(limit).Find(&workspaces).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Scopes(query.Scope(spec)).Find(&workspaces).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
 	return workspaces, total, nil
 }
 
-func (r *workspaceRepo) GetAllByOrgID(orgID string, page int, limit int) ([]model.Workspace, int64, error) {
+func (r *workspaceRepo) GetAllByOrgID(orgID string, spec query.Spec) ([]model.Workspace, int64, error) {
 	var workspaces []model.Workspace
 	var total int64
-	if err := r.db.Where("orgId = ?", orgID).Count(&total).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Model(&model.Workspace{}).Where(`"orgId" = ?`, orgID).Scopes(query.Where(spec)).Count(&total).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
-	if err := r.db.Where("orgId = ?", orgID).Offset((page - 1) * limit).Limit(limit).Find(&workspaces).Error; err != nil {
-		return nil, 0, err
+	if err := r.db.Where(`"orgId" = ?`, orgID).Scopes(query.Scope(spec)).Find(&workspaces).Error; err != nil {
+		return nil, 0, dberr.Translate(err)
 	}
 	return workspaces, total, nil
-
 }
 
 func (r *workspaceRepo) Total() (int64, error) {
 	var total int64
 	if err := r.db.Model(&model.Workspace{}).Count(&total).Error; err != nil {
-		return 0, err
+		return 0, dberr.Translate(err)
 	}
 	return total, nil
 }
@@ -0,0 +1,51 @@
+package rpcapi
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/rpc"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+)
+
+type OrgRpcApi interface {
+	Setup() error
+	GetOrganization(ctx context.Context, req *requests.GetOrganizationRequest) (interface{}, error)
+}
+
+func NewOrgRpcApi(toolkit *shared.Toolkit, usecases *usecase.AccountUseCases) error {
+	api := &orgRpcApi{
+		toolkit:  toolkit,
+		usecases: usecases,
+	}
+	return api.Setup()
+}
+
+type orgRpcApi struct {
+	toolkit  *shared.Toolkit
+	usecases *usecase.AccountUseCases
+}
+
+func (api *orgRpcApi) Setup() error {
+	return api.toolkit.Rpc.Handle(&requests.GetOrganizationRequest{}, rpc.Handler(api.GetOrganization))
+}
+
+func (api *orgRpcApi) GetOrganization(ctx context.Context, req *requests.GetOrganizationRequest) (interface{}, error) {
+	if err := validate(api.toolkit, req); err != nil {
+		return nil, err
+	}
+	org, err := api.usecases.OrgUseCase.GetOrgByID(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &requests.GetOrganizationResponse{
+		ID:      org.ID,
+		Name:    org.Name,
+		Website: org.Website,
+		Email:   org.Email,
+		Phone:   org.Phone,
+		Country: org.Country,
+		City:    org.City,
+		Address: org.Address,
+	}, nil
+}
@@ -0,0 +1,29 @@
+package rpcapi
+
+import (
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/errs"
+)
+
+// HandleAccountRequests serves the cross-service lookups other services use to resolve identities.
+func HandleAccountRequests(toolkit *shared.Toolkit, usecases *usecase.AccountUseCases) error {
+	if err := NewUserRpcApi(toolkit, usecases); err != nil {
+		return err
+	}
+	if err := NewWorkspaceRpcApi(toolkit, usecases); err != nil {
+		return err
+	}
+	if err := NewOrgRpcApi(toolkit, usecases); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validate(toolkit *shared.Toolkit, req interface{}) error {
+	if err := toolkit.Validator.ValidateStruct(req); err != nil {
+		fields := toolkit.Validator.GetValidationErrors(err)
+		return errs.NewValidationError("Invalid request", fields)
+	}
+	return nil
+}
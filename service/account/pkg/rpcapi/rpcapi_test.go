@@ -0,0 +1,152 @@
+package rpcapi
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/rpc"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+	"github.com/abdelrahman146/zard/shared/validator"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type fakeUserUseCase struct {
+	usecase.UserUseCase
+	users map[string]usecase.UserStruct
+}
+
+func (f *fakeUserUseCase) GetUserByID(id string) (*usecase.UserStruct, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, errs.NewNotFoundError("User not found", nil)
+	}
+	return &user, nil
+}
+
+func (f *fakeUserUseCase) GetUsersByIDs(ids []string) ([]usecase.UserStruct, error) {
+	var users []usecase.UserStruct
+	for _, id := range ids {
+		if user, ok := f.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+type fakeWorkspaceUseCase struct {
+	usecase.WorkspaceUseCase
+	workspaces map[string]model.Workspace
+}
+
+func (f *fakeWorkspaceUseCase) GetWorkSpaceByID(id string) (*model.Workspace, error) {
+	ws, ok := f.workspaces[id]
+	if !ok {
+		return nil, errs.NewNotFoundError("workspace not found", nil)
+	}
+	return &ws, nil
+}
+
+type fakeOrgUseCase struct {
+	usecase.OrgUseCase
+	orgs map[string]model.Organization
+}
+
+func (f *fakeOrgUseCase) GetOrgByID(id string) (*model.Organization, error) {
+	org, ok := f.orgs[id]
+	if !ok {
+		return nil, errs.NewNotFoundError("Organization not found", nil)
+	}
+	return &org, nil
+}
+
+type fakeAuthUseCase struct {
+	usecase.AuthUseCase
+	apiKeys map[string]string
+}
+
+func (f *fakeAuthUseCase) AuthenticateWorkspaceByApiKey(apiKey string) (string, error) {
+	id, ok := f.apiKeys[apiKey]
+	if !ok {
+		return "", errs.NewUnauthorizedError("invalid api key", nil)
+	}
+	return id, nil
+}
+
+func setup(t *testing.T) rpc.RPC {
+	bus := rpc.NewMemoryBus()
+	v := validator.NewValidator()
+	toolkit := &shared.Toolkit{Rpc: rpc.NewMemoryRPC(bus, v, rpc.MemoryRPCConfig{Group: "account"}), Validator: v}
+	usecases := &usecase.AccountUseCases{
+		UserUseCase: &fakeUserUseCase{users: map[string]usecase.UserStruct{
+			"usr_1": {ID: "usr_1", Name: "Jane", Email: "jane@example.com", OrgID: "org_1", Active: true},
+			"usr_2": {ID: "usr_2", Name: "John", Email: "john@example.com", OrgID: "org_1"},
+		}},
+		WorkspaceUseCase: &fakeWorkspaceUseCase{workspaces: map[string]model.Workspace{
+			"wrk_1": {ID: "wrk_1", Name: "Main", OrgID: "org_1", ApiKey: "secret"},
+		}},
+		OrgUseCase: &fakeOrgUseCase{orgs: map[string]model.Organization{
+			"org_1": {ID: "org_1", Name: "Acme", Email: "hello@acme.com", Country: "AE"},
+		}},
+		AuthUseCase: &fakeAuthUseCase{apiKeys: map[string]string{"zky_valid": "wrk_1"}},
+	}
+	assert.NoError(t, HandleAccountRequests(toolkit, usecases))
+	return rpc.NewMemoryRPC(bus, v, rpc.MemoryRPCConfig{Group: "test", Timeout: time.Second})
+}
+
+func TestGetUser(t *testing.T) {
+	client := setup(t)
+	user, err := rpc.Call[requests.GetUserResponse](context.Background(), client, &requests.GetUserRequest{ID: "usr_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane", user.Name)
+	assert.Equal(t, "org_1", user.OrgID)
+
+	_, err = rpc.Call[requests.GetUserResponse](context.Background(), client, &requests.GetUserRequest{ID: "usr_404"})
+	assert.Equal(t, 404, errs.HandleError(err).HttpCode)
+
+	_, err = rpc.Call[requests.GetUserResponse](context.Background(), client, &requests.GetUserRequest{})
+	assert.Error(t, err)
+}
+
+func TestGetUsers(t *testing.T) {
+	client := setup(t)
+	resp, err := rpc.Call[requests.GetUsersResponse](context.Background(), client, &requests.GetUsersRequest{IDs: []string{"usr_1", "usr_2", "usr_404"}})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Users, 2)
+	assert.Equal(t, []string{"usr_404"}, resp.Missing)
+
+	_, err = rpc.Call[requests.GetUsersResponse](context.Background(), client, &requests.GetUsersRequest{})
+	assert.Error(t, err)
+}
+
+func TestGetWorkspace(t *testing.T) {
+	client := setup(t)
+	ws, err := rpc.Call[requests.GetWorkspaceResponse](context.Background(), client, &requests.GetWorkspaceRequest{ID: "wrk_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Main", ws.Name)
+	assert.Equal(t, "org_1", ws.OrgID)
+
+	_, err = rpc.Call[requests.GetWorkspaceResponse](context.Background(), client, &requests.GetWorkspaceRequest{ID: "wrk_404"})
+	assert.Equal(t, 404, errs.HandleError(err).HttpCode)
+}
+
+func TestGetOrganization(t *testing.T) {
+	client := setup(t)
+	org, err := rpc.Call[requests.GetOrganizationResponse](context.Background(), client, &requests.GetOrganizationRequest{ID: "org_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Acme", org.Name)
+	assert.Equal(t, "AE", org.Country)
+}
+
+func TestResolveApiKey(t *testing.T) {
+	client := setup(t)
+	resp, err := rpc.Call[requests.ResolveApiKeyResponse](context.Background(), client, &requests.ResolveApiKeyRequest{ApiKey: "zky_valid"})
+	assert.NoError(t, err)
+	assert.Equal(t, "wrk_1", resp.WorkspaceID)
+
+	_, err = rpc.Call[requests.ResolveApiKeyResponse](context.Background(), client, &requests.ResolveApiKeyRequest{ApiKey: "zky_invalid"})
+	assert.Equal(t, 401, errs.HandleError(err).HttpCode)
+}
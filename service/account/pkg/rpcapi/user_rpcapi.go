@@ -0,0 +1,81 @@
+package rpcapi
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/rpc"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+)
+
+type UserRpcApi interface {
+	Setup() error
+	GetUser(ctx context.Context, req *requests.GetUserRequest) (interface{}, error)
+	GetUsers(ctx context.Context, req *requests.GetUsersRequest) (interface{}, error)
+}
+
+func NewUserRpcApi(toolkit *shared.Toolkit, usecases *usecase.AccountUseCases) error {
+	api := &userRpcApi{
+		toolkit:  toolkit,
+		usecases: usecases,
+	}
+	return api.Setup()
+}
+
+type userRpcApi struct {
+	toolkit  *shared.Toolkit
+	usecases *usecase.AccountUseCases
+}
+
+func (api *userRpcApi) Setup() error {
+	if err := api.toolkit.Rpc.Handle(&requests.GetUserRequest{}, rpc.Handler(api.GetUser)); err != nil {
+		return err
+	}
+	return api.toolkit.Rpc.Handle(&requests.GetUsersRequest{}, rpc.Handler(api.GetUsers))
+}
+
+func (api *userRpcApi) GetUser(ctx context.Context, req *requests.GetUserRequest) (interface{}, error) {
+	if err := validate(api.toolkit, req); err != nil {
+		return nil, err
+	}
+	user, err := api.usecases.UserUseCase.GetUserByID(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toUserResponse(user), nil
+}
+
+func (api *userRpcApi) GetUsers(ctx context.Context, req *requests.GetUsersRequest) (interface{}, error) {
+	if err := validate(api.toolkit, req); err != nil {
+		return nil, err
+	}
+	users, err := api.usecases.UserUseCase.GetUsersByIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+	resp := &requests.GetUsersResponse{Users: make([]requests.GetUserResponse, 0, len(users))}
+	found := make(map[string]bool, len(users))
+	for i := range users {
+		resp.Users = append(resp.Users, *toUserResponse(&users[i]))
+		found[users[i].ID] = true
+	}
+	for _, id := range req.IDs {
+		if !found[id] {
+			resp.Missing = append(resp.Missing, id)
+		}
+	}
+	return resp, nil
+}
+
+func toUserResponse(user *usecase.UserStruct) *requests.GetUserResponse {
+	return &requests.GetUserResponse{
+		ID:              user.ID,
+		Name:            user.Name,
+		Email:           user.Email,
+		Phone:           user.Phone,
+		IsEmailVerified: user.IsEmailVerified,
+		IsPhoneVerified: user.IsPhoneVerified,
+		Active:          user.Active,
+		OrgID:           user.OrgID,
+	}
+}
@@ -0,0 +1,62 @@
+package rpcapi
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/service/account/pkg/usecase"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/rpc"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+)
+
+type WorkspaceRpcApi interface {
+	Setup() error
+	GetWorkspace(ctx context.Context, req *requests.GetWorkspaceRequest) (interface{}, error)
+	ResolveApiKey(ctx context.Context, req *requests.ResolveApiKeyRequest) (interface{}, error)
+}
+
+func NewWorkspaceRpcApi(toolkit *shared.Toolkit, usecases *usecase.AccountUseCases) error {
+	api := &workspaceRpcApi{
+		toolkit:  toolkit,
+		usecases: usecases,
+	}
+	return api.Setup()
+}
+
+type workspaceRpcApi struct {
+	toolkit  *shared.Toolkit
+	usecases *usecase.AccountUseCases
+}
+
+func (api *workspaceRpcApi) Setup() error {
+	if err := api.toolkit.Rpc.Handle(&requests.GetWorkspaceRequest{}, rpc.Handler(api.GetWorkspace)); err != nil {
+		return err
+	}
+	return api.toolkit.Rpc.Handle(&requests.ResolveApiKeyRequest{}, rpc.Handler(api.ResolveApiKey))
+}
+
+func (api *workspaceRpcApi) GetWorkspace(ctx context.Context, req *requests.GetWorkspaceRequest) (interface{}, error) {
+	if err := validate(api.toolkit, req); err != nil {
+		return nil, err
+	}
+	ws, err := api.usecases.WorkspaceUseCase.GetWorkSpaceByID(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &requests.GetWorkspaceResponse{
+		ID:      ws.ID,
+		Name:    ws.Name,
+		Website: ws.Website,
+		OrgID:   ws.OrgID,
+	}, nil
+}
+
+func (api *workspaceRpcApi) ResolveApiKey(ctx context.Context, req *requests.ResolveApiKeyRequest) (interface{}, error) {
+	if err := validate(api.toolkit, req); err != nil {
+		return nil, err
+	}
+	id, err := api.usecases.AuthUseCase.AuthenticateWorkspaceByApiKey(req.ApiKey)
+	if err != nil {
+		return nil, err
+	}
+	return &requests.ResolveApiKeyResponse{WorkspaceID: id}, nil
+}
@@ -0,0 +1,414 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/service/account/pkg/repo"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/auth"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/query"
+	"slices"
+)
+
+// Permissions checked by the account service.
+const (
+	PermOrgRead              = "org.read"
+	PermOrgUpdate            = "org.update"
+	PermOrgMembersManage     = "org.members.manage"
+	PermOrgRolesManage       = "org.roles.manage"
+	PermWorkspaceCreate      = "workspace.create"
+	PermWorkspaceRead        = "workspace.read"
+	PermWorkspaceUpdate      = "workspace.update"
+	PermWorkspaceDelete      = "workspace.delete"
+	PermWorkspaceApiKeyReset = "workspace.apikey.reset"
+	PermUsersUnlock          = "users.unlock" // held by backoffice admins only, see BackofficeRoles
+)
+
+// BackofficeRoles are the built-in roles as backoffice users hold them, whose admins may also unlock users.
+var BackofficeRoles = auth.Roles{
+	auth.RoleOwner:  auth.DefaultRoles[auth.RoleOwner],
+	auth.RoleAdmin:  slices.Concat(auth.DefaultRoles[auth.RoleAdmin], []string{PermUsersUnlock}),
+	auth.RoleMember: auth.DefaultRoles[auth.RoleMember],
+	auth.RoleViewer: auth.DefaultRoles[auth.RoleViewer],
+}
+
+// KnownPermissions are the permissions custom roles can be made of, directly or through patterns
+// such as workspace.*.
+var KnownPermissions = []string{
+	PermOrgRead, PermOrgUpdate, PermOrgMembersManage, PermOrgRolesManage,
+	PermWorkspaceCreate, PermWorkspaceRead, PermWorkspaceUpdate, PermWorkspaceDelete, PermWorkspaceApiKeyReset,
+}
+
+// AccessUseCase manages the roles users hold in organizations and workspaces, and is the
+// auth.Policy of the account service, see auth.SetPolicy.
+type AccessUseCase interface {
+	auth.Policy
+	Check(userID string, permission string, resource auth.Resource) error
+	Permissions(userID string, resource auth.Resource) ([]string, error)
+	// AddMember, ChangeMemberRole, RemoveMember, CreateRole and DeleteRole act on behalf of actor,
+	// who cannot grant or take away permissions they do not hold themselves.
+	AddMember(actor *auth.Principal, memberDto *AddMemberStruct) (*model.Membership, error)
+	ChangeMemberRole(actor *auth.Principal, id string, role string) (*model.Membership, error)
+	RemoveMember(actor *auth.Principal, id string) error
+	GetMembershipsByUserID(userID string) ([]model.Membership, error)
+	GetMembersByOrgID(orgID string, spec query.Spec) (*shared.List[model.Membership], error)
+	CreateRole(actor *auth.Principal, roleDto *CreateRoleStruct) (*model.Role, error)
+	DeleteRole(actor *auth.Principal, id string) error
+	GetRolesByOrgID(orgID string) ([]model.Role, error)
+}
+
+func NewAccessUseCase(toolkit shared.Toolkit, memberRepo repo.MembershipRepo, roleRepo repo.RoleRepo, wsRepo repo.WorkspaceRepo) AccessUseCase {
+	return &accessUseCase{
+		toolkit:    toolkit,
+		memberRepo: memberRepo,
+		roleRepo:   roleRepo,
+		wsRepo:     wsRepo,
+		backoffice: auth.NewRolePolicy(BackofficeRoles),
+		fallback:   auth.NewRolePolicy(auth.DefaultRoles),
+	}
+}
+
+type accessUseCase struct {
+	toolkit    shared.Toolkit
+	memberRepo repo.MembershipRepo
+	roleRepo   repo.RoleRepo
+	wsRepo     repo.WorkspaceRepo
+	backoffice auth.Policy
+	fallback   auth.Policy
+}
+
+// Allowed checks users against their memberships. Other principals carry their roles and
+// scopes with them, and are checked against the built-in roles, or BackofficeRoles for backoffice users.
+func (uc *accessUseCase) Allowed(ctx context.Context, p *auth.Principal, permission string, resource auth.Resource) (bool, error) {
+	switch p.Type {
+	case auth.PrincipalUser:
+		if permission == PermUsersUnlock {
+			return false, nil // not even the * of owners grants it
+		}
+	case auth.PrincipalBackoffice:
+		return uc.backoffice.Allowed(ctx, p, permission, resource)
+	default:
+		return uc.fallback.Allowed(ctx, p, permission, resource)
+	}
+	if resource.OrgID == "" && resource.WorkspaceID == "" {
+		resource.OrgID = p.OrgID
+	}
+	permissions, err := uc.Permissions(p.ID, resource)
+	if err != nil {
+		return false, err
+	}
+	return auth.Grants(permissions, permission), nil
+}
+
+func (uc *accessUseCase) Check(userID string, permission string, resource auth.Resource) error {
+	permissions, err := uc.Permissions(userID, resource)
+	if err != nil {
+		return err
+	}
+	if !auth.Grants(permissions, permission) {
+		return errs.NewForbiddenError("missing permission "+permission, nil)
+	}
+	return nil
+}
+
+// Permissions returns what userID may do on resource: the permissions of their roles in its
+// organization, and in its workspace when it has one.
+func (uc *accessUseCase) Permissions(userID string, resource auth.Resource) ([]string, error) {
+	orgID, err := uc.resolveOrgID(resource)
+	if err != nil || orgID == "" {
+		return nil, err
+	}
+	memberships, err := uc.memberRepo.GetAllByUserID(userID)
+	if err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get memberships")
+	}
+	var roleNames []string
+	for _, m := range memberships {
+		if m.OrgID == orgID && (m.WorkspaceID == "" || m.WorkspaceID == resource.WorkspaceID) {
+			roleNames = append(roleNames, m.Role)
+		}
+	}
+	if len(roleNames) == 0 {
+		return nil, nil
+	}
+	roles, err := uc.orgRoles(orgID)
+	if err != nil {
+		return nil, err
+	}
+	return roles.Permissions(roleNames...), nil
+}
+
+// resolveOrgID returns the organization of resource, or "" when its workspace is not in it.
+func (uc *accessUseCase) resolveOrgID(resource auth.Resource) (string, error) {
+	if resource.WorkspaceID == "" {
+		return resource.OrgID, nil
+	}
+	ws, err := uc.wsRepo.GetOneByID(resource.WorkspaceID)
+	if errors.Is(err, errs.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errs.AnnotateKind(err, errs.ErrInternal, "failed to get workspace")
+	}
+	if resource.OrgID != "" && resource.OrgID != ws.OrgID {
+		return "", nil
+	}
+	return ws.OrgID, nil
+}
+
+// orgRoles returns the built-in roles along with the custom roles of orgID.
+func (uc *accessUseCase) orgRoles(orgID string) (auth.Roles, error) {
+	custom, err := uc.roleRepo.GetAllByOrgID(orgID)
+	if err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get roles")
+	}
+	roles := make(auth.Roles, len(auth.DefaultRoles)+len(custom))
+	for _, role := range custom {
+		roles[role.Name] = role.Permissions
+	}
+	for name, permissions := range auth.DefaultRoles {
+		roles[name] = permissions
+	}
+	return roles, nil
+}
+
+// rolePermissions returns the permissions of role in orgID, failing when there is no such role.
+func (uc *accessUseCase) rolePermissions(orgID string, role string) ([]string, error) {
+	roles, err := uc.orgRoles(orgID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, ok := roles[role]
+	if !ok {
+		return nil, ErrUnknownRole.New(nil)
+	}
+	return permissions, nil
+}
+
+// authorizeGrant fails unless actor holds manage and every one of permissions on resource, so nobody
+// hands out more than they may do themselves. A pattern is only held through a pattern covering it.
+func (uc *accessUseCase) authorizeGrant(actor *auth.Principal, manage string, permissions []string, resource auth.Resource) error {
+	if actor == nil {
+		return errs.NewUnauthorizedError("credentials are not provided", nil)
+	}
+	held := func(permission string) (bool, error) {
+		return uc.fallback.Allowed(context.Background(), actor, permission, resource)
+	}
+	if actor.Type == auth.PrincipalUser {
+		actorPermissions, err := uc.Permissions(actor.ID, resource)
+		if err != nil {
+			return err
+		}
+		held = func(permission string) (bool, error) {
+			return auth.Grants(actorPermissions, permission), nil
+		}
+	}
+	ok, err := held(manage)
+	if err != nil {
+		return errs.Annotate(err, "unable to check permission "+manage)
+	}
+	if !ok {
+		return errs.NewForbiddenError("missing permission "+manage, nil)
+	}
+	for _, permission := range permissions {
+		ok, err := held(permission)
+		if err != nil {
+			return errs.Annotate(err, "unable to check permission "+permission)
+		}
+		if !ok {
+			return ErrPermissionNotHeld.New(nil)
+		}
+	}
+	return nil
+}
+
+// validatePermissions fails unless every one of permissions is known or a pattern of known ones,
+// other than the bare * reserved to owners.
+func validatePermissions(permissions []string) error {
+	for _, permission := range permissions {
+		if !slices.ContainsFunc(KnownPermissions, func(known string) bool { return permission != "*" && auth.Match(permission, known) }) {
+			customErr := ErrUnknownPermission.New(nil)
+			customErr.Fields = map[string]string{"permissions": permission}
+			return customErr
+		}
+	}
+	return nil
+}
+
+// ensureOwnerRemains fails when m is the last organization-wide owner.
+func (uc *accessUseCase) ensureOwnerRemains(m *model.Membership) error {
+	if m.Role != auth.RoleOwner || m.WorkspaceID != "" {
+		return nil
+	}
+	owners, err := uc.memberRepo.CountByRole(m.OrgID, auth.RoleOwner)
+	if err != nil {
+		return errs.AnnotateKind(err, errs.ErrInternal, "failed to count owners")
+	}
+	if owners <= 1 {
+		return ErrLastOwner.New(nil)
+	}
+	return nil
+}
+
+func (uc *accessUseCase) AddMember(actor *auth.Principal, memberDto *AddMemberStruct) (*model.Membership, error) {
+	if err := uc.toolkit.Validator.ValidateStruct(memberDto); err != nil {
+		fields := uc.toolkit.Validator.GetValidationErrors(err)
+		return nil, errs.NewValidationError("invalid member data", fields)
+	}
+	if memberDto.WorkspaceID != "" {
+		ws, err := uc.wsRepo.GetOneByID(memberDto.WorkspaceID)
+		if err != nil {
+			return nil, errs.AnnotateKind(err, errs.ErrNotFound, "workspace not found")
+		}
+		if ws.OrgID != memberDto.OrgID {
+			return nil, errs.NewBadRequestError("workspace does not belong to the organization", nil)
+		}
+	}
+	permissions, err := uc.rolePermissions(memberDto.OrgID, memberDto.Role)
+	if err != nil {
+		return nil, err
+	}
+	resource := auth.Resource{OrgID: memberDto.OrgID, WorkspaceID: memberDto.WorkspaceID}
+	if err := uc.authorizeGrant(actor, PermOrgMembersManage, permissions, resource); err != nil {
+		return nil, err
+	}
+	membership := &model.Membership{
+		UserID:      memberDto.UserID,
+		OrgID:       memberDto.OrgID,
+		WorkspaceID: memberDto.WorkspaceID,
+		Role:        memberDto.Role,
+	}
+	if err := uc.memberRepo.Create(membership); errors.Is(err, errs.ErrConflict) {
+		return nil, ErrAlreadyMember.New(err)
+	} else if err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to add member")
+	}
+	return membership, nil
+}
+
+func (uc *accessUseCase) ChangeMemberRole(actor *auth.Principal, id string, role string) (*model.Membership, error) {
+	membership, err := uc.memberRepo.GetOneByID(id)
+	if err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "membership not found")
+	}
+	if membership.Role == role {
+		return membership, nil
+	}
+	permissions, err := uc.rolePermissions(membership.OrgID, role)
+	if err != nil {
+		return nil, err
+	}
+	// the permissions of the current role are taken away, which takes holding them too
+	current, err := uc.rolePermissions(membership.OrgID, membership.Role)
+	if err != nil && !errors.Is(err, errs.Kind(ErrUnknownRole.Code)) {
+		return nil, err
+	}
+	resource := auth.Resource{OrgID: membership.OrgID, WorkspaceID: membership.WorkspaceID}
+	if err := uc.authorizeGrant(actor, PermOrgMembersManage, slices.Concat(permissions, current), resource); err != nil {
+		return nil, err
+	}
+	if err := uc.ensureOwnerRemains(membership); err != nil {
+		return nil, err
+	}
+	membership.Role = role
+	if err := uc.memberRepo.Save(membership); err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update membership")
+	}
+	return membership, nil
+}
+
+func (uc *accessUseCase) RemoveMember(actor *auth.Principal, id string) error {
+	membership, err := uc.memberRepo.GetOneByID(id)
+	if err != nil {
+		return errs.AnnotateKind(err, errs.ErrNotFound, "membership not found")
+	}
+	current, err := uc.rolePermissions(membership.OrgID, membership.Role)
+	if err != nil && !errors.Is(err, errs.Kind(ErrUnknownRole.Code)) {
+		return err
+	}
+	resource := auth.Resource{OrgID: membership.OrgID, WorkspaceID: membership.WorkspaceID}
+	if err := uc.authorizeGrant(actor, PermOrgMembersManage, current, resource); err != nil {
+		return err
+	}
+	if err := uc.ensureOwnerRemains(membership); err != nil {
+		return err
+	}
+	if err := uc.memberRepo.Delete(id); err != nil {
+		return errs.AnnotateKind(err, errs.ErrInternal, "failed to remove member")
+	}
+	return nil
+}
+
+func (uc *accessUseCase) GetMembershipsByUserID(userID string) ([]model.Membership, error) {
+	memberships, err := uc.memberRepo.GetAllByUserID(userID)
+	if err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get memberships")
+	}
+	return memberships, nil
+}
+
+func (uc *accessUseCase) GetMembersByOrgID(orgID string, spec query.Spec) (*shared.List[model.Membership], error) {
+	memberships, total, err := uc.memberRepo.GetAllByOrgID(orgID, spec)
+	if err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get members")
+	}
+	return shared.NewList(memberships, total, spec), nil
+}
+
+func (uc *accessUseCase) CreateRole(actor *auth.Principal, roleDto *CreateRoleStruct) (*model.Role, error) {
+	if err := uc.toolkit.Validator.ValidateStruct(roleDto); err != nil {
+		fields := uc.toolkit.Validator.GetValidationErrors(err)
+		return nil, errs.NewValidationError("invalid role data", fields)
+	}
+	if _, ok := auth.DefaultRoles[roleDto.Name]; ok {
+		return nil, ErrReservedRole.New(nil)
+	}
+	if err := validatePermissions(roleDto.Permissions); err != nil {
+		return nil, err
+	}
+	if err := uc.authorizeGrant(actor, PermOrgRolesManage, roleDto.Permissions, auth.Resource{OrgID: roleDto.OrgID}); err != nil {
+		return nil, err
+	}
+	role := &model.Role{
+		OrgID:       roleDto.OrgID,
+		Name:        roleDto.Name,
+		Permissions: roleDto.Permissions,
+	}
+	if err := uc.roleRepo.Create(role); errors.Is(err, errs.ErrConflict) {
+		return nil, ErrRoleAlreadyExists.New(err)
+	} else if err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to create role")
+	}
+	return role, nil
+}
+
+func (uc *accessUseCase) DeleteRole(actor *auth.Principal, id string) error {
+	role, err := uc.roleRepo.GetOneByID(id)
+	if err != nil {
+		return errs.AnnotateKind(err, errs.ErrNotFound, "role not found")
+	}
+	if err := uc.authorizeGrant(actor, PermOrgRolesManage, nil, auth.Resource{OrgID: role.OrgID}); err != nil {
+		return err
+	}
+	members, err := uc.memberRepo.CountByRole(role.OrgID, role.Name)
+	if err != nil {
+		return errs.AnnotateKind(err, errs.ErrInternal, "failed to count members")
+	}
+	if members > 0 {
+		return ErrRoleInUse.New(nil)
+	}
+	if err := uc.roleRepo.Delete(id); err != nil {
+		return errs.AnnotateKind(err, errs.ErrInternal, "failed to delete role")
+	}
+	return nil
+}
+
+func (uc *accessUseCase) GetRolesByOrgID(orgID string) ([]model.Role, error) {
+	roles, err := uc.roleRepo.GetAllByOrgID(orgID)
+	if err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get roles")
+	}
+	return roles, nil
+}
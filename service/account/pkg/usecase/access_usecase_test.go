@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/service/account/pkg/repo"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/auth"
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"slices"
+	"testing"
+)
+
+type fakeMembershipRepo struct {
+	repo.MembershipRepo
+	memberships []model.Membership
+}
+
+func (f *fakeMembershipRepo) GetOneByID(id string) (*model.Membership, error) {
+	for _, m := range f.memberships {
+		if m.ID == id {
+			return &m, nil
+		}
+	}
+	return nil, errs.NewNotFoundError("record not found", nil)
+}
+
+func (f *fakeMembershipRepo) GetAllByUserID(userID string) ([]model.Membership, error) {
+	var memberships []model.Membership
+	for _, m := range f.memberships {
+		if m.UserID == userID {
+			memberships = append(memberships, m)
+		}
+	}
+	return memberships, nil
+}
+
+func (f *fakeMembershipRepo) CountByRole(orgID string, role string) (int64, error) {
+	var total int64
+	for _, m := range f.memberships {
+		if m.OrgID == orgID && m.Role == role {
+			total++
+		}
+	}
+	return total, nil
+}
+
+type fakeRoleRepo struct {
+	repo.RoleRepo
+	roles []model.Role
+}
+
+func (f *fakeRoleRepo) GetAllByOrgID(orgID string) ([]model.Role, error) {
+	var roles []model.Role
+	for _, r := range f.roles {
+		if r.OrgID == orgID {
+			roles = append(roles, r)
+		}
+	}
+	return roles, nil
+}
+
+func (f *fakeRoleRepo) GetOneByName(orgID string, name string) (*model.Role, error) {
+	for _, r := range f.roles {
+		if r.OrgID == orgID && r.Name == name {
+			return &r, nil
+		}
+	}
+	return nil, errs.NewNotFoundError("record not found", nil)
+}
+
+func (f *fakeRoleRepo) GetOneByID(id string) (*model.Role, error) {
+	for _, r := range f.roles {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, errs.NewNotFoundError("record not found", nil)
+}
+
+func (f *fakeRoleRepo) Delete(id string) error {
+	f.roles = slices.DeleteFunc(f.roles, func(r model.Role) bool { return r.ID == id })
+	return nil
+}
+
+type fakeWorkspaceRepo struct {
+	repo.WorkspaceRepo
+	workspaces map[string]model.Workspace
+}
+
+func (f *fakeWorkspaceRepo) GetOneByID(id string) (*model.Workspace, error) {
+	ws, ok := f.workspaces[id]
+	if !ok {
+		return nil, errs.NewNotFoundError("record not found", nil)
+	}
+	return &ws, nil
+}
+
+func newTestAccessUseCase() AccessUseCase {
+	members := &fakeMembershipRepo{memberships: []model.Membership{
+		{ID: "mbr_1", UserID: "usr_owner", OrgID: "org_1", Role: auth.RoleOwner},
+		{ID: "mbr_2", UserID: "usr_viewer", OrgID: "org_1", Role: auth.RoleViewer},
+		{ID: "mbr_3", UserID: "usr_viewer", OrgID: "org_1", WorkspaceID: "wrk_1", Role: auth.RoleAdmin},
+		{ID: "mbr_4", UserID: "usr_billing", OrgID: "org_1", Role: "billing"},
+	}}
+	roles := &fakeRoleRepo{roles: []model.Role{
+		{ID: "rol_1", OrgID: "org_1", Name: "billing", Permissions: []string{"billing.*"}},
+		{ID: "rol_2", OrgID: "org_2", Name: "billing", Permissions: []string{"*"}},
+		{ID: "rol_3", OrgID: "org_1", Name: "support", Permissions: []string{PermOrgRead}},
+	}}
+	workspaces := &fakeWorkspaceRepo{workspaces: map[string]model.Workspace{
+		"wrk_1": {ID: "wrk_1", OrgID: "org_1"},
+		"wrk_2": {ID: "wrk_2", OrgID: "org_1"},
+		"wrk_3": {ID: "wrk_3", OrgID: "org_2"},
+	}}
+	return NewAccessUseCase(shared.Toolkit{}, members, roles, workspaces)
+}
+
+func TestAccessUseCase_Check(t *testing.T) {
+	uc := newTestAccessUseCase()
+
+	assert.NoError(t, uc.Check("usr_owner", PermWorkspaceApiKeyReset, auth.Resource{WorkspaceID: "wrk_2"}))
+	assert.ErrorIs(t, uc.Check("usr_owner", PermWorkspaceRead, auth.Resource{WorkspaceID: "wrk_3"}), errs.ErrForbidden)
+	assert.ErrorIs(t, uc.Check("usr_owner", PermWorkspaceRead, auth.Resource{OrgID: "org_2", WorkspaceID: "wrk_1"}), errs.ErrForbidden)
+
+	// workspace memberships add to the organization-wide ones, only within the workspace
+	assert.NoError(t, uc.Check("usr_viewer", PermWorkspaceApiKeyReset, auth.Resource{WorkspaceID: "wrk_1"}))
+	assert.ErrorIs(t, uc.Check("usr_viewer", PermWorkspaceApiKeyReset, auth.Resource{WorkspaceID: "wrk_2"}), errs.ErrForbidden)
+	assert.NoError(t, uc.Check("usr_viewer", PermWorkspaceRead, auth.Resource{WorkspaceID: "wrk_2"}))
+
+	// custom roles are looked up in the organization of the resource
+	assert.NoError(t, uc.Check("usr_billing", "billing.refund", auth.Resource{OrgID: "org_1"}))
+	assert.ErrorIs(t, uc.Check("usr_billing", PermOrgRead, auth.Resource{OrgID: "org_1"}), errs.ErrForbidden)
+
+	assert.ErrorIs(t, uc.Check("usr_404", PermOrgRead, auth.Resource{OrgID: "org_1"}), errs.ErrForbidden)
+}
+
+func TestAccessUseCase_Allowed(t *testing.T) {
+	uc := newTestAccessUseCase()
+
+	allowed, err := uc.Allowed(context.Background(), &auth.Principal{Type: auth.PrincipalUser, ID: "usr_viewer", OrgID: "org_1"}, PermOrgRead, auth.Resource{})
+	require.NoError(t, err)
+	assert.True(t, allowed, "users are checked in their own organization by default")
+
+	// roles carried by the session are ignored for users, their memberships decide
+	allowed, err = uc.Allowed(context.Background(), &auth.Principal{Type: auth.PrincipalUser, ID: "usr_viewer", OrgID: "org_1", Roles: []string{auth.RoleOwner}}, PermOrgUpdate, auth.Resource{})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = uc.Allowed(context.Background(), &auth.Principal{Type: auth.PrincipalBackoffice, ID: "7", Roles: []string{auth.RoleAdmin}}, PermOrgUpdate, auth.Resource{OrgID: "org_2"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestAccessUseCase_AllowsBackofficeAdminsToUnlockUsers(t *testing.T) {
+	uc := newTestAccessUseCase()
+	for _, c := range []struct {
+		principal *auth.Principal
+		allowed   bool
+	}{
+		{&auth.Principal{Type: auth.PrincipalBackoffice, ID: "7", Roles: []string{auth.RoleAdmin}}, true},
+		{&auth.Principal{Type: auth.PrincipalBackoffice, ID: "8", Roles: []string{auth.RoleViewer}}, false},
+		{&auth.Principal{Type: auth.PrincipalBackoffice, ID: "9"}, false},
+		{&auth.Principal{Type: auth.PrincipalWorkspace, ID: "wrk_1", WorkspaceID: "wrk_1", Roles: []string{auth.RoleAdmin}}, false},
+		{owner, false},
+	} {
+		allowed, err := uc.Allowed(context.Background(), c.principal, PermUsersUnlock, auth.Resource{})
+		require.NoError(t, err)
+		assert.Equal(t, c.allowed, allowed, "%s %s", c.principal.Type, c.principal.ID)
+	}
+}
+
+var (
+	owner  = &auth.Principal{Type: auth.PrincipalUser, ID: "usr_owner", OrgID: "org_1"}
+	viewer = &auth.Principal{Type: auth.PrincipalUser, ID: "usr_viewer", OrgID: "org_1"}
+)
+
+func TestAccessUseCase_KeepsAnOwner(t *testing.T) {
+	uc := newTestAccessUseCase()
+
+	_, err := uc.ChangeMemberRole(owner, "mbr_1", auth.RoleAdmin)
+	assert.ErrorIs(t, err, errs.Kind(ErrLastOwner.Code))
+	assert.ErrorIs(t, uc.RemoveMember(owner, "mbr_1"), errs.ErrConflict)
+
+	_, err = uc.ChangeMemberRole(owner, "mbr_2", "unknown")
+	assert.ErrorIs(t, err, errs.ErrBadRequest)
+}
+
+func TestAccessUseCase_GrantsNoMoreThanTheActorHolds(t *testing.T) {
+	uc := newTestAccessUseCase()
+
+	_, err := uc.ChangeMemberRole(viewer, "mbr_4", auth.RoleViewer)
+	assert.ErrorIs(t, err, errs.ErrForbidden, "viewers do not manage members of the organization")
+
+	// usr_viewer is an admin of wrk_1, and manages its members without making owners
+	_, err = uc.ChangeMemberRole(viewer, "mbr_3", auth.RoleOwner)
+	assert.ErrorIs(t, err, errs.Kind(ErrPermissionNotHeld.Code))
+	assert.ErrorIs(t, uc.RemoveMember(viewer, "mbr_1"), errs.ErrForbidden)
+
+	backoffice := &auth.Principal{Type: auth.PrincipalBackoffice, ID: "7", Roles: []string{auth.RoleAdmin}}
+	_, err = uc.ChangeMemberRole(backoffice, "mbr_4", auth.RoleOwner)
+	assert.ErrorIs(t, err, errs.Kind(ErrPermissionNotHeld.Code))
+}
+
+func TestAccessUseCase_DeleteRole(t *testing.T) {
+	uc := newTestAccessUseCase()
+
+	assert.ErrorIs(t, uc.DeleteRole(viewer, "rol_1"), errs.ErrForbidden, "viewers do not manage roles")
+	assert.ErrorIs(t, uc.DeleteRole(owner, "rol_2"), errs.ErrForbidden, "roles of other organizations are out of reach")
+	assert.ErrorIs(t, uc.DeleteRole(owner, "rol_1"), errs.Kind(ErrRoleInUse.Code))
+	assert.ErrorIs(t, uc.DeleteRole(nil, "rol_3"), errs.ErrUnauthorized)
+	assert.NoError(t, uc.DeleteRole(owner, "rol_3"))
+}
+
+func TestValidatePermissions(t *testing.T) {
+	assert.NoError(t, validatePermissions([]string{PermOrgRead, "workspace.*", "org.members.*"}))
+	assert.ErrorIs(t, validatePermissions([]string{"*"}), errs.Kind(ErrUnknownPermission.Code), "only owners hold every permission")
+	assert.ErrorIs(t, validatePermissions([]string{"billing.refund"}), errs.ErrBadRequest)
+	assert.ErrorIs(t, validatePermissions([]string{"org.*.read"}), errs.ErrBadRequest)
+}
+
+func TestNewAccountUseCases_InstallsThePolicy(t *testing.T) {
+	conf := config.NewViperConfig()
+	conf.Set("app.secret", "secret")
+	access := newTestAccessUseCase().(*accessUseCase)
+	usecases := NewAccountUseCases(shared.Toolkit{Conf: conf, Cache: cache.NewMemoryCache()}, AccountRepos{
+		Membership: access.memberRepo,
+		Role:       access.roleRepo,
+		Workspace:  access.wsRepo,
+	})
+	t.Cleanup(func() { auth.SetPolicy(auth.NewRolePolicy(auth.DefaultRoles)) })
+
+	// the session of usr_viewer carries no roles, its memberships are what grant it permissions
+	ctx := auth.IntoContext(context.Background(), viewer)
+	assert.NoError(t, auth.Check(ctx, PermWorkspaceApiKeyReset, auth.Resource{WorkspaceID: "wrk_1"}))
+	assert.ErrorIs(t, auth.Check(ctx, PermOrgUpdate, auth.Resource{OrgID: "org_1"}), errs.ErrForbidden)
+	assert.NotNil(t, usecases.AuthUseCase)
+}
@@ -1,12 +1,18 @@
 package usecase
 
 import (
+	"context"
+	"crypto/hmac"
 	"encoding/json"
+	"errors"
 	"github.com/abdelrahman146/zard/service/account/pkg/model"
 	"github.com/abdelrahman146/zard/service/account/pkg/repo"
 	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/config"
 	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
 	"github.com/abdelrahman146/zard/shared/pubsub/messages"
+	"github.com/abdelrahman146/zard/shared/secrets"
 	"strconv"
 	"time"
 )
@@ -20,18 +26,38 @@ type AuthUseCase interface {
 	AuthenticateWorkspaceByApiKey(apiKey string) (id string, err error)
 	RevokeToken(token string) (err error)
 	RevokeAllUserTokens(userID string) (err error)
+	VerifyOTPHash(expectedVal, hash string) (err error)
+	VerifyUserPassword(id, password string) (err error)
+	UnlockUser(userID string) (err error)
+	// TokenTTL is how long user tokens are valid for, and the max age of their cookies.
+	TokenTTL() time.Duration
 }
 
 func NewAuthUseCase(toolkit shared.Toolkit, userRepo repo.UserRepo, wrkRepo repo.WorkspaceRepo) AuthUseCase {
-	return &authUseCase{
+	uc := &authUseCase{
 		toolkit:  toolkit,
 		userRepo: userRepo,
 		wrkRepo:  wrkRepo,
 	}
+	if err := config.Bind(toolkit.Conf, &uc.config); err != nil {
+		logger.GetLogger().Panic("invalid auth configuration", logger.Field("error", err))
+	}
+	uc.attempts = newAttemptTracker(toolkit.Cache, uc.config.Lockout)
+	uc.secrets = toolkit.Secrets
+	if uc.secrets == nil {
+		uc.secrets = secrets.NewConfigProvider(toolkit.Conf)
+	}
+	if _, err := secrets.Current(uc.secrets, "app.secret"); err != nil {
+		logger.GetLogger().Panic("app.secret is not provided", logger.Field("error", err))
+	}
+	return uc
 }
 
 type authUseCase struct {
 	toolkit  shared.Toolkit
+	config   AuthConfig
+	secrets  secrets.Provider
+	attempts *attemptTracker
 	userRepo repo.UserRepo
 	wrkRepo  repo.WorkspaceRepo
 }
@@ -54,52 +80,104 @@ func (uc *authUseCase) ToUserStruct(user *model.User) *UserStruct {
 
 func (uc *authUseCase) CreateUserToken(user *UserStruct) (token string, err error) {
 	userJson, err := json.Marshal(user)
-	token = shared.Utils.Auth.CreateToken("ztkn", user.ID, uc.toolkit.Conf.GetString("app.secret"))
-	ttl := time.Second * time.Duration(uc.toolkit.Conf.GetInt("app.auth.tokenTTL"))
-	if err := uc.toolkit.Cache.Set([]string{"account", "auth", "user", "tokens", token}, userJson, ttl); err != nil {
+	secret, err := secrets.Current(uc.secrets, "app.secret")
+	if err != nil {
+		return "", errs.NewInternalError("unable to create user session", err)
+	}
+	token = shared.Utils.Auth.CreateToken("ztkn", user.ID, secret)
+	if err := uc.toolkit.Cache.Set([]string{"account", "auth", "user", "tokens", token}, userJson, uc.config.TokenTTL); err != nil {
 		return "", errs.NewInternalError("unable to create user session", err)
 	}
 	return token, nil
 }
 
 func (uc *authUseCase) AuthenticateUserByEmailPassword(email, password string) (token string, user *UserStruct, err error) {
+	if err = uc.attempts.check(attemptsPassword, email); err != nil {
+		return "", nil, err
+	}
 	userModel, err := uc.userRepo.GetOneByEmail(email)
+	if errors.Is(err, errs.ErrNotFound) {
+		// unknown emails are tracked too, so they cannot be told apart from known ones
+		return "", nil, uc.failLogin(email, nil, ErrInvalidCredentials.New(err))
+	}
 	if err != nil {
-		return "", nil, errs.NewBadRequestError("invalid email", err)
+		return "", nil, errs.Annotate(err, "unable to find user")
 	}
 	if userModel.Password == nil {
-		return "", nil, errs.NewBadRequestError("invalid password", nil)
+		return "", nil, uc.failLogin(email, userModel, ErrInvalidCredentials.New(nil))
 	}
 	if userModel.Active == false {
-		return "", nil, errs.NewForbiddenError("inactive user", nil)
+		return "", nil, ErrInactiveUser.New(nil)
+	}
+	ok, err := uc.verifyPassword(userModel, password)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		return "", nil, uc.failLogin(email, userModel, ErrInvalidCredentials.New(nil))
 	}
-	if ok := shared.Utils.Auth.Compare(*userModel.Password, password, uc.toolkit.Conf.GetString("app.secret")); !ok {
-		return "", nil, errs.NewBadRequestError("invalid password", nil)
+	if err = uc.attempts.reset(attemptsPassword, email); err != nil {
+		logger.GetLogger().Error("unable to reset failed login attempts", logger.Field("error", err))
 	}
 	user = uc.ToUserStruct(userModel)
 	token, err = uc.CreateUserToken(user)
 	return token, user, err
 }
 
+// failLogin records a failed login of email and returns err, or a locked error once the account gets locked.
+func (uc *authUseCase) failLogin(email string, userModel *model.User, err error) error {
+	a, locked, tErr := uc.attempts.fail(attemptsPassword, email, uc.config.Lockout.MaxAttempts)
+	if tErr != nil {
+		logger.GetLogger().Error("unable to record failed login attempt", logger.Field("error", tErr))
+		return err
+	}
+	if !locked {
+		return err
+	}
+	event := &messages.AuthSecurityEvent{
+		Event:       messages.SecurityEventAccountLocked,
+		Target:      email,
+		Attempts:    a.Failures,
+		LockedUntil: a.LockedUntil,
+		Timestamp:   time.Now(),
+	}
+	if userModel != nil {
+		event.UserID = userModel.ID
+	}
+	uc.publishSecurityEvent(event)
+	return withRetryAfter(ErrAccountLocked.New(err), uc.config.Lockout.Duration)
+}
+
+func (uc *authUseCase) publishSecurityEvent(event *messages.AuthSecurityEvent) {
+	if err := uc.toolkit.PubSub.Publish(context.TODO(), event); err != nil {
+		logger.GetLogger().Error("failed to publish security event", logger.Field("event", event.Event), logger.Field("error", err))
+	}
+}
+
 func (uc *authUseCase) CreateAndSendOTP(target, reason, value string) (maxAge time.Duration, err error) {
 	_, err = uc.toolkit.Cache.Get([]string{"account", "auth", "otp", value})
 	if err == nil {
-		return 0, errs.NewBadRequestError("otp already exists", nil)
+		return 0, ErrOtpAlreadySent.New(nil)
 	}
 	otpNum, err := shared.Utils.Numbers.GenerateRandomDigits(6)
 	if err != nil {
 		return 0, errs.NewInternalError("Unable to create otp", err)
 	}
 	otp := strconv.Itoa(otpNum)
-	ttl := time.Duration(uc.toolkit.Conf.GetInt("app.auth.otpTTL"))
+	ttl := uc.config.OtpTTL
 	if err = uc.toolkit.Cache.Set([]string{"account", "auth", "otp", value}, []byte(otp), ttl); err != nil {
 		return 0, errs.NewInternalError("unable to create otp", err)
 	}
-	if err := uc.toolkit.PubSub.Publish(&messages.AuthOTPCreated{
+	secret, err := secrets.Current(uc.secrets, "app.secret")
+	if err != nil {
+		return 0, errs.NewInternalError("unable to create otp", err)
+	}
+	if err := uc.toolkit.PubSub.Publish(context.TODO(), &messages.AuthOTPCreated{
 		Value:     value,
 		Target:    target,
 		Reason:    reason,
 		Otp:       otp,
+		Hash:      otpHash(value, otp, secret),
 		Ttl:       ttl,
 		Timestamp: time.Now(),
 	}); err != nil {
@@ -109,12 +187,18 @@ func (uc *authUseCase) CreateAndSendOTP(target, reason, value string) (maxAge ti
 }
 
 func (uc *authUseCase) VerifyOTP(expectedVal, otp string) (err error) {
+	if err = uc.attempts.check(attemptsOtp, expectedVal); err != nil {
+		return err
+	}
 	res, err := uc.toolkit.Cache.Get([]string{"account", "auth", "otp", expectedVal})
 	if err != nil {
-		return errs.NewUnauthorizedError("invalid or expired otp", err)
+		return ErrInvalidOtp.New(err)
 	}
 	if string(res) != otp {
-		return errs.NewUnauthorizedError("invalid otp", nil)
+		return uc.failOtp(expectedVal)
+	}
+	if err = uc.attempts.reset(attemptsOtp, expectedVal); err != nil {
+		logger.GetLogger().Error("unable to reset failed otp attempts", logger.Field("error", err))
 	}
 	if err = uc.toolkit.Cache.Delete([]string{"account", "auth", "otp", expectedVal}); err != nil {
 		return errs.NewInternalError("unable to delete otp", err)
@@ -122,10 +206,36 @@ func (uc *authUseCase) VerifyOTP(expectedVal, otp string) (err error) {
 	return nil
 }
 
+// failOtp records a wrong OTP for value, and invalidates the OTP once there were too many, so
+// that it cannot be enumerated. A new OTP starts with a clean slate.
+func (uc *authUseCase) failOtp(value string) error {
+	a, exceeded, err := uc.attempts.fail(attemptsOtp, value, uc.config.Lockout.MaxOtpAttempts)
+	if err != nil {
+		logger.GetLogger().Error("unable to record failed otp attempt", logger.Field("error", err))
+		return ErrInvalidOtp.New(nil)
+	}
+	if !exceeded {
+		return ErrInvalidOtp.New(nil)
+	}
+	if err = uc.toolkit.Cache.Delete([]string{"account", "auth", "otp", value}); err != nil {
+		return errs.NewInternalError("unable to invalidate otp", err)
+	}
+	if err = uc.attempts.reset(attemptsOtp, value); err != nil {
+		logger.GetLogger().Error("unable to reset failed otp attempts", logger.Field("error", err))
+	}
+	uc.publishSecurityEvent(&messages.AuthSecurityEvent{
+		Event:     messages.SecurityEventOtpInvalidated,
+		Target:    value,
+		Attempts:  a.Failures,
+		Timestamp: time.Now(),
+	})
+	return ErrOtpInvalidated.New(nil)
+}
+
 func (uc *authUseCase) AuthenticateToken(token string) (user *UserStruct, err error) {
 	userJson, err := uc.toolkit.Cache.Get([]string{"account", "auth", "user", "tokens", token})
 	if err != nil {
-		return nil, errs.NewUnauthorizedError("invalid or expired token", err)
+		return nil, ErrInvalidToken.New(err)
 	}
 	if err = json.Unmarshal(userJson, &user); err != nil {
 		return nil, errs.NewInternalError("unable to parse user session", err)
@@ -134,18 +244,26 @@ func (uc *authUseCase) AuthenticateToken(token string) (user *UserStruct, err er
 }
 
 func (uc *authUseCase) AuthenticateWorkspaceByApiKey(apiKey string) (id string, err error) {
-	secret := uc.toolkit.Conf.GetString("app.secret")
-	if ok := shared.Utils.Auth.ValidateToken(apiKey, secret); !ok {
-		return "", errs.NewBadRequestError("invalid api key", nil)
+	_, ok, err := secrets.Verify(uc.secrets, "app.secret", func(secret string) bool {
+		return shared.Utils.Auth.ValidateToken(apiKey, secret)
+	})
+	if err != nil {
+		return "", errs.NewInternalError("unable to verify api key", err)
+	}
+	if !ok {
+		return "", ErrMalformedApiKey.New(nil)
 	}
 	if resp, err := uc.toolkit.Cache.Get([]string{"account", "auth", "workspace", "tokens", apiKey}); err == nil {
 		return string(resp), nil
 	}
 	workspace, err := uc.wrkRepo.GetOneByApiKey(apiKey)
+	if errors.Is(err, errs.ErrNotFound) {
+		return "", ErrInvalidApiKey.New(err)
+	}
 	if err != nil {
-		return "", errs.NewUnauthorizedError("invalid api key", err)
+		return "", errs.Annotate(err, "unable to find workspace")
 	}
-	if err = uc.toolkit.Cache.Set([]string{"account", "auth", "workspace", "tokens", apiKey}, []byte(workspace.ID), time.Second*time.Duration(uc.toolkit.Conf.GetInt("app.auth.apiKeyTTL"))); err != nil {
+	if err = uc.toolkit.Cache.Set([]string{"account", "auth", "workspace", "tokens", apiKey}, []byte(workspace.ID), uc.config.ApiKeyTTL); err != nil {
 		return "", errs.NewInternalError("unable to create workspace session", err)
 	}
 	return workspace.ID, nil
@@ -180,3 +298,89 @@ func (uc *authUseCase) RevokeAllUserTokens(userID string) (err error) {
 	}
 	return nil
 }
+
+// VerifyOTPHash verifies the OTP sent to expectedVal by the hash the links sent along with it carry.
+func (uc *authUseCase) VerifyOTPHash(expectedVal, hash string) (err error) {
+	otp, err := uc.toolkit.Cache.Get([]string{"account", "auth", "otp", expectedVal})
+	if err != nil {
+		return ErrInvalidOtp.New(err)
+	}
+	_, ok, err := secrets.Verify(uc.secrets, "app.secret", func(secret string) bool {
+		return hmac.Equal([]byte(otpHash(expectedVal, string(otp), secret)), []byte(hash))
+	})
+	if err != nil {
+		return errs.NewInternalError("unable to verify otp", err)
+	}
+	if !ok {
+		return ErrInvalidOtp.New(nil)
+	}
+	return uc.VerifyOTP(expectedVal, string(otp))
+}
+
+// VerifyUserPassword checks password against the one of the user, as changing it requires.
+func (uc *authUseCase) VerifyUserPassword(id, password string) (err error) {
+	userModel, err := uc.userRepo.GetOneByID(id)
+	if err != nil {
+		return errs.Annotate(err, "unable to find user")
+	}
+	ok, err := uc.verifyPassword(userModel, password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidCredentials.New(nil)
+	}
+	return nil
+}
+
+// verifyPassword checks password against the one of userModel. A password hashed with a previous
+// app.secret is hashed again with the current one, so it stays verifiable once that one is retired.
+func (uc *authUseCase) verifyPassword(userModel *model.User, password string) (bool, error) {
+	if userModel.Password == nil {
+		return false, nil
+	}
+	secret, ok, err := secrets.Verify(uc.secrets, "app.secret", func(secret string) bool {
+		return shared.Utils.Auth.Compare(*userModel.Password, password, secret)
+	})
+	if err != nil {
+		return false, errs.NewInternalError("unable to verify password", err)
+	}
+	if !ok {
+		return false, nil
+	}
+	current, err := secrets.Current(uc.secrets, "app.secret")
+	if err == nil && current != secret.Value {
+		err = uc.userRepo.UpdatePassword(userModel.ID, password)
+	}
+	if err != nil {
+		logger.GetLogger().Error("unable to hash password with the current secret", logger.Field("error", err))
+	}
+	return true, nil
+}
+
+// otpHash is the hash of the OTP sent to value, carried by the links sent along with it.
+func otpHash(value, otp, secret string) string {
+	return shared.Utils.Auth.Encrypt(value+":"+otp, secret)
+}
+
+// UnlockUser lifts the lockout of a user's account, along with the delays of its failed logins.
+func (uc *authUseCase) UnlockUser(userID string) (err error) {
+	user, err := uc.userRepo.GetOneByID(userID)
+	if err != nil {
+		return errs.Annotate(err, "User not found")
+	}
+	if err = uc.attempts.reset(attemptsPassword, user.Email); err != nil {
+		return errs.NewInternalError("unable to unlock user", err)
+	}
+	uc.publishSecurityEvent(&messages.AuthSecurityEvent{
+		Event:     messages.SecurityEventAccountUnlocked,
+		UserID:    user.ID,
+		Target:    user.Email,
+		Timestamp: time.Now(),
+	})
+	return nil
+}
+
+func (uc *authUseCase) TokenTTL() time.Duration {
+	return uc.config.TokenTTL
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAuthenticateUserByEmailPassword_AcceptsPreviousSecret(t *testing.T) {
+	password := shared.Utils.Auth.Encrypt("s3cr3t-pa55", "old")
+	users := &fakeUserRepo{users: []model.User{{ID: "usr_1", Email: "jane@zard.io", Password: &password, Active: true}}}
+	uc := newTestAuthUseCase(cache.NewMemoryCache(), &recordingPubSub{})
+	uc.userRepo = users
+	uc.secrets = secrets.NewStaticProvider(map[string]*secrets.Versions{"app.secret": {
+		Current:  secrets.Secret{Version: "v2", Value: "new"},
+		Previous: []secrets.Secret{{Version: "v1", Value: "old"}},
+	}})
+
+	_, user, err := uc.AuthenticateUserByEmailPassword("jane@zard.io", "s3cr3t-pa55")
+	require.NoError(t, err)
+	assert.Equal(t, "usr_1", user.ID)
+	assert.Equal(t, "s3cr3t-pa55", *users.users[0].Password, "the password is hashed again with the current secret")
+
+	_, _, err = uc.AuthenticateUserByEmailPassword("jane@zard.io", "wrong")
+	assert.ErrorIs(t, err, errs.Kind(ErrInvalidCredentials.Code))
+}
+
+func TestVerifyUserPassword(t *testing.T) {
+	uc := newTestAuthUseCase(cache.NewMemoryCache(), &recordingPubSub{})
+	assert.NoError(t, uc.VerifyUserPassword("usr_1", testPassword))
+	assert.ErrorIs(t, uc.VerifyUserPassword("usr_1", "wrong"), errs.Kind(ErrInvalidCredentials.Code))
+}
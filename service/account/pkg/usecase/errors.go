@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"embed"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"io/fs"
+)
+
+// Error codes returned by the account service, listed by cmd/errcodes.
+var (
+	ErrEmailAlreadyExists = errs.Register(errs.Entry{Code: "ACCOUNT_EMAIL_ALREADY_EXISTS", Kind: errs.ErrConflict, Message: "Email already exists"})
+	ErrPhoneAlreadyExists = errs.Register(errs.Entry{Code: "ACCOUNT_PHONE_ALREADY_EXISTS", Kind: errs.ErrConflict, Message: "Phone already exists"})
+	ErrUserAlreadyExists  = errs.Register(errs.Entry{Code: "ACCOUNT_USER_ALREADY_EXISTS", Kind: errs.ErrConflict, Message: "Email or phone already exists"})
+	ErrInvalidCredentials = errs.Register(errs.Entry{Code: "ACCOUNT_INVALID_CREDENTIALS", Kind: errs.ErrBadRequest, Message: "Invalid email or password"})
+	ErrInactiveUser       = errs.Register(errs.Entry{Code: "ACCOUNT_INACTIVE_USER", Kind: errs.ErrForbidden, Message: "User is inactive"})
+	ErrOtpAlreadySent     = errs.Register(errs.Entry{Code: "ACCOUNT_OTP_ALREADY_SENT", Kind: errs.ErrBadRequest, Message: "An OTP was already sent, try again later"})
+	ErrInvalidOtp         = errs.Register(errs.Entry{Code: "ACCOUNT_INVALID_OTP", Kind: errs.ErrUnauthorized, Message: "Invalid or expired OTP"})
+	ErrInvalidToken       = errs.Register(errs.Entry{Code: "ACCOUNT_INVALID_TOKEN", Kind: errs.ErrUnauthorized, Message: "Invalid or expired token"})
+	ErrMalformedApiKey    = errs.Register(errs.Entry{Code: "ACCOUNT_MALFORMED_API_KEY", Kind: errs.ErrBadRequest, Message: "Malformed API key"})
+	ErrInvalidApiKey      = errs.Register(errs.Entry{Code: "ACCOUNT_INVALID_API_KEY", Kind: errs.ErrUnauthorized, Message: "Invalid API key"})
+	ErrAccountLocked      = errs.Register(errs.Entry{Code: "ACCOUNT_LOCKED", Kind: errs.ErrTooManyRequests, Message: "Account is locked after too many failed attempts, try again later"})
+	ErrTooManyAttempts    = errs.Register(errs.Entry{Code: "ACCOUNT_TOO_MANY_ATTEMPTS", Kind: errs.ErrTooManyRequests, Message: "Too many failed attempts, try again later"})
+	ErrOtpInvalidated     = errs.Register(errs.Entry{Code: "ACCOUNT_OTP_INVALIDATED", Kind: errs.ErrTooManyRequests, Message: "Too many invalid attempts, request a new OTP"})
+	ErrUnknownRole        = errs.Register(errs.Entry{Code: "ACCOUNT_UNKNOWN_ROLE", Kind: errs.ErrBadRequest, Message: "Unknown role"})
+	ErrReservedRole       = errs.Register(errs.Entry{Code: "ACCOUNT_RESERVED_ROLE", Kind: errs.ErrConflict, Message: "Role name is reserved"})
+	ErrRoleAlreadyExists  = errs.Register(errs.Entry{Code: "ACCOUNT_ROLE_ALREADY_EXISTS", Kind: errs.ErrConflict, Message: "Role already exists"})
+	ErrRoleInUse          = errs.Register(errs.Entry{Code: "ACCOUNT_ROLE_IN_USE", Kind: errs.ErrConflict, Message: "Role is assigned to members"})
+	ErrAlreadyMember      = errs.Register(errs.Entry{Code: "ACCOUNT_ALREADY_MEMBER", Kind: errs.ErrConflict, Message: "User is already a member"})
+	ErrUnknownPermission  = errs.Register(errs.Entry{Code: "ACCOUNT_UNKNOWN_PERMISSION", Kind: errs.ErrBadRequest, Message: "Unknown permission"})
+	ErrPermissionNotHeld  = errs.Register(errs.Entry{Code: "ACCOUNT_PERMISSION_NOT_HELD", Kind: errs.ErrForbidden, Message: "Permissions you do not hold cannot be granted or taken away"})
+	ErrLastOwner          = errs.Register(errs.Entry{Code: "ACCOUNT_LAST_OWNER", Kind: errs.ErrConflict, Message: "An organization must keep at least one owner"})
+)
+
+//go:embed translations/*.json
+var translations embed.FS
+
+// LoadTranslations adds the localized messages of the account error codes, one <lang>.json file
+// per language in translations, to errs.DefaultCatalog.
+func LoadTranslations() error {
+	fsys, err := fs.Sub(translations, "translations")
+	if err != nil {
+		return err
+	}
+	return errs.DefaultCatalog.LoadTranslations(fsys)
+}
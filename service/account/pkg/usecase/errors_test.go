@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestLoadTranslations(t *testing.T) {
+	require.NoError(t, LoadTranslations())
+	for _, entry := range errs.DefaultCatalog.Entries() {
+		if !strings.HasPrefix(entry.Code, "ACCOUNT_") {
+			continue
+		}
+		assert.NotEqual(t, entry.Message, errs.DefaultCatalog.Localize(entry.Code, "ar"), "%s has no arabic translation", entry.Code)
+	}
+	assert.Equal(t, ErrInvalidCredentials.Message, errs.DefaultCatalog.Localize(ErrInvalidCredentials.Code, "en"))
+}
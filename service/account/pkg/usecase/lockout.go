@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	attemptsPassword = "password"
+	attemptsOtp      = "otp"
+)
+
+// attempts are the recent failures of a target, e.g. the password logins of an email.
+type attempts struct {
+	Failures    int
+	RetryAt     time.Time
+	LockedUntil time.Time
+}
+
+// attemptTracker slows down and then stops brute-forcing. Failures are counted atomically per
+// window, and a target is judged on the failures of the current and the previous window, so
+// concurrent attempts cannot slip through between a read and a write. Targets are hashed before
+// they are used in cache keys, so emails neither leak into the cache nor break its key format.
+type attemptTracker struct {
+	cache  cache.Cache
+	config LockoutConfig
+	now    func() time.Time
+}
+
+func newAttemptTracker(c cache.Cache, config LockoutConfig) *attemptTracker {
+	return &attemptTracker{cache: c, config: config, now: time.Now}
+}
+
+func (t *attemptTracker) keyPath(kind, target string, key ...string) []string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(target))))
+	return append([]string{"account", "auth", "attempts", kind, hex.EncodeToString(sum[:16])}, key...)
+}
+
+func (t *attemptTracker) failuresKeyPath(kind, target string, window int64) []string {
+	return t.keyPath(kind, target, "failures", strconv.FormatInt(window, 10))
+}
+
+// failuresKeyPaths returns the counters of the current and the previous window.
+func (t *attemptTracker) failuresKeyPaths(kind, target string) [][]string {
+	window := t.window()
+	return [][]string{t.failuresKeyPath(kind, target, window), t.failuresKeyPath(kind, target, window-1)}
+}
+
+func (t *attemptTracker) window() int64 {
+	return t.now().UnixNano() / int64(t.config.Window)
+}
+
+// getTime returns the time stored at key in unix nanoseconds, or the zero time when it is missing or past.
+func (t *attemptTracker) getTime(kind, target, key string) time.Time {
+	value := cache.GetCounter(t.cache, t.keyPath(kind, target, key))
+	if at := time.Unix(0, value); value != 0 && at.After(t.now()) {
+		return at
+	}
+	return time.Time{}
+}
+
+func (t *attemptTracker) setTime(kind, target, key string, at time.Time) error {
+	ttl := at.Sub(t.now())
+	if ttl <= 0 {
+		return nil
+	}
+	return t.cache.Set(t.keyPath(kind, target, key), []byte(strconv.FormatInt(at.UnixNano(), 10)), ttl)
+}
+
+func (t *attemptTracker) get(kind, target string) attempts {
+	var failures int64
+	for _, keyPath := range t.failuresKeyPaths(kind, target) {
+		failures += cache.GetCounter(t.cache, keyPath)
+	}
+	return attempts{
+		Failures:    int(failures),
+		RetryAt:     t.getTime(kind, target, "retryAt"),
+		LockedUntil: t.getTime(kind, target, "lockedUntil"),
+	}
+}
+
+// check returns an error while target is locked, or has to wait before its next attempt.
+func (t *attemptTracker) check(kind, target string) error {
+	now := t.now()
+	if lockedUntil := t.getTime(kind, target, "lockedUntil"); !lockedUntil.IsZero() {
+		return withRetryAfter(ErrAccountLocked.New(nil), lockedUntil.Sub(now))
+	}
+	if retryAt := t.getTime(kind, target, "retryAt"); !retryAt.IsZero() {
+		return withRetryAfter(ErrTooManyAttempts.New(nil), retryAt.Sub(now))
+	}
+	return nil
+}
+
+// fail records a failure of target, and locks it for the lockout duration once it has maxAttempts
+// failures. It reports whether this failure locked the target, which only one of concurrent
+// failures does.
+func (t *attemptTracker) fail(kind, target string, maxAttempts int) (attempts, bool, error) {
+	now, window := t.now(), t.window()
+	current, err := cache.Increment(t.cache, t.failuresKeyPath(kind, target, window), 1, 2*t.config.Window)
+	if err != nil {
+		return attempts{}, false, err
+	}
+	a := attempts{Failures: int(current + cache.GetCounter(t.cache, t.failuresKeyPath(kind, target, window-1)))}
+	if a.Failures < maxAttempts {
+		a.RetryAt = now.Add(t.delay(a.Failures))
+		return a, false, t.setTime(kind, target, "retryAt", a.RetryAt)
+	}
+	// concurrent failures may all reach maxAttempts, the lock is the one counted first
+	locks, err := cache.Increment(t.cache, t.keyPath(kind, target, "locks"), 1, t.config.Duration)
+	if err != nil || locks > 1 {
+		return a, false, err
+	}
+	a.LockedUntil = now.Add(t.config.Duration)
+	if err = t.setTime(kind, target, "lockedUntil", a.LockedUntil); err != nil {
+		return a, false, err
+	}
+	// the lock settles these failures, they do not count towards the next one
+	return a, true, t.clear(t.failuresKeyPaths(kind, target)...)
+}
+
+// reset forgets the failures of target, along with its delay and lock.
+func (t *attemptTracker) reset(kind, target string) error {
+	keyPaths := append(t.failuresKeyPaths(kind, target), t.keyPath(kind, target, "retryAt"), t.keyPath(kind, target, "lockedUntil"), t.keyPath(kind, target, "locks"))
+	return t.clear(keyPaths...)
+}
+
+// clear deletes the keys that exist among keyPaths.
+func (t *attemptTracker) clear(keyPaths ...[]string) error {
+	for _, keyPath := range keyPaths {
+		if _, err := t.cache.Get(keyPath); err != nil {
+			continue
+		}
+		if err := t.cache.Delete(keyPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// delay doubles with every failure, starting at the configured delay and capped at the max delay.
+func (t *attemptTracker) delay(failures int) time.Duration {
+	d := float64(t.config.Delay) * math.Pow(2, float64(failures-1))
+	return time.Duration(math.Min(d, float64(t.config.MaxDelay)))
+}
+
+func withRetryAfter(err errs.CustomError, d time.Duration) errs.CustomError {
+	err.Fields = map[string]string{"retryAfter": strconv.Itoa(int(math.Ceil(d.Seconds())))}
+	return err
+}
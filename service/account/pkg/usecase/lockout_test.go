@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/service/account/pkg/model"
+	"github.com/abdelrahman146/zard/service/account/pkg/repo"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/pubsub"
+	"github.com/abdelrahman146/zard/shared/pubsub/messages"
+	"github.com/abdelrahman146/zard/shared/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type recordingPubSub struct {
+	pubsub.PubSub
+	published []messages.Message
+}
+
+func (p *recordingPubSub) Publish(_ context.Context, message messages.Message) error {
+	p.published = append(p.published, message)
+	return nil
+}
+
+type fakeUserRepo struct {
+	repo.UserRepo
+	users []model.User
+}
+
+func (f *fakeUserRepo) GetOneByEmail(email string) (*model.User, error) {
+	for _, u := range f.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, errs.NewNotFoundError("record not found", nil)
+}
+
+// UpdatePassword keeps the password the repo would hash, as given.
+func (f *fakeUserRepo) UpdatePassword(id string, password string) error {
+	for i := range f.users {
+		if f.users[i].ID == id {
+			f.users[i].Password = &password
+			return nil
+		}
+	}
+	return errs.NewNotFoundError("record not found", nil)
+}
+
+func (f *fakeUserRepo) GetOneByID(id string) (*model.User, error) {
+	for _, u := range f.users {
+		if u.ID == id {
+			return &u, nil
+		}
+	}
+	return nil, errs.NewNotFoundError("record not found", nil)
+}
+
+const testPassword = "pa55word"
+
+var testLockout = LockoutConfig{MaxAttempts: 3, MaxOtpAttempts: 3, Window: 15 * time.Minute, Duration: 15 * time.Minute}
+
+func newTestAuthUseCase(c cache.Cache, ps *recordingPubSub) *authUseCase {
+	password := shared.Utils.Auth.Encrypt(testPassword, "secret")
+	return &authUseCase{
+		toolkit:  shared.Toolkit{Cache: c, PubSub: ps},
+		config:   AuthConfig{Lockout: testLockout},
+		secrets:  secrets.NewStaticProvider(map[string]*secrets.Versions{"app.secret": {Current: secrets.Secret{Version: "current", Value: "secret"}}}),
+		attempts: newAttemptTracker(c, testLockout),
+		userRepo: &fakeUserRepo{users: []model.User{{ID: "usr_1", Email: "jane@zard.io", Password: &password, Active: true}}},
+	}
+}
+
+func TestAttemptTracker(t *testing.T) {
+	now := time.Unix(0, 0)
+	tracker := newAttemptTracker(cache.NewMemoryCache(), LockoutConfig{MaxAttempts: 4, Window: time.Hour, Duration: 10 * time.Minute, Delay: time.Second, MaxDelay: 3 * time.Second})
+	tracker.now = func() time.Time { return now }
+
+	assert.NoError(t, tracker.check(attemptsPassword, "jane@zard.io"))
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		_, locked, err := tracker.fail(attemptsPassword, "Jane@zard.io", 4)
+		require.NoError(t, err)
+		assert.False(t, locked)
+		err = tracker.check(attemptsPassword, "jane@zard.io")
+		assert.ErrorIs(t, err, errs.Kind(ErrTooManyAttempts.Code))
+		now = now.Add(delay)
+		assert.NoError(t, tracker.check(attemptsPassword, "jane@zard.io"), "waits %s", delay)
+	}
+	a, locked, err := tracker.fail(attemptsPassword, "jane@zard.io", 4)
+	require.NoError(t, err)
+	assert.True(t, locked)
+	assert.Equal(t, 4, a.Failures)
+	err = tracker.check(attemptsPassword, "jane@zard.io")
+	assert.ErrorIs(t, err, errs.Kind(ErrAccountLocked.Code))
+	assert.Equal(t, "600", errs.HandleError(err).Fields["retryAfter"])
+	assert.NoError(t, tracker.check(attemptsOtp, "jane@zard.io"), "kinds are tracked apart")
+
+	now = now.Add(time.Hour)
+	assert.NoError(t, tracker.check(attemptsPassword, "jane@zard.io"))
+	assert.Equal(t, attempts{}, tracker.get(attemptsPassword, "jane@zard.io"), "failures expire with the window")
+}
+
+func TestAttemptTracker_ConcurrentFailures(t *testing.T) {
+	tracker := newAttemptTracker(cache.NewMemoryCache(), testLockout)
+	var wg sync.WaitGroup
+	var locks atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, locked, err := tracker.fail(attemptsPassword, "jane@zard.io", testLockout.MaxAttempts); err == nil && locked {
+				locks.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), locks.Load(), "exactly one failure locks the account")
+	assert.ErrorIs(t, tracker.check(attemptsPassword, "jane@zard.io"), errs.Kind(ErrAccountLocked.Code))
+}
+
+func TestAuthenticateUserByEmailPassword_LocksOut(t *testing.T) {
+	ps := &recordingPubSub{}
+	uc := newTestAuthUseCase(cache.NewMemoryCache(), ps)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := uc.AuthenticateUserByEmailPassword("jane@zard.io", "wrong")
+		assert.ErrorIs(t, err, errs.Kind(ErrInvalidCredentials.Code))
+	}
+	_, _, err := uc.AuthenticateUserByEmailPassword("jane@zard.io", "wrong")
+	assert.ErrorIs(t, err, errs.Kind(ErrAccountLocked.Code))
+	require.Len(t, ps.published, 1)
+	event := ps.published[0].(*messages.AuthSecurityEvent)
+	assert.Equal(t, messages.SecurityEventAccountLocked, event.Event)
+	assert.Equal(t, "usr_1", event.UserID)
+	assert.Equal(t, 3, event.Attempts)
+
+	_, _, err = uc.AuthenticateUserByEmailPassword("jane@zard.io", testPassword)
+	assert.ErrorIs(t, err, errs.Kind(ErrAccountLocked.Code), "the right password does not get through a lockout")
+
+	require.NoError(t, uc.UnlockUser("usr_1"))
+	assert.Equal(t, messages.SecurityEventAccountUnlocked, ps.published[1].(*messages.AuthSecurityEvent).Event)
+	_, _, err = uc.AuthenticateUserByEmailPassword("jane@zard.io", "wrong")
+	assert.ErrorIs(t, err, errs.Kind(ErrInvalidCredentials.Code))
+
+	assert.ErrorIs(t, uc.UnlockUser("usr_404"), errs.ErrNotFound)
+}
+
+func TestAuthenticateUserByEmailPassword_ResetsFailuresOnSuccess(t *testing.T) {
+	uc := newTestAuthUseCase(cache.NewMemoryCache(), &recordingPubSub{})
+	uc.attempts.now = func() time.Time { return time.Now().Add(time.Minute) } // past the delays of the failures
+
+	for i := 0; i < 2; i++ {
+		_, _, err := uc.AuthenticateUserByEmailPassword("jane@zard.io", "wrong")
+		assert.ErrorIs(t, err, errs.Kind(ErrInvalidCredentials.Code))
+	}
+	token, user, err := uc.AuthenticateUserByEmailPassword("jane@zard.io", testPassword)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, "usr_1", user.ID)
+	assert.Equal(t, attempts{}, uc.attempts.get(attemptsPassword, "jane@zard.io"), "a successful login clears the failures")
+
+	for i := 0; i < 2; i++ {
+		_, _, err = uc.AuthenticateUserByEmailPassword("jane@zard.io", "wrong")
+		assert.ErrorIs(t, err, errs.Kind(ErrInvalidCredentials.Code), "the failures before the login are not counted")
+	}
+}
+
+func TestAuthenticateUserByEmailPassword_TracksUnknownEmails(t *testing.T) {
+	uc := newTestAuthUseCase(cache.NewMemoryCache(), &recordingPubSub{})
+	var err error
+	for i := 0; i < 3; i++ {
+		_, _, err = uc.AuthenticateUserByEmailPassword("nobody@zard.io", "wrong")
+	}
+	assert.ErrorIs(t, err, errs.Kind(ErrAccountLocked.Code))
+}
+
+func TestVerifyOTP_InvalidatesAfterTooManyAttempts(t *testing.T) {
+	c := cache.NewMemoryCache()
+	require.NoError(t, c.Set([]string{"account", "auth", "otp", "jane@zard.io"}, []byte("123456"), time.Minute))
+	ps := &recordingPubSub{}
+	uc := newTestAuthUseCase(c, ps)
+
+	assert.ErrorIs(t, uc.VerifyOTP("jane@zard.io", "000000"), errs.Kind(ErrInvalidOtp.Code))
+	assert.ErrorIs(t, uc.VerifyOTP("jane@zard.io", "000001"), errs.Kind(ErrInvalidOtp.Code))
+	assert.ErrorIs(t, uc.VerifyOTP("jane@zard.io", "000002"), errs.Kind(ErrOtpInvalidated.Code))
+	require.Len(t, ps.published, 1)
+	assert.Equal(t, messages.SecurityEventOtpInvalidated, ps.published[0].(*messages.AuthSecurityEvent).Event)
+	assert.ErrorIs(t, uc.VerifyOTP("jane@zard.io", "123456"), errs.Kind(ErrInvalidOtp.Code), "the OTP is gone")
+
+	require.NoError(t, c.Set([]string{"account", "auth", "otp", "jane@zard.io"}, []byte("654321"), time.Minute))
+	assert.NoError(t, uc.VerifyOTP("jane@zard.io", "654321"), "a new OTP starts with a clean slate")
+}
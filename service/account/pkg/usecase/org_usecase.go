@@ -5,6 +5,7 @@ import (
 	"github.com/abdelrahman146/zard/service/account/pkg/repo"
 	"github.com/abdelrahman146/zard/shared"
 	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/query"
 )
 
 type OrgUseCase interface {
@@ -15,8 +16,7 @@ type OrgUseCase interface {
 	GetOrgByEmail(email string) (*model.Organization, error)
 	GetOrgByUserID(userID string) (*model.Organization, error)
 	GetOrgByWorkspaceID(workspaceID string) (*model.Organization, error)
-	GetAll(page int, limit int) (*shared.List[model.Organization], error)
-	Search(keyword string, page int, limit int) (*shared.List[model.Organization], error)
+	GetAll(spec query.Spec) (*shared.List[model.Organization], error)
 }
 
 func NewOrgUseCase(toolkit shared.Toolkit, orgRepo repo.OrgRepo, userRepo repo.UserRepo, workspaceRepo repo.WorkspaceRepo) OrgUseCase {
@@ -50,7 +50,7 @@ func (uc *orgUseCase) CreateOrg(orgDto CreateOrgStruct) (*model.Organization, er
 		Address: orgDto.Address,
 	}
 	if err := uc.orgRepo.Create(org); err != nil {
-		return nil, errs.NewInternalError("failed to create organization", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to create organization")
 	}
 	return org, nil
 }
@@ -62,7 +62,7 @@ func (uc *orgUseCase) UpdateOrg(id string, orgDto UpdateOrgStruct) (*model.Organ
 	}
 	org, err := uc.orgRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("Organization not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "Organization not found")
 	}
 	if orgDto.Name != nil {
 		org.Name = *orgDto.Name
@@ -86,14 +86,14 @@ func (uc *orgUseCase) UpdateOrg(id string, orgDto UpdateOrgStruct) (*model.Organ
 		org.Address = *orgDto.Address
 	}
 	if err := uc.orgRepo.Save(org); err != nil {
-		return nil, errs.NewInternalError("failed to update organization", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update organization")
 	}
 	return org, nil
 }
 
 func (uc *orgUseCase) DeleteOrg(id string) error {
 	if err := uc.orgRepo.Delete(id); err != nil {
-		return errs.NewInternalError("failed to delete organization", err)
+		return errs.AnnotateKind(err, errs.ErrInternal, "failed to delete organization")
 	}
 	return nil
 }
@@ -101,7 +101,7 @@ func (uc *orgUseCase) DeleteOrg(id string) error {
 func (uc *orgUseCase) GetOrgByID(id string) (*model.Organization, error) {
 	org, err := uc.orgRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("Organization not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "Organization not found")
 	}
 	return org, nil
 }
@@ -109,7 +109,7 @@ func (uc *orgUseCase) GetOrgByID(id string) (*model.Organization, error) {
 func (uc *orgUseCase) GetOrgByEmail(email string) (*model.Organization, error) {
 	org, err := uc.orgRepo.GetOneByEmail(email)
 	if err != nil {
-		return nil, errs.NewNotFoundError("Organization not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "Organization not found")
 	}
 	return org, nil
 }
@@ -117,11 +117,11 @@ func (uc *orgUseCase) GetOrgByEmail(email string) (*model.Organization, error) {
 func (uc *orgUseCase) GetOrgByUserID(userID string) (*model.Organization, error) {
 	user, err := uc.userRepo.GetOneByID(userID)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	org, err := uc.orgRepo.GetOneByID(user.OrgID)
 	if err != nil {
-		return nil, errs.NewNotFoundError("Failed to get organization", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "Failed to get organization")
 	}
 	return org, nil
 }
@@ -129,37 +129,19 @@ func (uc *orgUseCase) GetOrgByUserID(userID string) (*model.Organization, error)
 func (uc *orgUseCase) GetOrgByWorkspaceID(workspaceID string) (*model.Organization, error) {
 	workspace, err := uc.workspaceRepo.GetOneByID(workspaceID)
 	if err != nil {
-		return nil, errs.NewNotFoundError("Workspace not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "Workspace not found")
 	}
 	org, err := uc.orgRepo.GetOneByID(workspace.OrgID)
 	if err != nil {
-		return nil, errs.NewNotFoundError("Failed to get organization", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "Failed to get organization")
 	}
 	return org, nil
 }
 
-func (uc *orgUseCase) GetAll(page int, limit int) (*shared.List[model.Organization], error) {
-	orgs, total, err := uc.orgRepo.GetAll(page, limit)
+func (uc *orgUseCase) GetAll(spec query.Spec) (*shared.List[model.Organization], error) {
+	orgs, total, err := uc.orgRepo.GetAll(spec)
 	if err != nil {
-		return nil, errs.NewInternalError("Failed to get organizations", err)
-	}
-	return &shared.List[model.Organization]{
-		Items: orgs,
-		Page:  page,
-		Limit: limit,
-		Total: total,
-	}, nil
-}
-
-func (uc *orgUseCase) Search(keyword string, page int, limit int) (*shared.List[model.Organization], error) {
-	orgs, total, err := uc.orgRepo.Search(keyword, page, limit)
-	if err != nil {
-		return nil, errs.NewInternalError("Failed to search organizations", err)
-	}
-	return &shared.List[model.Organization]{
-		Items: orgs,
-		Page:  page,
-		Limit: limit,
-		Total: total,
-	}, nil
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "Failed to get organizations")
+	}
+	return shared.NewList(orgs, total, spec), nil
 }
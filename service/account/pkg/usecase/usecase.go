@@ -1,17 +1,64 @@
 package usecase
 
 import (
+	"github.com/abdelrahman146/zard/service/account/pkg/repo"
+	"github.com/abdelrahman146/zard/shared"
+	"github.com/abdelrahman146/zard/shared/auth"
 	"gorm.io/gorm"
 	"time"
 )
 
 type AccountUseCases struct {
+	AccessUseCase    AccessUseCase
 	AuthUseCase      AuthUseCase
 	OrgUseCase       OrgUseCase
 	UserUseCase      UserUseCase
 	WorkspaceUseCase WorkspaceUseCase
 }
 
+// AccountRepos are the repos the account use cases are built on.
+type AccountRepos struct {
+	Membership repo.MembershipRepo
+	Org        repo.OrgRepo
+	Role       repo.RoleRepo
+	User       repo.UserRepo
+	Workspace  repo.WorkspaceRepo
+}
+
+// NewAccountUseCases builds the use cases of the account service, and installs AccessUseCase as the
+// auth.Policy, so the permissions RequirePermission checks come from the memberships of users.
+func NewAccountUseCases(toolkit shared.Toolkit, repos AccountRepos) *AccountUseCases {
+	usecases := &AccountUseCases{
+		AccessUseCase:    NewAccessUseCase(toolkit, repos.Membership, repos.Role, repos.Workspace),
+		AuthUseCase:      NewAuthUseCase(toolkit, repos.User, repos.Workspace),
+		OrgUseCase:       NewOrgUseCase(toolkit, repos.Org, repos.User, repos.Workspace),
+		UserUseCase:      NewUserUseCase(toolkit, repos.User),
+		WorkspaceUseCase: NewWorkspaceUseCase(toolkit, repos.Workspace),
+	}
+	auth.SetPolicy(usecases.AccessUseCase)
+	return usecases
+}
+
+type AuthConfig struct {
+	TokenTTL  time.Duration `config:"app.auth.tokenTTL" default:"24h"`
+	OtpTTL    time.Duration `config:"app.auth.otpTTL" default:"5m"`
+	ApiKeyTTL time.Duration `config:"app.auth.apiKeyTTL" default:"1h"`
+	Lockout   LockoutConfig `config:"app.auth.lockout"`
+}
+
+// LockoutConfig bounds failed password and OTP attempts. After a failure, the next attempt has to
+// wait Delay, doubled with every further failure up to MaxDelay. MaxAttempts failed logins within
+// the current and the previous Window lock the account for Duration, and MaxOtpAttempts invalidate
+// the OTP.
+type LockoutConfig struct {
+	MaxAttempts    int           `config:"maxAttempts" default:"5" validate:"gte=1"`
+	MaxOtpAttempts int           `config:"maxOtpAttempts" default:"5" validate:"gte=1"`
+	Window         time.Duration `config:"window" default:"15m" validate:"gt=0"`
+	Duration       time.Duration `config:"duration" default:"15m"`
+	Delay          time.Duration `config:"delay" default:"1s"`
+	MaxDelay       time.Duration `config:"maxDelay" default:"30s"`
+}
+
 type CreateOrgStruct struct {
 	Name    string  `json:"name,omitempty" validate:"required,omitempty"`
 	Website *string `json:"website,omitempty"`
@@ -61,10 +108,23 @@ type CreateUserStruct struct {
 	Name     string  `json:"name,omitempty" validate:"required,omitempty"`
 	Email    string  `json:"email,omitempty" validate:"required,email"`
 	Phone    *string `json:"phone,omitempty" validate:"phone"`
-	Password *string `json:"password,omitempty" validate:"required,omitempty"`
+	Password *string `json:"password,omitempty" validate:"required,omitempty" sensitive:"true"`
 	OrgID    string  `json:"orgId,omitempty" validate:"required,omitempty"`
 }
 
 type UpdateUserStruct struct {
 	Name *string `json:"name,omitempty"`
 }
+
+type AddMemberStruct struct {
+	UserID      string `json:"userId,omitempty" validate:"required"`
+	OrgID       string `json:"orgId,omitempty" validate:"required"`
+	WorkspaceID string `json:"workspaceId,omitempty"`
+	Role        string `json:"role,omitempty" validate:"required"`
+}
+
+type CreateRoleStruct struct {
+	OrgID       string   `json:"orgId,omitempty" validate:"required"`
+	Name        string   `json:"name,omitempty" validate:"required"`
+	Permissions []string `json:"permissions,omitempty" validate:"required,min=1"`
+}
@@ -1,12 +1,15 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"github.com/abdelrahman146/zard/service/account/pkg/model"
 	"github.com/abdelrahman146/zard/service/account/pkg/repo"
 	"github.com/abdelrahman146/zard/shared"
 	"github.com/abdelrahman146/zard/shared/errs"
 	"github.com/abdelrahman146/zard/shared/logger"
 	"github.com/abdelrahman146/zard/shared/pubsub/messages"
+	"github.com/abdelrahman146/zard/shared/query"
 	"time"
 )
 
@@ -24,9 +27,9 @@ type UserUseCase interface {
 	GetUserByID(id string) (*UserStruct, error)
 	GetUserByEmail(email string) (*UserStruct, error)
 	GetUserByPhone(phone string) (*UserStruct, error)
-	GetAll(page int, limit int) (*shared.List[UserStruct], error)
-	GetUsersByOrgID(orgID string, page int, limit int) (*shared.List[UserStruct], error)
-	Search(keyword string, page int, limit int) (*shared.List[UserStruct], error)
+	GetUsersByIDs(ids []string) ([]UserStruct, error)
+	GetAll(spec query.Spec) (*shared.List[UserStruct], error)
+	GetUsersByOrgID(orgID string, spec query.Spec) (*shared.List[UserStruct], error)
 }
 
 func NewUserUseCase(toolkit shared.Toolkit, userRepo repo.UserRepo) UserUseCase {
@@ -71,13 +74,17 @@ func (uc *userUseCase) CreateUser(userDto *CreateUserStruct) (*UserStruct, error
 		return nil, errs.NewValidationError("invalid user data", fields)
 	}
 	if userDto.Email != "" {
-		if user, _ := uc.userRepo.GetOneByEmail(userDto.Email); user != nil {
-			return nil, errs.NewConflictError("email already exists", nil)
+		if _, err := uc.userRepo.GetOneByEmail(userDto.Email); err == nil {
+			return nil, ErrEmailAlreadyExists.New(nil)
+		} else if !errors.Is(err, errs.ErrNotFound) {
+			return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to create user")
 		}
 	}
-	if userDto.Phone != nil || *userDto.Phone != "" {
-		if user, _ := uc.userRepo.GetOneByPhone(*userDto.Phone); user != nil {
-			return nil, errs.NewConflictError("phone already exists", nil)
+	if userDto.Phone != nil && *userDto.Phone != "" {
+		if _, err := uc.userRepo.GetOneByPhone(*userDto.Phone); err == nil {
+			return nil, ErrPhoneAlreadyExists.New(nil)
+		} else if !errors.Is(err, errs.ErrNotFound) {
+			return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to create user")
 		}
 	}
 	user := &model.User{
@@ -90,8 +97,11 @@ func (uc *userUseCase) CreateUser(userDto *CreateUserStruct) (*UserStruct, error
 		Active:          true,
 		OrgID:           userDto.OrgID,
 	}
-	if err := uc.userRepo.Create(user); err != nil {
-		return nil, errs.NewInternalError("failed to create user", err)
+	// the checks above race with concurrent sign ups, the unique constraints have the last word
+	if err := uc.userRepo.Create(user); errors.Is(err, errs.ErrConflict) {
+		return nil, ErrUserAlreadyExists.New(err)
+	} else if err != nil {
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to create user")
 	}
 	userCreatedMessage := &messages.UserCreatedMessage{
 		UserID:    user.ID,
@@ -99,7 +109,7 @@ func (uc *userUseCase) CreateUser(userDto *CreateUserStruct) (*UserStruct, error
 		Email:     user.Email,
 		Timestamp: time.Now(),
 	}
-	if err := uc.toolkit.PubSub.Publish(userCreatedMessage); err != nil {
+	if err := uc.toolkit.PubSub.Publish(context.TODO(), userCreatedMessage); err != nil {
 		logger.GetLogger().Error("failed to publish user created message", logger.Field("error", err))
 	}
 	return uc.ToUserStruct(user), nil
@@ -112,13 +122,13 @@ func (uc *userUseCase) UpdateUser(id string, userDto *UpdateUserStruct) (*UserSt
 	}
 	user, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	if userDto.Name != nil {
 		user.Name = *userDto.Name
 	}
 	if err := uc.userRepo.Save(user); err != nil {
-		return nil, errs.NewInternalError("failed to update user", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update user")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -126,7 +136,7 @@ func (uc *userUseCase) UpdateUser(id string, userDto *UpdateUserStruct) (*UserSt
 func (uc *userUseCase) UpdateUserEmail(id string, email string) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	if user.Email == email {
 		return uc.ToUserStruct(user), nil
@@ -134,7 +144,7 @@ func (uc *userUseCase) UpdateUserEmail(id string, email string) (*UserStruct, er
 	user.Email = email
 	user.IsEmailVerified = false
 	if err := uc.userRepo.Save(user); err != nil {
-		return nil, errs.NewInternalError("failed to update user", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update user")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -142,7 +152,7 @@ func (uc *userUseCase) UpdateUserEmail(id string, email string) (*UserStruct, er
 func (uc *userUseCase) UpdateUserPhone(id string, phone string) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	if user.Phone != nil && *user.Phone == phone {
 		return uc.ToUserStruct(user), nil
@@ -150,7 +160,7 @@ func (uc *userUseCase) UpdateUserPhone(id string, phone string) (*UserStruct, er
 	user.Phone = &phone
 	user.IsPhoneVerified = false
 	if err := uc.userRepo.Save(user); err != nil {
-		return nil, errs.NewInternalError("failed to update user", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update user")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -158,10 +168,10 @@ func (uc *userUseCase) UpdateUserPhone(id string, phone string) (*UserStruct, er
 func (uc *userUseCase) UpdateUserPassword(id string, password string) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	if err := uc.userRepo.UpdatePassword(id, password); err != nil {
-		return nil, errs.NewInternalError("failed to update user", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update user")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -169,11 +179,11 @@ func (uc *userUseCase) UpdateUserPassword(id string, password string) (*UserStru
 func (uc *userUseCase) DeactivateUser(id string) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	user.Active = false
 	if err := uc.userRepo.Save(user); err != nil {
-		return nil, errs.NewInternalError("failed to update user", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update user")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -181,11 +191,11 @@ func (uc *userUseCase) DeactivateUser(id string) (*UserStruct, error) {
 func (uc *userUseCase) ActivateUser(id string) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	user.Active = true
 	if err := uc.userRepo.Save(user); err != nil {
-		return nil, errs.NewInternalError("failed to update user", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update user")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -193,11 +203,11 @@ func (uc *userUseCase) ActivateUser(id string) (*UserStruct, error) {
 func (uc *userUseCase) SetUserEmailVerified(id string, verified bool) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	user.IsEmailVerified = verified
 	if err := uc.userRepo.Save(user); err != nil {
-		return nil, errs.NewInternalError("failed to update user", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update user")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -205,11 +215,11 @@ func (uc *userUseCase) SetUserEmailVerified(id string, verified bool) (*UserStru
 func (uc *userUseCase) SetUserPhoneVerified(id string, verified bool) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	user.IsPhoneVerified = verified
 	if err := uc.userRepo.Save(user); err != nil {
-		return nil, errs.NewInternalError("failed to update user", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update user")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -217,7 +227,7 @@ func (uc *userUseCase) SetUserPhoneVerified(id string, verified bool) (*UserStru
 func (uc *userUseCase) DeleteUser(id string) error {
 	_, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return errs.NewNotFoundError("User not found", err)
+		return errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	return uc.userRepo.Delete(id)
 }
@@ -225,7 +235,7 @@ func (uc *userUseCase) DeleteUser(id string) error {
 func (uc *userUseCase) GetUserByID(id string) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -233,7 +243,7 @@ func (uc *userUseCase) GetUserByID(id string) (*UserStruct, error) {
 func (uc *userUseCase) GetUserByEmail(email string) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByEmail(email)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	return uc.ToUserStruct(user), nil
 }
@@ -241,34 +251,31 @@ func (uc *userUseCase) GetUserByEmail(email string) (*UserStruct, error) {
 func (uc *userUseCase) GetUserByPhone(phone string) (*UserStruct, error) {
 	user, err := uc.userRepo.GetOneByPhone(phone)
 	if err != nil {
-		return nil, errs.NewNotFoundError("User not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "User not found")
 	}
 	return uc.ToUserStruct(user), nil
 }
 
-func (uc *userUseCase) GetAll(page int, limit int) (*shared.List[UserStruct], error) {
-	users, total, err := uc.userRepo.GetAll(page, limit)
+func (uc *userUseCase) GetUsersByIDs(ids []string) ([]UserStruct, error) {
+	users, err := uc.userRepo.GetAllByIDs(ids)
 	if err != nil {
-		return nil, errs.NewInternalError("failed to get users", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get users")
 	}
-	userList := uc.ToUserStructList(users)
-	return &shared.List[UserStruct]{Items: userList, Total: total, Page: page, Limit: limit}, nil
+	return uc.ToUserStructList(users), nil
 }
 
-func (uc *userUseCase) GetUsersByOrgID(orgID string, page int, limit int) (*shared.List[UserStruct], error) {
-	users, total, err := uc.userRepo.GetAllByOrgID(orgID, page, limit)
+func (uc *userUseCase) GetAll(spec query.Spec) (*shared.List[UserStruct], error) {
+	users, total, err := uc.userRepo.GetAll(spec)
 	if err != nil {
-		return nil, errs.NewInternalError("failed to get users", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get users")
 	}
-	userList := uc.ToUserStructList(users)
-	return &shared.List[UserStruct]{Items: userList, Total: total, Page: page, Limit: limit}, nil
+	return shared.NewList(uc.ToUserStructList(users), total, spec), nil
 }
 
-func (uc *userUseCase) Search(keyword string, page int, limit int) (*shared.List[UserStruct], error) {
-	users, total, err := uc.userRepo.Search(keyword, page, limit)
+func (uc *userUseCase) GetUsersByOrgID(orgID string, spec query.Spec) (*shared.List[UserStruct], error) {
+	users, total, err := uc.userRepo.GetAllByOrgID(orgID, spec)
 	if err != nil {
-		return nil, errs.NewInternalError("failed to search users", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get users")
 	}
-	userList := uc.ToUserStructList(users)
-	return &shared.List[UserStruct]{Items: userList, Total: total, Page: page, Limit: limit}, nil
+	return shared.NewList(uc.ToUserStructList(users), total, spec), nil
 }
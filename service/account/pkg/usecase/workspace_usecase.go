@@ -5,6 +5,7 @@ import (
 	"github.com/abdelrahman146/zard/service/account/pkg/repo"
 	"github.com/abdelrahman146/zard/shared"
 	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/query"
 )
 
 type WorkspaceUseCase interface {
@@ -14,9 +15,8 @@ type WorkspaceUseCase interface {
 	DeleteWorkSpace(id string) error
 	GetWorkSpaceByID(id string) (*model.Workspace, error)
 	GetWorkSpaceByApiKey(apiKey string) (*model.Workspace, error)
-	GetAll(page int, limit int) (*shared.List[model.Workspace], error)
-	GetAllByOrgID(orgID string, page int, limit int) (*shared.List[model.Workspace], error)
-	Search(keyword string, page int, limit int) (*shared.List[model.Workspace], error)
+	GetAll(spec query.Spec) (*shared.List[model.Workspace], error)
+	GetAllByOrgID(orgID string, spec query.Spec) (*shared.List[model.Workspace], error)
 }
 
 func NewWorkspaceUseCase(toolkit shared.Toolkit, wsRepo repo.WorkspaceRepo) WorkspaceUseCase {
@@ -42,7 +42,7 @@ func (uc *wsUseCase) CreateWorkSpace(wsDto *CreateWorkspaceStruct) (*model.Works
 		OrgID:   wsDto.OrgID,
 	}
 	if err := uc.wsRepo.Create(ws); err != nil {
-		return nil, errs.NewInternalError("failed to create workspace", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to create workspace")
 	}
 	return ws, nil
 }
@@ -54,7 +54,7 @@ func (uc *wsUseCase) UpdateWorkSpace(id string, wsDto *UpdateWorkspaceStruct) (*
 	}
 	ws, err := uc.wsRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("workspace not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "workspace not found")
 	}
 	if wsDto.Website != nil {
 		ws.Website = wsDto.Website
@@ -63,7 +63,7 @@ func (uc *wsUseCase) UpdateWorkSpace(id string, wsDto *UpdateWorkspaceStruct) (*
 		ws.Name = *wsDto.Name
 	}
 	if err := uc.wsRepo.Save(ws); err != nil {
-		return nil, errs.NewInternalError("failed to update workspace", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to update workspace")
 	}
 	return ws, nil
 }
@@ -71,7 +71,7 @@ func (uc *wsUseCase) UpdateWorkSpace(id string, wsDto *UpdateWorkspaceStruct) (*
 func (uc *wsUseCase) ResetApiKey(id string) (apikey string, err error) {
 	ws, err := uc.wsRepo.ResetApiKey(id)
 	if err != nil {
-		return "", errs.NewInternalError("failed to reset api key", err)
+		return "", errs.AnnotateKind(err, errs.ErrInternal, "failed to reset api key")
 	}
 	return ws.ApiKey, nil
 }
@@ -79,7 +79,7 @@ func (uc *wsUseCase) ResetApiKey(id string) (apikey string, err error) {
 func (uc *wsUseCase) DeleteWorkSpace(id string) error {
 	_, err := uc.wsRepo.GetOneByID(id)
 	if err != nil {
-		return errs.NewNotFoundError("workspace not found", err)
+		return errs.AnnotateKind(err, errs.ErrNotFound, "workspace not found")
 	}
 	return uc.wsRepo.Delete(id)
 }
@@ -87,7 +87,7 @@ func (uc *wsUseCase) DeleteWorkSpace(id string) error {
 func (uc *wsUseCase) GetWorkSpaceByID(id string) (*model.Workspace, error) {
 	ws, err := uc.wsRepo.GetOneByID(id)
 	if err != nil {
-		return nil, errs.NewNotFoundError("workspace not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "workspace not found")
 	}
 	return ws, nil
 }
@@ -95,46 +95,23 @@ func (uc *wsUseCase) GetWorkSpaceByID(id string) (*model.Workspace, error) {
 func (uc *wsUseCase) GetWorkSpaceByApiKey(apiKey string) (*model.Workspace, error) {
 	ws, err := uc.wsRepo.GetOneByApiKey(apiKey)
 	if err != nil {
-		return nil, errs.NewNotFoundError("workspace not found", err)
+		return nil, errs.AnnotateKind(err, errs.ErrNotFound, "workspace not found")
 	}
 	return ws, nil
 }
 
-func (uc *wsUseCase) GetAll(page int, limit int) (*shared.List[model.Workspace], error) {
-	workspaces, total, err := uc.wsRepo.GetAll(page, limit)
+func (uc *wsUseCase) GetAll(spec query.Spec) (*shared.List[model.Workspace], error) {
+	workspaces, total, err := uc.wsRepo.GetAll(spec)
 	if err != nil {
-		return nil, errs.NewInternalError("failed to get workspaces", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get workspaces")
 	}
-	return &shared.List[model.Workspace]{
-		Items: workspaces,
-		Total: total,
-		Page:  page,
-		Limit: limit,
-	}, nil
+	return shared.NewList(workspaces, total, spec), nil
 }
 
-func (uc *wsUseCase) GetAllByOrgID(orgID string, page int, limit int) (*shared.List[model.Workspace], error) {
-	workspaces, total, err := uc.wsRepo.GetAllByOrgID(orgID, page, limit)
+func (uc *wsUseCase) GetAllByOrgID(orgID string, spec query.Spec) (*shared.List[model.Workspace], error) {
+	workspaces, total, err := uc.wsRepo.GetAllByOrgID(orgID, spec)
 	if err != nil {
-		return nil, errs.NewInternalError("failed to get workspaces", err)
+		return nil, errs.AnnotateKind(err, errs.ErrInternal, "failed to get workspaces")
 	}
-	return &shared.List[model.Workspace]{
-		Items: workspaces,
-		Total: total,
-		Page:  page,
-		Limit: limit,
-	}, nil
-}
-
-func (uc *wsUseCase) Search(keyword string, page int, limit int) (*shared.List[model.Workspace], error) {
-	workspaces, total, err := uc.wsRepo.Search(keyword, page, limit)
-	if err != nil {
-		return nil, errs.NewInternalError("failed to search workspaces", err)
-	}
-	return &shared.List[model.Workspace]{
-		Items: workspaces,
-		Total: total,
-		Page:  page,
-		Limit: limit,
-	}, nil
+	return shared.NewList(workspaces, total, spec), nil
 }
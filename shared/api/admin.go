@@ -0,0 +1,83 @@
+package api
+
+import (
+	"github.com/abdelrahman146/zard/shared/api/openapi"
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// Permissions of the admin routes. No built-in role but owner grants them.
+const (
+	PermConfigRead    = "config.read"
+	PermLoggingManage = "logging.manage"
+)
+
+// LoggingConfigPrefix is where log levels are configured: app.logging.level and app.logging.levels.<name>.
+const LoggingConfigPrefix = "app.logging"
+
+// AdminConfig is what the admin routes expose. Routes whose dependency is nil are not mounted.
+type AdminConfig struct {
+	Loader  *config.Loader // GET /config serves its redacted dump
+	Levels  *logger.Levels // GET, PUT and DELETE /logging/levels read and change the log levels
+	Watcher config.Watcher // applies the levels under LoggingConfigPrefix to Levels when they change
+}
+
+type Admin struct{}
+
+// Mount registers the admin routes on router, e.g. app.Group("/v1/backoffice/admin"). They are
+// restricted to backoffice users holding PermConfigRead or PermLoggingManage.
+func (Admin) Mount(router fiber.Router, cache cache.Cache, admin AdminConfig) {
+	authorize := Api.Auth.AuthorizeBackofficeMiddleware(cache)
+	session := Api.Docs.SessionSecurity()
+	forbidden := openapi.Kind(errs.ErrForbidden)
+	if admin.Loader != nil {
+		openapi.Get(router, "/config", openapi.Operation{
+			Summary:     "Dump the configuration",
+			Description: "Every value with the source it came from, secrets redacted. Requires the " + PermConfigRead + " permission.",
+			Tags:        []string{"admin"},
+			Result:      map[string]config.DumpEntry{},
+			Errors:      []errs.Entry{forbidden},
+			Security:    session,
+		}, authorize, Api.Auth.RequirePermission(PermConfigRead), adaptor.HTTPHandler(admin.Loader.DumpHandler()))
+	}
+	if admin.Levels == nil {
+		return
+	}
+	levels := adaptor.HTTPHandler(admin.Levels.Handler())
+	requirePermission := Api.Auth.RequirePermission(PermLoggingManage)
+	openapi.Get(router, "/logging/levels", openapi.Operation{
+		Summary:  "List the log levels",
+		Tags:     []string{"admin"},
+		Result:   logger.LevelsSnapshot{},
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission, levels)
+	openapi.Put(router, "/logging/levels", openapi.Operation{
+		Summary:     "Set a log level",
+		Description: `Sets the level of a named logger, "" being the global one, for a while when duration is set.`,
+		Tags:        []string{"admin"},
+		Body: struct {
+			Logger   string `json:"logger"`
+			Level    string `json:"level"`
+			Duration string `json:"duration,omitempty"` // e.g. 10m
+		}{},
+		Result:   logger.LevelsSnapshot{},
+		Errors:   []errs.Entry{forbidden},
+		Security: session,
+	}, authorize, requirePermission, levels)
+	openapi.Delete(router, "/logging/levels", openapi.Operation{
+		Summary:     "Reset a log level",
+		Description: "Makes the logger of the logger query parameter follow its parent again.",
+		Tags:        []string{"admin"},
+		Result:      logger.LevelsSnapshot{},
+		Errors:      []errs.Entry{forbidden},
+		Security:    session,
+	}, authorize, requirePermission, levels)
+	if admin.Watcher != nil {
+		admin.Watcher.OnChange(LoggingConfigPrefix, admin.Levels.OnConfigChange(LoggingConfigPrefix))
+	}
+}
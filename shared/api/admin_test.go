@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/abdelrahman146/zard/shared/redact"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeWatcher struct {
+	prefix   string
+	callback func(changed map[string]interface{})
+}
+
+func (w *fakeWatcher) Start() {}
+func (w *fakeWatcher) Stop()  {}
+func (w *fakeWatcher) OnChange(keyPrefix string, callback func(changed map[string]interface{})) {
+	w.prefix, w.callback = keyPrefix, callback
+}
+
+func TestAdmin_Mount(t *testing.T) {
+	cache := newTestCache(map[string]string{
+		"account.auth.backoffice.tokens.ztkn_owner": `{"id":1,"role":"owner"}`,
+		"account.auth.backoffice.tokens.ztkn_admin": `{"id":2,"role":"admin"}`,
+		"account.auth.user.tokens.ztkn_user":        `{"id":"usr_1","orgId":"org_1","role":"owner"}`,
+	})
+	loader := config.NewLoader().Defaults(map[string]interface{}{"app": map[string]interface{}{"secret": "hunter2"}})
+	loader.Load(config.NewViperConfig())
+	levels := logger.NewLevels(zapcore.InfoLevel)
+	watcher := &fakeWatcher{}
+	app := fiber.New(fiber.Config{ErrorHandler: Api.Response.ErrorHandler(ErrorHandlerConfig{})})
+	Api.Admin.Mount(app.Group("/v1/backoffice/admin"), cache, AdminConfig{Loader: loader, Levels: levels, Watcher: watcher})
+	call := func(method, path, token, body string) (int, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(out)
+	}
+
+	status, body := call("GET", "/v1/backoffice/admin/config", "ztkn_owner", "")
+	assert.Equal(t, 200, status)
+	dump := map[string]config.DumpEntry{}
+	require.NoError(t, json.Unmarshal([]byte(body), &dump))
+	assert.Equal(t, config.DumpEntry{Value: redact.Mask, Source: "default"}, dump["app.secret"])
+
+	status, _ = call("GET", "/v1/backoffice/admin/config", "ztkn_admin", "")
+	assert.Equal(t, 403, status, "only roles granting "+PermConfigRead+" read the configuration")
+	status, _ = call("GET", "/v1/backoffice/admin/config", "ztkn_user", "")
+	assert.Equal(t, 401, status, "user sessions are not backoffice sessions")
+	status, _ = call("GET", "/v1/backoffice/admin/config", "", "")
+	assert.Equal(t, 401, status)
+
+	status, _ = call("PUT", "/v1/backoffice/admin/logging/levels", "ztkn_owner", `{"logger":"cache","level":"debug"}`)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, zapcore.DebugLevel, levels.Level("cache"))
+	status, _ = call("PUT", "/v1/backoffice/admin/logging/levels", "ztkn_admin", `{"logger":"cache","level":"error"}`)
+	assert.Equal(t, 403, status)
+
+	require.Equal(t, LoggingConfigPrefix, watcher.prefix)
+	watcher.callback(map[string]interface{}{"app.logging.levels.cache": "warn"})
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("cache"), "levels follow the configuration at runtime")
+}
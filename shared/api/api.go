@@ -1,11 +1,19 @@
 package api
 
 type Struct struct {
-	Response Response
-	Auth     Auth
+	Response  Response
+	Auth      Auth
+	Logging   Logging
+	RateLimit RateLimit
+	Docs      Docs
+	Admin     Admin
 }
 
 var Api = Struct{
-	Response: Response{},
-	Auth:     Auth{},
+	Response:  Response{},
+	Auth:      Auth{},
+	Logging:   Logging{},
+	RateLimit: RateLimit{},
+	Docs:      Docs{},
+	Admin:     Admin{},
 }
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/abdelrahman146/zard/shared/auth"
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/rpc"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+	"github.com/gofiber/fiber/v2"
+	"slices"
+	"strings"
+)
+
+const (
+	SchemeCookie = "cookie"
+	SchemeBearer = "bearer"
+	SchemeApiKey = "api-key"
+
+	ApiKeyHeader = "X-Api-Key"
+)
+
+// CredentialExtractor returns the credential a request presents, or "" when it has none.
+type CredentialExtractor func(ctx *fiber.Ctx) string
+
+// Authenticator checks a credential and returns its principal.
+type Authenticator func(ctx context.Context, credential string) (*auth.Principal, error)
+
+// Scheme is a way of authenticating, e.g. a session token in a cookie.
+type Scheme struct {
+	Name         string
+	Extract      CredentialExtractor
+	Authenticate Authenticator
+}
+
+// ApiKeyResolver returns the ID of the workspace an API key belongs to.
+// A func calling AuthUseCase.AuthenticateWorkspaceByApiKey satisfies it through ApiKeyResolverFunc.
+type ApiKeyResolver interface {
+	ResolveApiKey(ctx context.Context, apiKey string) (workspaceID string, err error)
+}
+
+type ApiKeyResolverFunc func(ctx context.Context, apiKey string) (workspaceID string, err error)
+
+func (f ApiKeyResolverFunc) ResolveApiKey(ctx context.Context, apiKey string) (string, error) {
+	return f(ctx, apiKey)
+}
+
+// NewRpcApiKeyResolver resolves API keys through the account service.
+func NewRpcApiKeyResolver(r rpc.RPC) ApiKeyResolver {
+	return ApiKeyResolverFunc(func(ctx context.Context, apiKey string) (string, error) {
+		resp, err := rpc.Call[requests.ResolveApiKeyResponse](ctx, r, &requests.ResolveApiKeyRequest{ApiKey: apiKey})
+		if err != nil {
+			return "", err
+		}
+		return resp.WorkspaceID, nil
+	})
+}
+
+func (Auth) CookieExtractor(name string) CredentialExtractor {
+	return func(ctx *fiber.Ctx) string {
+		return ctx.Cookies(name)
+	}
+}
+
+func (Auth) BearerExtractor() CredentialExtractor {
+	return func(ctx *fiber.Ctx) string {
+		scheme, token, ok := strings.Cut(ctx.Get(fiber.HeaderAuthorization), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(token)
+	}
+}
+
+func (Auth) HeaderExtractor(header string) CredentialExtractor {
+	return func(ctx *fiber.Ctx) string {
+		return ctx.Get(header)
+	}
+}
+
+// session holds the fields of a cached session a principal is made of. Sessions hold more,
+// e.g. a user's profile, which the service owning them decodes when it needs it.
+type session struct {
+	ID          sessionID `json:"id"`
+	OrgID       string    `json:"orgId"`
+	WorkspaceID string    `json:"workspaceId"`
+	Role        string    `json:"role"`
+	Roles       []string  `json:"roles"`
+	Scopes      []string  `json:"scopes"`
+}
+
+// sessionID also accepts numbers, which backoffice users are identified by.
+type sessionID string
+
+func (id *sessionID) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*id = sessionID(number)
+		return nil
+	}
+	return json.Unmarshal(data, (*string)(id))
+}
+
+// SessionAuthenticator looks session tokens of tokenOwner up in the cache.
+func (Auth) SessionAuthenticator(cache cache.Cache, tokenOwner auth.PrincipalType) Authenticator {
+	return func(ctx context.Context, token string) (*auth.Principal, error) {
+		resp, err := cache.Get([]string{"account", "auth", string(tokenOwner), "tokens", token})
+		if err != nil {
+			return nil, errs.NewUnauthorizedError("invalid or expired token", err)
+		}
+		var s session
+		if err = json.Unmarshal(resp, &s); err != nil {
+			return nil, errs.NewInternalError("unable to parse "+string(tokenOwner)+" session", err)
+		}
+		principal := &auth.Principal{Type: tokenOwner, ID: string(s.ID), OrgID: s.OrgID, WorkspaceID: s.WorkspaceID, Roles: s.Roles, Scopes: s.Scopes}
+		if s.Role != "" {
+			principal.Roles = append(principal.Roles, s.Role)
+		}
+		if tokenOwner == auth.PrincipalWorkspace {
+			principal.WorkspaceID = principal.ID
+		}
+		return principal, nil
+	}
+}
+
+// ApiKeyAuthenticator authenticates workspaces by API key, granting them auth.ApiKeyScopes.
+func (Auth) ApiKeyAuthenticator(resolver ApiKeyResolver) Authenticator {
+	return func(ctx context.Context, apiKey string) (*auth.Principal, error) {
+		workspaceID, err := resolver.ResolveApiKey(ctx, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		return &auth.Principal{Type: auth.PrincipalWorkspace, ID: workspaceID, WorkspaceID: workspaceID, Scopes: slices.Clone(auth.ApiKeyScopes)}, nil
+	}
+}
+
+// CookieScheme authenticates the session token of tokenOwner in the token cookie.
+func (a Auth) CookieScheme(cache cache.Cache, tokenOwner auth.PrincipalType) Scheme {
+	return Scheme{Name: SchemeCookie, Extract: a.CookieExtractor("token"), Authenticate: a.SessionAuthenticator(cache, tokenOwner)}
+}
+
+// BearerScheme authenticates the session token of tokenOwner in the Authorization header.
+func (a Auth) BearerScheme(cache cache.Cache, tokenOwner auth.PrincipalType) Scheme {
+	return Scheme{Name: SchemeBearer, Extract: a.BearerExtractor(), Authenticate: a.SessionAuthenticator(cache, tokenOwner)}
+}
+
+// ApiKeyScheme authenticates workspaces by the API key in the X-Api-Key header.
+func (a Auth) ApiKeyScheme(resolver ApiKeyResolver) Scheme {
+	return Scheme{Name: SchemeApiKey, Extract: a.HeaderExtractor(ApiKeyHeader), Authenticate: a.ApiKeyAuthenticator(resolver)}
+}
+
+// AuthenticateMiddleware authenticates requests with the first of schemes they present a credential
+// for, and stores the principal in the user context. A credential that fails is not retried with
+// the next scheme. Handlers read the principal with auth.FromContext.
+func (Auth) AuthenticateMiddleware(schemes ...Scheme) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if _, err := authenticate(ctx, schemes); err != nil {
+			return err
+		}
+		return ctx.Next()
+	}
+}
+
+// AuthorizeServiceMiddleware authenticates workspaces by API key on the routes of
+// /v1/service/:workspaceId, and rejects the keys of workspaces other than the one in the path.
+func (a Auth) AuthorizeServiceMiddleware(resolver ApiKeyResolver) fiber.Handler {
+	schemes := []Scheme{a.ApiKeyScheme(resolver)}
+	return func(ctx *fiber.Ctx) error {
+		principal, err := authenticate(ctx, schemes)
+		if err != nil {
+			return err
+		}
+		if principal.WorkspaceID != ctx.Params("workspaceId") {
+			return errs.NewForbiddenError("api key does not belong to the workspace", nil)
+		}
+		return ctx.Next()
+	}
+}
+
+func authenticate(ctx *fiber.Ctx, schemes []Scheme) (*auth.Principal, error) {
+	for _, scheme := range schemes {
+		credential := scheme.Extract(ctx)
+		if credential == "" {
+			continue
+		}
+		principal, err := scheme.Authenticate(ctx.UserContext(), credential)
+		if err != nil {
+			return nil, err
+		}
+		principal.Scheme, principal.Credential = scheme.Name, credential
+		ctx.SetUserContext(auth.IntoContext(ctx.UserContext(), principal))
+		return principal, nil
+	}
+	return nil, errs.NewUnauthorizedError("credentials are not provided", nil)
+}
+
+// RequirePermission rejects requests whose principal does not hold permission, see auth.Check.
+// The resource is taken from the orgId and workspaceId route parameters, when the route has them.
+func (Auth) RequirePermission(permission string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		resource := auth.Resource{OrgID: ctx.Params("orgId"), WorkspaceID: ctx.Params("workspaceId")}
+		if err := auth.Check(ctx.UserContext(), permission, resource); err != nil {
+			return err
+		}
+		return ctx.Next()
+	}
+}
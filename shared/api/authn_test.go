@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/shared/auth"
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestCache returns a memory cache holding values by dotted key.
+func newTestCache(values map[string]string) cache.Cache {
+	c := cache.NewMemoryCache()
+	for key, value := range values {
+		_ = c.Set(strings.Split(key, "."), []byte(value), 0)
+	}
+	return c
+}
+
+func authApp(schemes ...Scheme) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: Api.Response.ErrorHandler(ErrorHandlerConfig{})})
+	app.Get("/whoami", Api.Auth.AuthenticateMiddleware(schemes...), func(ctx *fiber.Ctx) error {
+		principal, err := auth.Current(ctx.UserContext())
+		if err != nil {
+			return err
+		}
+		ids := logger.CorrelationIDs(ctx.UserContext())
+		return ctx.SendString(string(principal.Type) + ":" + principal.ID + ":" + principal.Scheme + ":" + ids[logger.UserIDKey] + ids[logger.WorkspaceIDKey] + ":" + principal.Credential)
+	})
+	return app
+}
+
+func whoami(t *testing.T, app *fiber.App, headers map[string]string) (int, string) {
+	req := httptest.NewRequest("GET", "/whoami", nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestAuthenticateMiddleware(t *testing.T) {
+	cache := newTestCache(map[string]string{"account.auth.user.tokens.ztkn_1": `{"id":"usr_1","email":"jane@zard.io"}`})
+	resolver := ApiKeyResolverFunc(func(ctx context.Context, apiKey string) (string, error) {
+		if apiKey == "zky_valid" {
+			return "wrk_1", nil
+		}
+		return "", errs.NewUnauthorizedError("invalid api key", nil)
+	})
+	app := authApp(Api.Auth.CookieScheme(cache, "user"), Api.Auth.BearerScheme(cache, "user"), Api.Auth.ApiKeyScheme(resolver))
+
+	status, body := whoami(t, app, map[string]string{"Cookie": "token=ztkn_1"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "user:usr_1:cookie:usr_1:ztkn_1", body)
+
+	status, body = whoami(t, app, map[string]string{"Authorization": "Bearer ztkn_1"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "user:usr_1:bearer:usr_1:ztkn_1", body)
+
+	status, body = whoami(t, app, map[string]string{ApiKeyHeader: "zky_valid"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "workspace:wrk_1:api-key:wrk_1:zky_valid", body)
+
+	status, _ = whoami(t, app, map[string]string{"Authorization": "Bearer ztkn_expired", ApiKeyHeader: "zky_valid"})
+	assert.Equal(t, 401, status, "a failed credential is not retried with the next scheme")
+
+	status, _ = whoami(t, app, map[string]string{ApiKeyHeader: "zky_invalid"})
+	assert.Equal(t, 401, status)
+
+	status, _ = whoami(t, app, map[string]string{"Authorization": "Basic dXNlcjpwYXNz"})
+	assert.Equal(t, 401, status)
+
+	status, _ = whoami(t, app, nil)
+	assert.Equal(t, 401, status)
+}
+
+func TestAuthorizeServiceMiddleware(t *testing.T) {
+	resolver := ApiKeyResolverFunc(func(ctx context.Context, apiKey string) (string, error) {
+		if apiKey == "zky_valid" {
+			return "wrk_1", nil
+		}
+		return "", errs.NewUnauthorizedError("invalid api key", nil)
+	})
+	app := fiber.New(fiber.Config{ErrorHandler: Api.Response.ErrorHandler(ErrorHandlerConfig{})})
+	service := app.Group("/v1/service/:workspaceId")
+	service.Get("/", Api.Auth.AuthorizeServiceMiddleware(resolver), Api.Auth.RequirePermission("workspace.read"), func(ctx *fiber.Ctx) error {
+		return ctx.SendString(ctx.Params("workspaceId"))
+	})
+	service.Delete("/", Api.Auth.AuthorizeServiceMiddleware(resolver), Api.Auth.RequirePermission("workspace.delete"), func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+	call := func(method, path, apiKey string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(ApiKeyHeader, apiKey)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 200, call("GET", "/v1/service/wrk_1", "zky_valid"), "the scopes of API keys satisfy the policy")
+	assert.Equal(t, 403, call("GET", "/v1/service/wrk_2", "zky_valid"), "keys only open their own workspace")
+	assert.Equal(t, 403, call("DELETE", "/v1/service/wrk_1", "zky_valid"), "keys grant no more than auth.ApiKeyScopes")
+	assert.Equal(t, 401, call("GET", "/v1/service/wrk_1", "zky_invalid"))
+	assert.Equal(t, 401, call("GET", "/v1/service/wrk_1", ""))
+}
+
+func TestAuthorizeUserMiddleware_AcceptsBearerTokens(t *testing.T) {
+	cache := newTestCache(map[string]string{"account.auth.user.tokens.ztkn_1": `{"id":"usr_1"}`})
+	app := fiber.New()
+	app.Get("/", Api.Auth.AuthorizeUserMiddleware(cache), func(ctx *fiber.Ctx) error {
+		principal, err := auth.Current(ctx.UserContext())
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(principal.ID)
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "bearer ztkn_1")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestSessionAuthenticator_DecodesPrincipal(t *testing.T) {
+	cache := newTestCache(map[string]string{
+		"account.auth.user.tokens.ztkn_1":       `{"id":"usr_1","orgId":"org_1","email":"jane@zard.io"}`,
+		"account.auth.workspace.tokens.ztkn_2":  `{"id":"wrk_1","orgId":"org_1","name":"Shop"}`,
+		"account.auth.backoffice.tokens.ztkn_3": `{"id":7,"role":"admin"}`,
+		"account.auth.user.tokens.ztkn_4":       `{"id":"usr_2","roles":["owner"],"scopes":["billing:read"]}`,
+	})
+	principal, err := Api.Auth.SessionAuthenticator(cache, auth.PrincipalUser)(context.Background(), "ztkn_1")
+	require.NoError(t, err)
+	assert.Equal(t, &auth.Principal{Type: auth.PrincipalUser, ID: "usr_1", OrgID: "org_1"}, principal)
+
+	principal, err = Api.Auth.SessionAuthenticator(cache, auth.PrincipalWorkspace)(context.Background(), "ztkn_2")
+	require.NoError(t, err)
+	assert.Equal(t, &auth.Principal{Type: auth.PrincipalWorkspace, ID: "wrk_1", OrgID: "org_1", WorkspaceID: "wrk_1"}, principal)
+
+	principal, err = Api.Auth.SessionAuthenticator(cache, auth.PrincipalBackoffice)(context.Background(), "ztkn_3")
+	require.NoError(t, err)
+	assert.Equal(t, &auth.Principal{Type: auth.PrincipalBackoffice, ID: "7", Roles: []string{"admin"}}, principal)
+
+	principal, err = Api.Auth.SessionAuthenticator(cache, auth.PrincipalUser)(context.Background(), "ztkn_4")
+	require.NoError(t, err)
+	assert.True(t, principal.HasRole("owner"))
+	assert.True(t, principal.HasScope("billing:read"))
+	assert.False(t, principal.HasScope("billing:write"))
+
+	_, err = Api.Auth.SessionAuthenticator(cache, auth.PrincipalUser)(context.Background(), "ztkn_404")
+	assert.ErrorIs(t, err, errs.ErrUnauthorized)
+}
+
+func TestRequirePermission(t *testing.T) {
+	cache := newTestCache(map[string]string{
+		"account.auth.user.tokens.ztkn_admin":  `{"id":"usr_1","orgId":"org_1","role":"admin"}`,
+		"account.auth.user.tokens.ztkn_viewer": `{"id":"usr_2","orgId":"org_1","role":"viewer"}`,
+	})
+	app := fiber.New(fiber.Config{ErrorHandler: Api.Response.ErrorHandler(ErrorHandlerConfig{})})
+	app.Post("/orgs/:orgId/apikey/reset",
+		Api.Auth.AuthenticateMiddleware(Api.Auth.BearerScheme(cache, auth.PrincipalUser)),
+		Api.Auth.RequirePermission("workspace.apikey.reset"),
+		func(ctx *fiber.Ctx) error {
+			return ctx.SendStatus(fiber.StatusNoContent)
+		})
+	reset := func(token, orgID string) int {
+		req := httptest.NewRequest("POST", "/orgs/"+orgID+"/apikey/reset", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, 204, reset("ztkn_admin", "org_1"))
+	assert.Equal(t, 403, reset("ztkn_admin", "org_2"))
+	assert.Equal(t, 403, reset("ztkn_viewer", "org_1"))
+	assert.Equal(t, 401, reset("ztkn_404", "org_1"))
+}
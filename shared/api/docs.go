@@ -0,0 +1,49 @@
+package api
+
+import (
+	"github.com/abdelrahman146/zard/shared/api/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Docs struct{}
+
+// Config describes how the routes of this package answer and authenticate in OpenAPI documents:
+// results in the success envelope, errors as problem+json, and the cookie, bearer and API key schemes.
+func (Docs) Config(info openapi.Info) openapi.Config {
+	return openapi.Config{
+		Info: info,
+		SecuritySchemes: map[string]openapi.SecurityScheme{
+			SchemeCookie: {Type: "apiKey", In: "cookie", Name: "token", Description: "Session token set by the login routes."},
+			SchemeBearer: {Type: "http", Scheme: "bearer", Description: "Session token."},
+			SchemeApiKey: {Type: "apiKey", In: "header", Name: ApiKeyHeader, Description: "API key of a workspace."},
+		},
+		Envelope: func(result *openapi.Schema) *openapi.Schema {
+			envelope := &openapi.Schema{
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"success": {Type: "boolean"}},
+				Required:   []string{"success"},
+			}
+			if result != nil {
+				envelope.Properties["result"] = result
+			}
+			return envelope
+		},
+		Problem:            Problem{},
+		ProblemContentType: ProblemContentType,
+	}
+}
+
+// SessionSecurity are the schemes session tokens are accepted with, see AuthorizeUserMiddleware.
+func (Docs) SessionSecurity() []string {
+	return []string{SchemeCookie, SchemeBearer}
+}
+
+// ApiKeySecurity is the scheme API keys are accepted with, see AuthorizeServiceMiddleware.
+func (Docs) ApiKeySecurity() []string {
+	return []string{SchemeApiKey}
+}
+
+// Serve serves the document of the routes registered with openapi at /openapi.json, and a viewer at /docs.
+func (d Docs) Serve(router fiber.Router, info openapi.Info) {
+	openapi.Serve(router, openapi.DefaultRegistry, d.Config(info))
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"github.com/abdelrahman146/zard/shared/api/middleware"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+const RequestIDHeader = middleware.RequestIDHeader
+
+type Logging struct{}
+
+// RequestLoggerMiddleware assigns every request an ID and logs it once it completes, like the
+// RequestID and AccessLog middlewares of the middleware package put together.
+func (Logging) RequestLoggerMiddleware() func(ctx *fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		middleware.SetRequestID(ctx)
+		start := time.Now()
+		err := ctx.Next()
+		middleware.LogRequest(ctx, start, err)
+		return err
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type recordingLogger struct {
+	mu      *sync.Mutex
+	fields  []logger.F
+	entries *[]map[string]interface{}
+}
+
+func (l *recordingLogger) log(msg string, fields []logger.F) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := map[string]interface{}{"msg": msg}
+	for _, f := range append(append([]logger.F{}, l.fields...), fields...) {
+		e[f.Key] = f.Value
+	}
+	*l.entries = append(*l.entries, e)
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...logger.F) { l.log(msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...logger.F)  { l.log(msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields ...logger.F)  { l.log(msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...logger.F) { l.log(msg, fields) }
+func (l *recordingLogger) Panic(msg string, fields ...logger.F) { l.log(msg, fields) }
+func (l *recordingLogger) With(fields ...logger.F) logger.Logger {
+	return &recordingLogger{mu: l.mu, fields: append(append([]logger.F{}, l.fields...), fields...), entries: l.entries}
+}
+
+func (l *recordingLogger) Named(name string) logger.Logger {
+	return l.With(logger.Field("logger", name))
+}
+
+func TestRequestLoggerMiddleware(t *testing.T) {
+	var entries []map[string]interface{}
+	rec := &recordingLogger{mu: &sync.Mutex{}, entries: &entries}
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(logger.IntoContext(ctx.UserContext(), rec))
+		return ctx.Next()
+	})
+	app.Use(Api.Logging.RequestLoggerMiddleware())
+	app.Get("/ok", func(ctx *fiber.Ctx) error {
+		logger.FromContext(ctx.UserContext()).Info("handling")
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+	app.Get("/missing", func(ctx *fiber.Ctx) error {
+		return errs.NewNotFoundError("user not found", nil)
+	})
+
+	req := httptest.NewRequest("GET", "/ok", nil)
+	req.Header.Set(RequestIDHeader, "req_1")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "req_1", resp.Header.Get(RequestIDHeader))
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "handling", entries[0]["msg"])
+	assert.Equal(t, "req_1", entries[0][logger.RequestIDKey])
+	assert.Equal(t, "request completed", entries[1]["msg"])
+	assert.Equal(t, 204, entries[1]["status"])
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/missing", nil))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Header.Get(RequestIDHeader))
+	assert.Equal(t, "request rejected", entries[2]["msg"])
+	assert.Equal(t, 404, entries[2]["status"])
+	assert.Equal(t, resp.Header.Get(RequestIDHeader), entries[2][logger.RequestIDKey])
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/auth"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/errs/report"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/abdelrahman146/zard/shared/utils"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs taken from callers, as they end up in every log line.
+const maxRequestIDLength = 128
+
+// Bundle returns the middlewares every server starts with, in the order they have to run.
+func Bundle() []fiber.Handler {
+	return []fiber.Handler{RequestID(), AccessLog(), Recover()}
+}
+
+// RequestID assigns every request an ID, see SetRequestID.
+func RequestID() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		SetRequestID(ctx)
+		return ctx.Next()
+	}
+}
+
+// SetRequestID takes the ID of the request from the X-Request-ID header, or generates one when the
+// caller sent none or an invalid one. The ID is echoed back and stored in the user context, so that
+// logger.FromContext, RPC calls and published messages carry it.
+func SetRequestID(ctx *fiber.Ctx) string {
+	requestID := ctx.Get(RequestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = utils.Utils.Strings.Cuid()
+	}
+	ctx.Set(RequestIDHeader, requestID)
+	ctx.SetUserContext(logger.WithCorrelationID(ctx.UserContext(), logger.RequestIDKey, requestID))
+	return requestID
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == ':') {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLog logs every request once it completes, see LogRequest.
+func AccessLog() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		err := ctx.Next()
+		LogRequest(ctx, start, err)
+		return err
+	}
+}
+
+// LogRequest logs the method, route, status and latency of a request along with its principal.
+// Server errors are reported, and logged with the ID of their event.
+func LogRequest(ctx *fiber.Ctx, start time.Time, err error) {
+	status := Status(ctx, err)
+	fields := []logger.F{
+		logger.Field("method", ctx.Method()),
+		logger.Field("route", ctx.Route().Path),
+		logger.Field("path", ctx.Path()),
+		logger.Field("status", status),
+		logger.Field("latency", time.Since(start)),
+	}
+	// the user context is read again since auth middlewares add the principal to it
+	if p, ok := auth.FromContext(ctx.UserContext()); ok {
+		fields = append(fields, logger.Field("principal", string(p.Type)+":"+p.ID), logger.Field("scheme", p.Scheme))
+	}
+	log := logger.FromContext(ctx.UserContext())
+	switch {
+	case status >= 500 && err != nil:
+		eventID := report.Report(ctx.UserContext(), err)
+		log.Error("request failed", append(fields, logger.Field("error", err), logger.Field("eventId", eventID))...)
+	case status >= 500:
+		log.Error("request failed", fields...)
+	case err != nil:
+		log.Warn("request rejected", append(fields, logger.Field("error", err))...)
+	default:
+		log.Info("request completed", fields...)
+	}
+}
+
+// Status returns the status the error handler answers err with, or the status of the response.
+func Status(ctx *fiber.Ctx, err error) int {
+	if err == nil {
+		return ctx.Response().StatusCode()
+	}
+	if fiberErr, ok := err.(*fiber.Error); ok {
+		return fiberErr.Code
+	}
+	return errs.HandleError(err).HttpCode
+}
+
+// Recover turns panics into internal errors, which carry the stack of the panic.
+func Recover() fiber.Handler {
+	return func(ctx *fiber.Ctx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				cause, ok := r.(error)
+				if !ok {
+					cause = fmt.Errorf("%v", r)
+				}
+				err = errs.NewInternalError("panic recovered", cause)
+			}
+		}()
+		return ctx.Next()
+	}
+}
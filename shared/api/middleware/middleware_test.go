@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"github.com/abdelrahman146/zard/shared/auth"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingLogger struct {
+	mu      *sync.Mutex
+	fields  []logger.F
+	entries *[]map[string]interface{}
+}
+
+func (l *recordingLogger) log(msg string, fields []logger.F) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := map[string]interface{}{"msg": msg}
+	for _, f := range append(append([]logger.F{}, l.fields...), fields...) {
+		e[f.Key] = f.Value
+	}
+	*l.entries = append(*l.entries, e)
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...logger.F) { l.log(msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...logger.F)  { l.log(msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields ...logger.F)  { l.log(msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...logger.F) { l.log(msg, fields) }
+func (l *recordingLogger) Panic(msg string, fields ...logger.F) { l.log(msg, fields) }
+func (l *recordingLogger) With(fields ...logger.F) logger.Logger {
+	return &recordingLogger{mu: l.mu, fields: append(append([]logger.F{}, l.fields...), fields...), entries: l.entries}
+}
+
+func (l *recordingLogger) Named(name string) logger.Logger {
+	return l.With(logger.Field("logger", name))
+}
+
+func newTestApp(entries *[]map[string]interface{}) *fiber.App {
+	rec := &recordingLogger{mu: &sync.Mutex{}, entries: entries}
+	app := fiber.New(fiber.Config{ErrorHandler: func(ctx *fiber.Ctx, err error) error {
+		return ctx.SendStatus(Status(ctx, err))
+	}})
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(logger.IntoContext(ctx.UserContext(), rec))
+		return ctx.Next()
+	})
+	for _, handler := range Bundle() {
+		app.Use(handler)
+	}
+	return app
+}
+
+func TestRequestID(t *testing.T) {
+	var entries []map[string]interface{}
+	app := newTestApp(&entries)
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(logger.CorrelationIDs(ctx.UserContext())[logger.RequestIDKey])
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "req_1")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, "req_1", resp.Header.Get(RequestIDHeader))
+
+	for _, invalid := range []string{"", "req 1\nlevel=error", strings.Repeat("a", 129)} {
+		req = httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, invalid)
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.Header.Get(RequestIDHeader))
+		assert.NotEqual(t, invalid, resp.Header.Get(RequestIDHeader))
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var entries []map[string]interface{}
+	app := newTestApp(&entries)
+	app.Get("/users/:id", func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(auth.IntoContext(ctx.UserContext(), &auth.Principal{Type: auth.PrincipalUser, ID: "usr_1", Scheme: "bearer"}))
+		if ctx.Params("id") == "usr_404" {
+			return errs.NewNotFoundError("user not found", nil)
+		}
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/users/usr_1", nil))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "request completed", entries[0]["msg"])
+	assert.Equal(t, "GET", entries[0]["method"])
+	assert.Equal(t, "/users/:id", entries[0]["route"])
+	assert.Equal(t, "/users/usr_1", entries[0]["path"])
+	assert.Equal(t, 204, entries[0]["status"])
+	assert.Equal(t, "user:usr_1", entries[0]["principal"])
+	assert.Equal(t, "bearer", entries[0]["scheme"])
+	assert.NotEmpty(t, entries[0][logger.RequestIDKey])
+	assert.Equal(t, "usr_1", entries[0][logger.UserIDKey])
+
+	_, err = app.Test(httptest.NewRequest("GET", "/users/usr_404", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "request rejected", entries[1]["msg"])
+	assert.Equal(t, 404, entries[1]["status"])
+}
+
+func TestRecover(t *testing.T) {
+	var entries []map[string]interface{}
+	app := newTestApp(&entries)
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		var m map[string]int
+		m["boom"]++
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+	require.Len(t, entries, 2, "the error is reported, then the request logged")
+	assert.Equal(t, "request failed", entries[1]["msg"])
+	assert.Equal(t, 500, entries[1]["status"])
+	assert.Equal(t, entries[0]["eventId"], entries[1]["eventId"])
+	logged := entries[1]["error"].(error)
+	assert.ErrorIs(t, logged, errs.ErrInternal)
+	assert.Contains(t, logged.Error(), "assignment to entry in nil map")
+	assert.NotEmpty(t, errs.HandleError(logged).StackTrace(), "the stack of the panic is kept")
+}
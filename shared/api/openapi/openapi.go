@@ -0,0 +1,367 @@
+package openapi
+
+import (
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const Version = "3.0.3"
+
+// Operation documents a route. Body, Query and Result are examples of the structs the handler
+// parses and answers with, e.g. LoginWithEmailAndPasswordRequest{}, and are described from their
+// json, query and validate tags.
+type Operation struct {
+	ID          string // operationId, defaults to the method followed by the path
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	Body        interface{}
+	Query       interface{}
+	Result      interface{}
+	Status      int // status of success responses, defaults to 200
+	// Errors are the codes the route answers with besides the ones every route may answer with,
+	// e.g. usecase.ErrAccountLocked. Kinds are looked up with Kind.
+	Errors []errs.Entry
+	// Security names the schemes the route accepts, any of which authenticates a request.
+	Security []string
+}
+
+// Route is an operation registered for a method and a path.
+type Route struct {
+	Method    string
+	Path      string // in fiber syntax, e.g. /v1/users/:id
+	Operation Operation
+}
+
+// Registry collects the operations of the routes they document.
+type Registry interface {
+	Add(method, path string, op Operation)
+	Routes() []Route
+	// Document describes the registered routes.
+	Document(config Config) *Document
+}
+
+// DefaultRegistry holds the operations registered with Get, Post, Put, Patch and Delete.
+var DefaultRegistry = NewRegistry()
+
+type registry struct {
+	mu     sync.RWMutex
+	routes []Route
+}
+
+func NewRegistry() Registry {
+	return &registry{}
+}
+
+func (r *registry) Add(method, path string, op Operation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, Route{Method: method, Path: path, Operation: op})
+}
+
+func (r *registry) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Route(nil), r.routes...)
+}
+
+// Register registers handlers on router and documents the route in registry.
+func Register(registry Registry, router fiber.Router, method, path string, op Operation, handlers ...fiber.Handler) fiber.Router {
+	registry.Add(method, joinPath(prefix(router), path), op)
+	return router.Add(method, path, handlers...)
+}
+
+// Get registers a documented GET route, e.g. openapi.Get(v1group, "/user", op, handlers...).
+func Get(router fiber.Router, path string, op Operation, handlers ...fiber.Handler) fiber.Router {
+	return Register(DefaultRegistry, router, fiber.MethodGet, path, op, handlers...)
+}
+
+func Post(router fiber.Router, path string, op Operation, handlers ...fiber.Handler) fiber.Router {
+	return Register(DefaultRegistry, router, fiber.MethodPost, path, op, handlers...)
+}
+
+func Put(router fiber.Router, path string, op Operation, handlers ...fiber.Handler) fiber.Router {
+	return Register(DefaultRegistry, router, fiber.MethodPut, path, op, handlers...)
+}
+
+func Patch(router fiber.Router, path string, op Operation, handlers ...fiber.Handler) fiber.Router {
+	return Register(DefaultRegistry, router, fiber.MethodPatch, path, op, handlers...)
+}
+
+func Delete(router fiber.Router, path string, op Operation, handlers ...fiber.Handler) fiber.Router {
+	return Register(DefaultRegistry, router, fiber.MethodDelete, path, op, handlers...)
+}
+
+// Kind returns the catalog entry of a generic error kind, e.g. errs.ErrNotFound.
+func Kind(kind errs.Kind) errs.Entry {
+	if entry, ok := errs.DefaultCatalog.Lookup(string(kind)); ok {
+		return entry
+	}
+	return errs.Entry{Code: string(kind), Kind: kind, HttpCode: kind.HttpCode(), Message: kind.Desc()}
+}
+
+func prefix(router fiber.Router) string {
+	if group, ok := router.(*fiber.Group); ok {
+		return group.Prefix
+	}
+	return ""
+}
+
+func joinPath(prefix, path string) string {
+	joined := strings.TrimRight(prefix, "/") + "/" + strings.TrimLeft(path, "/")
+	if len(joined) > 1 {
+		joined = strings.TrimRight(joined, "/")
+	}
+	return joined
+}
+
+// Config describes what the routes have in common: how they answer and how they authenticate.
+type Config struct {
+	Info            Info
+	Servers         []Server
+	SecuritySchemes map[string]SecurityScheme
+	// Envelope wraps the schema of results into the body of success responses, results are
+	// answered as they are when it is nil.
+	Envelope func(result *Schema) *Schema
+	// Problem is an example of the body of error responses, answered as ProblemContentType.
+	Problem            interface{}
+	ProblemContentType string
+	// Catalog holds the messages of error codes, defaults to errs.DefaultCatalog.
+	Catalog errs.Catalog
+	// Types describes types that are not described by their fields, e.g. ones marshalled by a
+	// MarshalJSON method. time.Time is described as a date-time string.
+	Types map[interface{}]*Schema
+}
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem holds the operations of a path by lower case method.
+type PathItem map[string]*OperationObject
+
+type OperationObject struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"` // apiKey or http
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"` // of the header or cookie holding API keys
+	In           string `json:"in,omitempty"`   // header, query or cookie
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement maps scheme names to the scopes they require.
+type SecurityRequirement map[string][]string
+
+var pathParam = regexp.MustCompile(`:(\w+)\??`)
+
+func (r *registry) Document(config Config) *Document {
+	if config.Catalog == nil {
+		config.Catalog = errs.DefaultCatalog
+	}
+	s := newSchemas(config.Types)
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    config.Info,
+		Servers: config.Servers,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas:         s.defs,
+			SecuritySchemes: config.SecuritySchemes,
+		},
+	}
+	var problem *Schema
+	if config.Problem != nil {
+		problem = s.of(reflectType(config.Problem))
+	}
+	tags := make(map[string]bool)
+	for _, route := range r.Routes() {
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation(s, config, problem, route)
+		for _, tag := range route.Operation.Tags {
+			tags[tag] = true
+		}
+	}
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	return doc
+}
+
+func operation(s *schemas, config Config, problem *Schema, route Route) *OperationObject {
+	op := route.Operation
+	o := &OperationObject{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Deprecated:  op.Deprecated,
+		Responses:   make(map[string]Response),
+	}
+	if o.OperationID == "" {
+		o.OperationID = operationID(route.Method, route.Path)
+	}
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		o.Parameters = append(o.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	if op.Query != nil {
+		o.Parameters = append(o.Parameters, s.parameters(reflectType(op.Query), "query")...)
+	}
+	errors := append([]errs.Entry(nil), op.Errors...)
+	if op.Body != nil {
+		o.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: s.of(reflectType(op.Body))}},
+		}
+		errors = append(errors, Kind(errs.ErrBadRequest), Kind(errs.ErrValidation))
+	}
+	if len(op.Security) > 0 {
+		for _, scheme := range op.Security {
+			o.Security = append(o.Security, SecurityRequirement{scheme: {}})
+		}
+		errors = append(errors, Kind(errs.ErrUnauthorized))
+	}
+	errors = append(errors, Kind(errs.ErrInternal))
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if status != http.StatusNoContent {
+		var result *Schema
+		if op.Result != nil {
+			result = s.of(reflectType(op.Result))
+		}
+		if config.Envelope != nil {
+			result = config.Envelope(result)
+		}
+		if result != nil {
+			success.Content = map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: result}}
+		}
+	}
+	o.Responses[strconv.Itoa(status)] = success
+	for code, response := range errorResponses(config, problem, errors) {
+		o.Responses[code] = response
+	}
+	return o
+}
+
+// errorResponses groups entries by status, and describes each status with the codes answered with it.
+func errorResponses(config Config, problem *Schema, entries []errs.Entry) map[string]Response {
+	byStatus := make(map[int][]errs.Entry)
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if seen[entry.Code] {
+			continue
+		}
+		seen[entry.Code] = true
+		byStatus[entry.HttpCode] = append(byStatus[entry.HttpCode], entry)
+	}
+	responses := make(map[string]Response, len(byStatus))
+	for status, entries := range byStatus {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+		lines := make([]string, len(entries))
+		codes := make([]interface{}, len(entries))
+		for i, entry := range entries {
+			message := config.Catalog.Localize(entry.Code, "")
+			if message == "" {
+				message = entry.Message
+			}
+			lines[i] = fmt.Sprintf("- `%s`: %s", entry.Code, message)
+			codes[i] = entry.Code
+		}
+		response := Response{Description: http.StatusText(status) + "\n\n" + strings.Join(lines, "\n")}
+		if problem != nil {
+			schema := &Schema{AllOf: []*Schema{problem, {
+				Type:       "object",
+				Properties: map[string]*Schema{"code": {Type: "string", Enum: codes}},
+			}}}
+			response.Content = map[string]MediaType{config.ProblemContentType: {Schema: schema}}
+		}
+		responses[strconv.Itoa(status)] = response
+	}
+	return responses
+}
+
+// operationID turns a route into an identifier, e.g. POST /v1/users/:id/unlock into postV1UsersIdUnlock.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range regexp.MustCompile(`[A-Za-z0-9]+`).FindAllString(path, -1) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
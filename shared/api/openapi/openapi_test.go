@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var errTestLocked = errs.Register(errs.Entry{Code: "OPENAPI_TEST_LOCKED", Kind: errs.ErrTooManyRequests, Message: "Account is locked"})
+
+type loginRequest struct {
+	Email    string   `json:"email,omitempty" validate:"required,email"`
+	Password string   `json:"password,omitempty" validate:"required,min=8,max=64" sensitive:"true"`
+	Remember *bool    `json:"remember,omitempty"`
+	Role     string   `json:"role" validate:"oneof=owner admin"`
+	Age      int      `json:"age" validate:"gte=18"`
+	Tags     []string `json:"tags" validate:"max=3,dive,required"`
+	internal string
+}
+
+type user struct {
+	ID        string    `json:"id"`
+	Manager   *user     `json:"manager"`
+	CreatedAt time.Time `json:"createdAt"`
+	Audit
+}
+
+type Audit struct {
+	UpdatedBy string `json:"updatedBy"`
+}
+
+type listQuery struct {
+	Status string `query:"status" validate:"required"`
+	Limit  int    `query:"limit" validate:"max=100"`
+}
+
+type problem struct {
+	Code   string `json:"code"`
+	Status int    `json:"status"`
+}
+
+func testConfig() Config {
+	return Config{
+		Info:               Info{Title: "Account", Version: "1.0.0"},
+		SecuritySchemes:    map[string]SecurityScheme{"bearer": {Type: "http", Scheme: "bearer"}},
+		Problem:            problem{},
+		ProblemContentType: "application/problem+json",
+	}
+}
+
+func TestRegister(t *testing.T) {
+	registry := NewRegistry()
+	app := fiber.New()
+	v1 := app.Group("/v1/auth")
+	Register(registry, v1, fiber.MethodPost, "/login", Operation{Summary: "Log in", Tags: []string{"auth"}, Body: loginRequest{}, Result: user{}, Errors: []errs.Entry{errTestLocked}},
+		func(ctx *fiber.Ctx) error { return ctx.SendStatus(fiber.StatusOK) })
+	Register(registry, v1, fiber.MethodGet, "/users/:id", Operation{Result: &user{}, Query: listQuery{}, Security: []string{"bearer"}, Errors: []errs.Entry{Kind(errs.ErrNotFound)}},
+		func(ctx *fiber.Ctx) error { return ctx.SendStatus(fiber.StatusOK) })
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/v1/auth/login", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode, "documented routes are registered on the router")
+
+	doc := registry.Document(testConfig())
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Equal(t, []Tag{{Name: "auth"}}, doc.Tags)
+
+	login := doc.Paths["/v1/auth/login"]["post"]
+	require.NotNil(t, login)
+	assert.Equal(t, "postV1AuthLogin", login.OperationID)
+	assert.Equal(t, Ref("loginRequest"), login.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, Ref("user"), login.Responses["200"].Content["application/json"].Schema)
+	assert.ElementsMatch(t, []string{"200", "400", "429", "500"}, keys(login.Responses))
+	assert.Equal(t, "Bad Request\n\n- `BAD_REQUEST`: Could not understand the request due to invalid syntax.\n- `VALIDATION_ERROR`: Could not understand the request due to invalid syntax.", login.Responses["400"].Description)
+	locked := login.Responses["429"].Content["application/problem+json"].Schema
+	assert.Equal(t, []interface{}{"OPENAPI_TEST_LOCKED"}, locked.AllOf[1].Properties["code"].Enum)
+	assert.Empty(t, login.Security)
+
+	get := doc.Paths["/v1/auth/users/{id}"]["get"]
+	require.NotNil(t, get)
+	assert.Equal(t, []SecurityRequirement{{"bearer": {}}}, get.Security)
+	assert.ElementsMatch(t, []string{"200", "401", "404", "500"}, keys(get.Responses))
+	assert.Equal(t, []Parameter{
+		{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "status", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "limit", In: "query", Schema: &Schema{Type: "integer", Format: "int32", Maximum: float(100)}},
+	}, get.Parameters)
+}
+
+func TestDocument_Schemas(t *testing.T) {
+	registry := NewRegistry()
+	registry.Add(fiber.MethodPost, "/login", Operation{Body: loginRequest{}, Result: []user{}})
+	schemas := registry.Document(testConfig()).Components.Schemas
+
+	login := schemas["loginRequest"]
+	require.NotNil(t, login)
+	assert.Equal(t, []string{"email", "password"}, login.Required)
+	assert.Equal(t, &Schema{Type: "string", Format: "email"}, login.Properties["email"])
+	assert.Equal(t, &Schema{Type: "string", Format: "password", WriteOnly: true, MinLength: integer(8), MaxLength: integer(64)}, login.Properties["password"])
+	assert.Equal(t, &Schema{Type: "boolean", Nullable: true}, login.Properties["remember"])
+	assert.Equal(t, []interface{}{"owner", "admin"}, login.Properties["role"].Enum)
+	assert.Equal(t, float(18), login.Properties["age"].Minimum)
+	assert.Equal(t, integer(3), login.Properties["tags"].MaxItems)
+	assert.NotContains(t, login.Properties, "internal")
+
+	u := schemas["user"]
+	require.NotNil(t, u)
+	assert.Equal(t, Ref("user"), u.Properties["manager"], "recursive types refer to themselves")
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, u.Properties["createdAt"])
+	assert.Equal(t, &Schema{Type: "string"}, u.Properties["updatedBy"], "embedded structs are flattened")
+	assert.Contains(t, schemas, "problem")
+}
+
+func TestServe(t *testing.T) {
+	registry := NewRegistry()
+	registry.Add(fiber.MethodGet, "/v1/ping", Operation{Summary: "Ping"})
+	app := fiber.New()
+	Serve(app, registry, testConfig())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/openapi.json", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	var doc map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	assert.Contains(t, doc["paths"], "/v1/ping")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/docs", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get(fiber.HeaderContentType), "text/html")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"/openapi.json"`)
+	assert.NotContains(t, string(body), "https://", "the viewer loads no third-party assets")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/docs/assets/README.md", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	resp, err = app.Test(httptest.NewRequest("GET", "/docs/assets/missing.js", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func keys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
+
+func integer(n int) *int {
+	return &n
+}
+
+func float(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,281 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI 3.0 schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+}
+
+// Ref returns a schema referring to the component schema name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	invalidName   = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// schemas describes Go types, and holds the named structs it met as component schemas.
+type schemas struct {
+	defs  map[string]*Schema
+	names map[reflect.Type]string
+	types map[reflect.Type]*Schema
+}
+
+func newSchemas(types map[interface{}]*Schema) *schemas {
+	s := &schemas{
+		defs:  make(map[string]*Schema),
+		names: make(map[reflect.Type]string),
+		types: map[reflect.Type]*Schema{timeType: {Type: "string", Format: "date-time"}},
+	}
+	for example, schema := range types {
+		s.types[reflectType(example)] = schema
+	}
+	return s
+}
+
+func reflectType(example interface{}) reflect.Type {
+	t := reflect.TypeOf(example)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func (s *schemas) of(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := s.of(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	}
+	if known, ok := s.types[t]; ok {
+		copied := *known
+		return &copied
+	}
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return Ref(s.define(t))
+	}
+	return &Schema{}
+}
+
+// define adds the component schema of the named struct t, and returns its name. Structs of
+// different packages sharing a name are told apart by their package name.
+func (s *schemas) define(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := invalidName.ReplaceAllString(t.Name(), "_")
+	if _, taken := s.defs[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	s.names[t] = name
+	s.defs[name] = &Schema{} // placeholder, so that recursive types refer to it
+	s.defs[name] = s.object(t)
+	return name
+}
+
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.fields(t, func(name string, field reflect.StructField) {
+		property := s.of(field.Type)
+		if field.Tag.Get("sensitive") == "true" {
+			property.Format, property.WriteOnly = "password", true
+		}
+		if applyValidate(property, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	})
+	return schema
+}
+
+// fields calls fn with the JSON names of the fields of t, the ones of embedded structs included.
+func (s *schemas) fields(t reflect.Type, fn func(name string, field reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.fields(embedded, fn)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fn(name, field)
+	}
+}
+
+// parameters describes the fields of t as parameters in location, named by their tag of the same
+// name, e.g. query:"status".
+func (s *schemas) parameters(t reflect.Type, location string) []Parameter {
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(location), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := s.of(field.Type)
+		required := applyValidate(schema, field.Tag.Get("validate"))
+		params = append(params, Parameter{Name: name, In: location, Required: required, Schema: schema})
+	}
+	return params
+}
+
+// applyValidate adds the constraints of validator tags to schema, and reports whether they
+// require the field. Constraints of referenced schemas and of dive elements are not described.
+func applyValidate(schema *Schema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			return required
+		case "required":
+			required = true
+			continue
+		}
+		if schema.Ref != "" || strings.Contains(rule, "|") {
+			continue
+		}
+		switch name {
+		case "email":
+			schema.Format = "email"
+		case "url", "uri", "http_url":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "datetime":
+			schema.Format = "date-time"
+		case "e164":
+			schema.Pattern = `^\+[1-9]\d{1,14}$`
+		case "alphanum":
+			schema.Pattern = `^[A-Za-z0-9]*$`
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, value)
+			}
+		case "len":
+			bound(schema, param, true, true, false)
+		case "min", "gte":
+			bound(schema, param, true, false, false)
+		case "max", "lte":
+			bound(schema, param, false, true, false)
+		case "gt":
+			bound(schema, param, true, false, true)
+		case "lt":
+			bound(schema, param, false, true, true)
+		}
+	}
+	return required
+}
+
+// bound sets the lower and/or upper bound of schema: a length for strings and arrays, and a
+// value for numbers.
+func bound(schema *Schema, param string, lower, upper, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch schema.Type {
+	case "string", "array":
+		n := int(value)
+		if exclusive && lower {
+			n++
+		} else if exclusive && upper {
+			n--
+		}
+		minimum, maximum := &schema.MinLength, &schema.MaxLength
+		if schema.Type == "array" {
+			minimum, maximum = &schema.MinItems, &schema.MaxItems
+		}
+		if lower {
+			*minimum = &n
+		}
+		if upper {
+			*maximum = &n
+		}
+	case "integer", "number":
+		if lower {
+			schema.Minimum, schema.ExclusiveMinimum = &value, exclusive
+		}
+		if upper {
+			schema.Maximum, schema.ExclusiveMaximum = &value, exclusive
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package openapi
+
+import (
+	"embed"
+	"github.com/gofiber/fiber/v2"
+	"html/template"
+	"io/fs"
+	"path"
+	"sync"
+)
+
+//go:embed viewer.html
+var viewerHTML string
+
+// swaggerUI holds the Swagger UI assets the viewer loads, vendored with `make swagger-ui`.
+//
+//go:embed swagger-ui
+var swaggerUI embed.FS
+
+var viewer = template.Must(template.New("viewer").Parse(viewerHTML))
+
+// Serve answers GET /openapi.json with the document of registry, and GET /docs with a viewer of it
+// whose assets are served from /docs/assets.
+// The document is generated on the first request, once every route has been registered.
+func Serve(router fiber.Router, registry Registry, config Config) {
+	var (
+		once     sync.Once
+		document *Document
+	)
+	router.Get("/openapi.json", func(ctx *fiber.Ctx) error {
+		once.Do(func() {
+			document = registry.Document(config)
+		})
+		return ctx.JSON(document)
+	})
+	router.Get("/docs", func(ctx *fiber.Ctx) error {
+		_, err := fs.Stat(swaggerUI, "swagger-ui/swagger-ui-bundle.js")
+		ctx.Type("html", "utf-8")
+		return viewer.Execute(ctx, map[string]interface{}{
+			"Title":    config.Info.Title,
+			"URL":      joinPath(prefix(router), "/openapi.json"),
+			"Assets":   joinPath(prefix(router), "/docs/assets"),
+			"Vendored": err == nil,
+		})
+	})
+	router.Get("/docs/assets/:file", func(ctx *fiber.Ctx) error {
+		file := ctx.Params("file")
+		content, err := swaggerUI.ReadFile(path.Join("swagger-ui", file))
+		if err != nil {
+			return fiber.ErrNotFound
+		}
+		ctx.Type(path.Ext(file))
+		return ctx.Send(content)
+	})
+}
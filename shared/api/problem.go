@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/redact"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"strings"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	Message   string            `json:"message,omitempty"` // message of the code in the client's language
+	Errors    map[string]string `json:"errors,omitempty"`
+	RequestID string            `json:"requestId,omitempty"`
+}
+
+type ErrorHandlerConfig struct {
+	// TypeBaseURI prefixes the error code to build the problem type, e.g. https://docs.zard.io/errors/
+	// gives https://docs.zard.io/errors/not-found. Types are about:blank when it is empty.
+	TypeBaseURI string
+	// Production hides the details of server errors from clients. The causes errors wrap are never
+	// sent, they only reach the logs.
+	Production bool
+	// Catalog localizes messages by the Accept-Language header, defaults to errs.DefaultCatalog.
+	Catalog errs.Catalog
+}
+
+// NewProblem describes err for the request to instance. The detail is the message of err without
+// its cause, and credentials in it are always redacted.
+func (Response) NewProblem(err error, instance string, config ErrorHandlerConfig) Problem {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		err = errs.NewCustomError(fiberErr.Message, statusCode(fiberErr.Code), http.StatusText(fiberErr.Code), fiberErr.Code, nil)
+	}
+	customErr := errs.HandleError(err)
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(customErr.HttpCode),
+		Status:   customErr.HttpCode,
+		Detail:   customErr.Message(),
+		Instance: instance,
+		Code:     customErr.Code,
+		Errors:   customErr.Fields,
+	}
+	if problem.Title == "" {
+		problem.Title = customErr.Desc
+	}
+	if config.TypeBaseURI != "" {
+		problem.Type = config.TypeBaseURI + strings.ToLower(strings.ReplaceAll(customErr.Code, "_", "-"))
+	}
+	if config.Production && problem.Status >= 500 {
+		problem.Detail = ""
+	}
+	problem.Detail = redact.String(problem.Detail)
+	return problem
+}
+
+// statusCode turns an HTTP status into an error code, e.g. 404 into NOT_FOUND.
+func statusCode(status int) string {
+	return strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
+
+// ErrorHandler returns a fiber.ErrorHandler answering every error with a problem+json body.
+// Register it with fiber.New(fiber.Config{ErrorHandler: api.Api.Response.ErrorHandler(config)}).
+func (r Response) ErrorHandler(config ErrorHandlerConfig) fiber.ErrorHandler {
+	return func(ctx *fiber.Ctx, err error) error {
+		problem := r.NewProblem(err, ctx.OriginalURL(), config)
+		catalog := config.Catalog
+		if catalog == nil {
+			catalog = errs.DefaultCatalog
+		}
+		problem.Message = catalog.Localize(problem.Code, ctx.Get(fiber.HeaderAcceptLanguage))
+		problem.RequestID = string(ctx.Response().Header.Peek(RequestIDHeader))
+		ctx.Set(fiber.HeaderContentType, ProblemContentType)
+		return ctx.Status(problem.Status).JSON(problem, ProblemContentType)
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func problemFor(t *testing.T, config ErrorHandlerConfig, handler fiber.Handler) (int, string, Problem) {
+	app := fiber.New(fiber.Config{ErrorHandler: Api.Response.ErrorHandler(config)})
+	app.Get("/users/:id", handler)
+	resp, err := app.Test(httptest.NewRequest("GET", "/users/42?expand=true", nil))
+	require.NoError(t, err)
+	var problem Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	return resp.StatusCode, resp.Header.Get(fiber.HeaderContentType), problem
+}
+
+func TestErrorHandler_CustomErrorWithoutCause(t *testing.T) {
+	status, contentType, problem := problemFor(t, ErrorHandlerConfig{TypeBaseURI: "https://docs.zard.io/errors/"}, func(ctx *fiber.Ctx) error {
+		return errs.NewUnauthorizedError("missing token", nil)
+	})
+	assert.Equal(t, 401, status)
+	assert.Equal(t, ProblemContentType, contentType)
+	assert.Equal(t, Problem{
+		Type:     "https://docs.zard.io/errors/unauthorized",
+		Title:    "Unauthorized",
+		Status:   401,
+		Detail:   "missing token",
+		Instance: "/users/42?expand=true",
+		Code:     "UNAUTHORIZED",
+		Message:  "Authentication required for the target resource.",
+	}, problem)
+}
+
+func TestErrorHandler_FieldErrors(t *testing.T) {
+	_, _, problem := problemFor(t, ErrorHandlerConfig{}, func(ctx *fiber.Ctx) error {
+		return errs.NewValidationError("invalid user", map[string]string{"email": "is required"})
+	})
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, map[string]string{"email": "is required"}, problem.Errors)
+}
+
+func TestErrorHandler_FiberError(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: Api.Response.ErrorHandler(ErrorHandlerConfig{})})
+	resp, err := app.Test(httptest.NewRequest("GET", "/missing", nil))
+	require.NoError(t, err)
+	var problem Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, "NOT_FOUND", problem.Code)
+	assert.Equal(t, "Not Found", problem.Title)
+	assert.Equal(t, "Cannot GET /missing", problem.Detail)
+}
+
+func TestErrorHandler_Production(t *testing.T) {
+	cause := errors.New("dial postgres://zard:hunter2@db/zard refused")
+	_, _, problem := problemFor(t, ErrorHandlerConfig{}, func(ctx *fiber.Ctx) error {
+		return errs.NewInternalError("unable to load user", cause)
+	})
+	assert.Equal(t, "unable to load user", problem.Detail, "causes only reach the logs")
+
+	status, _, problem := problemFor(t, ErrorHandlerConfig{Production: true}, func(ctx *fiber.Ctx) error {
+		return errs.NewInternalError("unable to load user", cause)
+	})
+	assert.Equal(t, 500, status)
+	assert.Empty(t, problem.Detail)
+	assert.Equal(t, "INTERNAL_ERROR", problem.Code)
+
+	_, _, problem = problemFor(t, ErrorHandlerConfig{Production: true}, func(ctx *fiber.Ctx) error {
+		return errs.NewNotFoundError("user not found", cause)
+	})
+	assert.Equal(t, "user not found", problem.Detail)
+}
+
+func TestErrorHandler_UnknownError(t *testing.T) {
+	status, _, problem := problemFor(t, ErrorHandlerConfig{Production: true}, func(ctx *fiber.Ctx) error {
+		return errors.New("boom")
+	})
+	assert.GreaterOrEqual(t, status, 500)
+	assert.Equal(t, status, problem.Status)
+	assert.Empty(t, problem.Detail)
+}
+
+func TestNewErrorResponse_WithoutCause(t *testing.T) {
+	httpCode, resp := Api.Response.NewErrorResponse(errs.NewUnauthorizedError("missing token", nil))
+	assert.Equal(t, 401, httpCode)
+	assert.Equal(t, "missing token", resp.Error.Reason)
+}
+
+func TestErrorHandler_LocalizesMessage(t *testing.T) {
+	catalog := errs.NewCatalog()
+	emailTaken := catalog.Register(errs.Entry{Code: "EMAIL_TAKEN", Kind: errs.ErrConflict, Message: "Email already exists"})
+	require.NoError(t, catalog.AddTranslations("fr", map[string]string{emailTaken.Key: "L'adresse e-mail existe déjà"}))
+	app := fiber.New(fiber.Config{ErrorHandler: Api.Response.ErrorHandler(ErrorHandlerConfig{Catalog: catalog})})
+	app.Post("/users", func(ctx *fiber.Ctx) error {
+		return emailTaken.New(nil)
+	})
+	req := httptest.NewRequest("POST", "/users", nil)
+	req.Header.Set("Accept-Language", "fr-CA,fr;q=0.9,en;q=0.8")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	var problem Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, 409, resp.StatusCode)
+	assert.Equal(t, "EMAIL_TAKEN", problem.Code)
+	assert.Equal(t, "L'adresse e-mail existe déjà", problem.Message)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/abdelrahman146/zard/shared/auth"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/abdelrahman146/zard/shared/ratelimit"
+	"github.com/gofiber/fiber/v2"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+// RateLimitKey returns what a request is limited by, or "" to limit it by IP.
+type RateLimitKey func(ctx *fiber.Ctx) string
+
+type RateLimit struct{}
+
+func (RateLimit) ByIP() RateLimitKey {
+	return func(ctx *fiber.Ctx) string {
+		return "ip:" + ctx.IP()
+	}
+}
+
+// ByUser limits authenticated users by ID, it has to run after AuthenticateMiddleware.
+func (RateLimit) ByUser() RateLimitKey {
+	return func(ctx *fiber.Ctx) string {
+		if p, ok := auth.FromContext(ctx.UserContext()); ok && p.Type == auth.PrincipalUser {
+			return "user:" + p.ID
+		}
+		return ""
+	}
+}
+
+// ByWorkspace limits the requests of a workspace together, whichever principal sends them.
+func (RateLimit) ByWorkspace() RateLimitKey {
+	return func(ctx *fiber.Ctx) string {
+		if p, ok := auth.FromContext(ctx.UserContext()); ok && p.WorkspaceID != "" {
+			return "workspace:" + p.WorkspaceID
+		}
+		return ""
+	}
+}
+
+// ByApiKey limits by the X-Api-Key header, which is hashed so that keys are not stored in the cache.
+func (RateLimit) ByApiKey() RateLimitKey {
+	return func(ctx *fiber.Ctx) string {
+		apiKey := ctx.Get(ApiKeyHeader)
+		if apiKey == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(apiKey))
+		return "apikey:" + hex.EncodeToString(sum[:16])
+	}
+}
+
+// Middleware rejects requests over the policy of limiter with a too many requests error, and sets the
+// RateLimit-* headers on every response. The first of keys returning a key is used, and requests are
+// limited by IP when none does. Requests are let through when the cache fails.
+func (r RateLimit) Middleware(limiter ratelimit.Limiter, keys ...RateLimitKey) fiber.Handler {
+	policy := limiter.Policy()
+	policyHeader := strconv.Itoa(policy.Limit) + ";w=" + seconds(policy.Window)
+	return func(ctx *fiber.Ctx) error {
+		key := ""
+		for _, k := range keys {
+			if key = k(ctx); key != "" {
+				break
+			}
+		}
+		if key == "" {
+			key = r.ByIP()(ctx)
+		}
+		result, err := limiter.Allow(key)
+		if err != nil {
+			logger.FromContext(ctx.UserContext()).Warn("rate limiter failed, letting the request through",
+				logger.Field("policy", policy.Name), logger.Field("error", err))
+			return ctx.Next()
+		}
+		ctx.Set(RateLimitPolicyHeader, policyHeader)
+		ctx.Set(RateLimitLimitHeader, strconv.Itoa(result.Limit))
+		ctx.Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		ctx.Set(RateLimitResetHeader, seconds(result.Reset))
+		if !result.Allowed {
+			ctx.Set(fiber.HeaderRetryAfter, seconds(result.RetryAfter))
+			return errs.NewTooManyRequestsError("rate limit of "+policy.Name+" exceeded, retry in "+seconds(result.RetryAfter)+"s", nil)
+		}
+		return ctx.Next()
+	}
+}
+
+// seconds rounds d up to whole seconds, as the headers expect.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"github.com/abdelrahman146/zard/shared/auth"
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/ratelimit"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingLimiter struct {
+	ratelimit.Limiter
+}
+
+func (failingLimiter) Policy() ratelimit.Policy {
+	return ratelimit.Policy{Name: "broken", Limit: 1, Window: time.Minute}
+}
+
+func (failingLimiter) Allow(string) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("cache unavailable")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := ratelimit.NewLimiter(cache.NewMemoryCache(), ratelimit.Policy{Name: "login", Limit: 2, Window: time.Minute})
+	app := fiber.New(fiber.Config{ErrorHandler: Api.Response.ErrorHandler(ErrorHandlerConfig{})})
+	app.Post("/login", Api.RateLimit.Middleware(limiter, Api.RateLimit.ByApiKey()), func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+	login := func(apiKey string) *http.Response {
+		req := httptest.NewRequest("POST", "/login", nil)
+		if apiKey != "" {
+			req.Header.Set(ApiKeyHeader, apiKey)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := login("")
+	assert.Equal(t, 204, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", resp.Header.Get(RateLimitRemainingHeader))
+	assert.Equal(t, "2;w=60", resp.Header.Get(RateLimitPolicyHeader))
+	assert.NotEmpty(t, resp.Header.Get(RateLimitResetHeader))
+
+	assert.Equal(t, 204, login("").StatusCode)
+	resp = login("")
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get(RateLimitRemainingHeader))
+	assert.NotEmpty(t, resp.Header.Get(fiber.HeaderRetryAfter))
+
+	assert.Equal(t, 204, login("zky_1").StatusCode, "api keys are limited apart from the IP")
+}
+
+func TestRateLimitMiddleware_FailsOpen(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", Api.RateLimit.Middleware(failingLimiter{}), func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 204, resp.StatusCode)
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		assert.Equal(t, "", Api.RateLimit.ByUser()(ctx))
+		assert.Equal(t, "", Api.RateLimit.ByApiKey()(ctx))
+		ctx.SetUserContext(auth.IntoContext(ctx.UserContext(), &auth.Principal{Type: auth.PrincipalUser, ID: "usr_1", WorkspaceID: "wrk_1"}))
+		assert.Equal(t, "user:usr_1", Api.RateLimit.ByUser()(ctx))
+		assert.Equal(t, "workspace:wrk_1", Api.RateLimit.ByWorkspace()(ctx))
+		assert.Equal(t, "ip:0.0.0.0", Api.RateLimit.ByIP()(ctx))
+		return nil
+	})
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+}
@@ -1,6 +1,9 @@
 package api
 
-import "github.com/abdelrahman146/zard/shared/errs"
+import (
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/redact"
+)
 
 type Response struct{}
 
@@ -30,9 +33,9 @@ func (Response) NewErrorResponse(err error) (httpCode int, resp ErrorResponse) {
 			Message:  customErr.Desc,
 			HttpCode: customErr.HttpCode,
 			Code:     customErr.Code,
-			Reason:   customErr.Original.Error(),
 		},
 	}
+	resp.Error.Reason = redact.String(customErr.Message())
 	return httpCode, resp
 }
 
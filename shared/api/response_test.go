@@ -0,0 +1,20 @@
+package api
+
+import (
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewErrorResponse_LeavesOutCause(t *testing.T) {
+	err := errs.NewInternalError("unable to connect", errors.New("dial postgres://zard:hunter2@db/zard: password=hunter2 rejected"))
+	httpCode, resp := Api.Response.NewErrorResponse(err)
+	assert.Equal(t, 500, httpCode)
+	assert.Equal(t, "unable to connect", resp.Error.Reason)
+}
+
+func TestNewErrorResponse_RedactsReason(t *testing.T) {
+	_, resp := Api.Response.NewErrorResponse(errs.NewBadRequestError("invalid dsn postgres://zard:hunter2@db/zard", nil))
+	assert.Equal(t, "invalid dsn postgres://zard:xxxxx@db/zard", resp.Error.Reason)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"github.com/abdelrahman146/zard/shared/api/middleware"
+	"github.com/abdelrahman146/zard/shared/api/openapi"
+	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+type ServerConfig struct {
+	Name         string        `config:"app.name" default:"zard"`
+	Debug        bool          `config:"app.debug"` // shows the details of server errors to clients
+	ErrorTypeURI string        `config:"app.api.errorTypeUri"`
+	ProxyHeader  string        `config:"app.api.proxyHeader"` // e.g. X-Forwarded-For, when running behind a proxy
+	BodyLimit    int           `config:"app.api.bodyLimit" default:"4194304" validate:"gt=0"`
+	ReadTimeout  time.Duration `config:"app.api.readTimeout" default:"30s"`
+	WriteTimeout time.Duration `config:"app.api.writeTimeout" default:"30s"`
+	IdleTimeout  time.Duration `config:"app.api.idleTimeout" default:"2m"`
+	Version      string        `config:"app.version" default:"1.0.0"`
+	Docs         bool          `config:"app.api.docs"` // serves the OpenAPI document at /openapi.json and a viewer at /docs
+}
+
+// NewServer returns a fiber app answering errors with problem+json bodies, and running the
+// middleware bundle: request IDs, access logs and panic recovery. With app.api.docs, it serves the
+// OpenAPI document of the routes registered with openapi, see Docs.Serve.
+func NewServer(conf config.Config) *fiber.App {
+	var serverConfig ServerConfig
+	if err := config.Bind(conf, &serverConfig); err != nil {
+		logger.GetLogger().Panic("invalid server configuration", logger.Field("error", err))
+	}
+	app := fiber.New(fiber.Config{
+		AppName:               serverConfig.Name,
+		ProxyHeader:           serverConfig.ProxyHeader,
+		BodyLimit:             serverConfig.BodyLimit,
+		ReadTimeout:           serverConfig.ReadTimeout,
+		WriteTimeout:          serverConfig.WriteTimeout,
+		IdleTimeout:           serverConfig.IdleTimeout,
+		DisableStartupMessage: true,
+		ErrorHandler: Api.Response.ErrorHandler(ErrorHandlerConfig{
+			TypeBaseURI: serverConfig.ErrorTypeURI,
+			Production:  !serverConfig.Debug,
+		}),
+	})
+	for _, handler := range middleware.Bundle() {
+		app.Use(handler)
+	}
+	if serverConfig.Docs {
+		Api.Docs.Serve(app, openapi.Info{Title: serverConfig.Name, Version: serverConfig.Version})
+	}
+	return app
+}
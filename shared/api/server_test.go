@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewServer(t *testing.T) {
+	conf := config.NewViperConfig()
+	conf.Set("app.api.errorTypeUri", "https://docs.zard.io/errors/")
+	app := NewServer(conf)
+	app.Get("/panic", func(ctx *fiber.Ctx) error {
+		panic("boom")
+	})
+	app.Get("/missing", func(ctx *fiber.Ctx) error {
+		return errs.NewNotFoundError("user not found", nil)
+	})
+
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set(RequestIDHeader, "req_1")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+	assert.Equal(t, ProblemContentType, resp.Header.Get(fiber.HeaderContentType))
+	var problem Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, "req_1", problem.RequestID)
+	assert.Equal(t, "https://docs.zard.io/errors/internal-error", problem.Type)
+	assert.Empty(t, problem.Detail, "panics are not leaked")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/missing", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get(RequestIDHeader))
+}
+
+func TestNewServer_Debug(t *testing.T) {
+	conf := config.NewViperConfig()
+	conf.Set("app.debug", true)
+	app := NewServer(conf)
+	app.Get("/panic", func(ctx *fiber.Ctx) error {
+		panic("boom")
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", "/panic", nil))
+	require.NoError(t, err)
+	var problem Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, "panic recovered", problem.Detail, "the panic value only reaches the logs")
+}
+
+func TestNewServer_Docs(t *testing.T) {
+	conf := config.NewViperConfig()
+	conf.Set("app.api.docs", true)
+	app := NewServer(conf)
+	resp, err := app.Test(httptest.NewRequest("GET", "/openapi.json", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	var doc struct {
+		Info       map[string]string `json:"info"`
+		Components struct {
+			SecuritySchemes map[string]interface{} `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+	assert.Equal(t, "zard", doc.Info["title"])
+	assert.Contains(t, doc.Components.SecuritySchemes, SchemeApiKey)
+
+	app = NewServer(config.NewViperConfig())
+	resp, err = app.Test(httptest.NewRequest("GET", "/openapi.json", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode, "docs are served when enabled only")
+}
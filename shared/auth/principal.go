@@ -0,0 +1,69 @@
+// Package auth describes who a request is made by, independently of how it authenticated.
+package auth
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"slices"
+)
+
+type PrincipalType string
+
+const (
+	PrincipalUser       PrincipalType = "user"
+	PrincipalWorkspace  PrincipalType = "workspace"
+	PrincipalBackoffice PrincipalType = "backoffice"
+	PrincipalService    PrincipalType = "service" // another service calling on its own behalf
+)
+
+// Principal is who a request is authenticated as.
+type Principal struct {
+	Type        PrincipalType `json:"type"`
+	ID          string        `json:"id"`
+	OrgID       string        `json:"orgId,omitempty"`
+	WorkspaceID string        `json:"workspaceId,omitempty"`
+	Roles       []string      `json:"roles,omitempty"`
+	Scopes      []string      `json:"scopes,omitempty"`
+	Scheme      string        `json:"scheme,omitempty"` // how the principal authenticated, e.g. bearer
+	Credential  string        `json:"-"`                // what it authenticated with, e.g. the session token to revoke on logout
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type contextKey struct{}
+
+// correlationKeys maps principal types to the correlation ID their ID is logged under.
+var correlationKeys = map[PrincipalType]string{
+	PrincipalUser:      logger.UserIDKey,
+	PrincipalWorkspace: logger.WorkspaceIDKey,
+}
+
+// IntoContext stores p in ctx and adds its ID to the correlation IDs of ctx.
+func IntoContext(ctx context.Context, p *Principal) context.Context {
+	ctx = context.WithValue(ctx, contextKey{}, p)
+	if key, ok := correlationKeys[p.Type]; ok {
+		ctx = logger.WithCorrelationID(ctx, key, p.ID)
+	}
+	return ctx
+}
+
+// FromContext returns the principal stored in ctx.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Current returns the principal stored in ctx, or an unauthorized error when there is none.
+func Current(ctx context.Context) (*Principal, error) {
+	if p, ok := FromContext(ctx); ok {
+		return p, nil
+	}
+	return nil, errs.NewUnauthorizedError("request is not authenticated", nil)
+}
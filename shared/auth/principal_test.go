@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPrincipalContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+	_, err := Current(context.Background())
+	assert.ErrorIs(t, err, errs.ErrUnauthorized)
+
+	p := &Principal{Type: PrincipalWorkspace, ID: "wrk_1", WorkspaceID: "wrk_1", Scopes: []string{"orders:read"}}
+	ctx := IntoContext(context.Background(), p)
+	got, err := Current(ctx)
+	require.NoError(t, err)
+	assert.Same(t, p, got)
+	assert.Equal(t, map[string]string{logger.WorkspaceIDKey: "wrk_1"}, logger.CorrelationIDs(ctx))
+
+	// keys are typed, so a string key of the same name does not collide
+	assert.Nil(t, context.WithValue(ctx, "workspace", []byte("{}")).Value("principal"))
+	got, _ = FromContext(context.WithValue(ctx, "workspace", []byte("{}")))
+	assert.Same(t, p, got)
+}
+
+func TestPrincipal_RolesAndScopes(t *testing.T) {
+	p := &Principal{Roles: []string{"admin"}, Scopes: []string{"orders:read"}}
+	assert.True(t, p.HasRole("admin"))
+	assert.False(t, p.HasRole("owner"))
+	assert.True(t, p.HasScope("orders:read"))
+	assert.False(t, p.HasScope("orders:write"))
+}
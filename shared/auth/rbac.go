@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"strings"
+	"sync"
+)
+
+// Built-in roles, organizations can define custom ones on top of them.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
+// Roles maps role names to the permissions they grant. Permissions are dotted strings such as
+// workspace.apikey.reset, and a trailing * grants everything below it, e.g. workspace.*.
+type Roles map[string][]string
+
+var DefaultRoles = Roles{
+	RoleOwner:  {"*"},
+	RoleAdmin:  {"org.read", "org.update", "org.members.*", "workspace.*"},
+	RoleMember: {"org.read", "workspace.read", "workspace.update"},
+	RoleViewer: {"org.read", "workspace.read"},
+}
+
+// ApiKeyScopes are the permissions the API key of a workspace grants, on that workspace only.
+var ApiKeyScopes = []string{"workspace.read"}
+
+// Permissions returns the permissions granted by roles, ignoring unknown ones.
+func (r Roles) Permissions(roles ...string) []string {
+	var permissions []string
+	for _, role := range roles {
+		permissions = append(permissions, r[role]...)
+	}
+	return permissions
+}
+
+// Match reports whether pattern grants permission.
+func Match(pattern, permission string) bool {
+	if pattern == "*" || pattern == permission {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, ".*")
+	return ok && strings.HasPrefix(permission, prefix+".")
+}
+
+// Grants reports whether any of permissions grants permission.
+func Grants(permissions []string, permission string) bool {
+	for _, pattern := range permissions {
+		if Match(pattern, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resource is what a permission is checked on. Empty IDs are not checked.
+type Resource struct {
+	OrgID       string
+	WorkspaceID string
+}
+
+// Policy decides whether a principal holds a permission on a resource.
+type Policy interface {
+	Allowed(ctx context.Context, p *Principal, permission string, resource Resource) (bool, error)
+}
+
+type rolePolicy struct {
+	roles Roles
+}
+
+// NewRolePolicy grants principals the permissions of their roles and their scopes, within their own
+// organization and workspace. Backoffice principals are not bound to any organization.
+func NewRolePolicy(roles Roles) Policy {
+	return &rolePolicy{roles: roles}
+}
+
+func (r *rolePolicy) Allowed(_ context.Context, p *Principal, permission string, resource Resource) (bool, error) {
+	if p.Type != PrincipalBackoffice {
+		if resource.OrgID != "" && resource.OrgID != p.OrgID {
+			return false, nil
+		}
+		if resource.WorkspaceID != "" && resource.WorkspaceID != p.WorkspaceID {
+			return false, nil
+		}
+	}
+	return Grants(p.Scopes, permission) || Grants(r.roles.Permissions(p.Roles...), permission), nil
+}
+
+var (
+	mu     sync.RWMutex
+	policy = NewRolePolicy(DefaultRoles)
+)
+
+// SetPolicy replaces the policy Check uses, which is NewRolePolicy(DefaultRoles) by default.
+func SetPolicy(p Policy) {
+	mu.Lock()
+	defer mu.Unlock()
+	policy = p
+}
+
+// Check returns an unauthorized error when ctx has no principal, and a forbidden error when
+// its principal does not hold permission on resource.
+func Check(ctx context.Context, permission string, resource Resource) error {
+	p, err := Current(ctx)
+	if err != nil {
+		return err
+	}
+	mu.RLock()
+	current := policy
+	mu.RUnlock()
+	allowed, err := current.Allowed(ctx, p, permission, resource)
+	if err != nil {
+		return errs.Annotate(err, "unable to check permission "+permission)
+	}
+	if !allowed {
+		return errs.NewForbiddenError("missing permission "+permission, nil)
+	}
+	return nil
+}
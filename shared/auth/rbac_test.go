@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	assert.True(t, Match("*", "workspace.apikey.reset"))
+	assert.True(t, Match("workspace.apikey.reset", "workspace.apikey.reset"))
+	assert.True(t, Match("workspace.*", "workspace.apikey.reset"))
+	assert.True(t, Match("workspace.apikey.*", "workspace.apikey.reset"))
+	assert.False(t, Match("workspace.*", "workspace"))
+	assert.False(t, Match("workspace.*", "workspaces.read"))
+	assert.False(t, Match("workspace.read", "workspace.update"))
+}
+
+func TestDefaultRoles(t *testing.T) {
+	assert.True(t, Grants(DefaultRoles.Permissions(RoleOwner), "billing.refund"))
+	assert.True(t, Grants(DefaultRoles.Permissions(RoleAdmin), "workspace.apikey.reset"))
+	assert.False(t, Grants(DefaultRoles.Permissions(RoleAdmin), "org.delete"))
+	assert.True(t, Grants(DefaultRoles.Permissions(RoleMember), "workspace.update"))
+	assert.False(t, Grants(DefaultRoles.Permissions(RoleMember), "workspace.apikey.reset"))
+	assert.False(t, Grants(DefaultRoles.Permissions(RoleViewer), "workspace.update"))
+	assert.Empty(t, DefaultRoles.Permissions("unknown"))
+}
+
+func TestRolePolicy(t *testing.T) {
+	policy := NewRolePolicy(DefaultRoles)
+	admin := &Principal{Type: PrincipalUser, ID: "usr_1", OrgID: "org_1", Roles: []string{RoleAdmin}}
+
+	allowed, err := policy.Allowed(context.Background(), admin, "workspace.apikey.reset", Resource{OrgID: "org_1"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, _ = policy.Allowed(context.Background(), admin, "workspace.apikey.reset", Resource{OrgID: "org_2"})
+	assert.False(t, allowed, "roles do not reach other organizations")
+
+	ws := &Principal{Type: PrincipalWorkspace, ID: "wrk_1", WorkspaceID: "wrk_1", Scopes: []string{"orders.*"}}
+	allowed, _ = policy.Allowed(context.Background(), ws, "orders.read", Resource{WorkspaceID: "wrk_1"})
+	assert.True(t, allowed)
+	allowed, _ = policy.Allowed(context.Background(), ws, "orders.read", Resource{WorkspaceID: "wrk_2"})
+	assert.False(t, allowed)
+
+	backoffice := &Principal{Type: PrincipalBackoffice, ID: "7", Roles: []string{RoleOwner}}
+	allowed, _ = policy.Allowed(context.Background(), backoffice, "org.update", Resource{OrgID: "org_2"})
+	assert.True(t, allowed)
+}
+
+type policyFunc func(ctx context.Context, p *Principal, permission string, resource Resource) (bool, error)
+
+func (f policyFunc) Allowed(ctx context.Context, p *Principal, permission string, resource Resource) (bool, error) {
+	return f(ctx, p, permission, resource)
+}
+
+func TestCheck(t *testing.T) {
+	ctx := IntoContext(context.Background(), &Principal{Type: PrincipalUser, ID: "usr_1", OrgID: "org_1", Roles: []string{RoleViewer}})
+
+	assert.NoError(t, Check(ctx, "workspace.read", Resource{}))
+	assert.ErrorIs(t, Check(ctx, "workspace.update", Resource{}), errs.ErrForbidden)
+	assert.ErrorIs(t, Check(context.Background(), "workspace.read", Resource{}), errs.ErrUnauthorized)
+
+	SetPolicy(policyFunc(func(context.Context, *Principal, string, Resource) (bool, error) {
+		return false, errors.New("connection refused")
+	}))
+	defer SetPolicy(NewRolePolicy(DefaultRoles))
+	assert.ErrorIs(t, Check(ctx, "workspace.read", Resource{}), errs.ErrInternal)
+}
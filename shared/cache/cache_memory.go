@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errKeyNotFound = errors.New("key not found")
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // zero when the entry does not expire
+}
+
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+// NewMemoryCache keeps entries in memory until their ttl, for tests and services running a single
+// instance. It increments counters atomically.
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+// lookup returns the entry at key, dropping it when it expired. c.mu must be held.
+func (c *memoryCache) lookup(key string) (memoryEntry, bool) {
+	entry, ok := c.entries[key]
+	if ok && !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, ok
+}
+
+func (c *memoryCache) store(key string, value []byte, ttl time.Duration) {
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = c.now().Add(ttl)
+	}
+	c.entries[key] = entry
+}
+
+func (c *memoryCache) Get(keyPath []string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.lookup(strings.Join(keyPath, "."))
+	if !ok {
+		return nil, errKeyNotFound
+	}
+	return append([]byte(nil), entry.value...), nil
+}
+
+func (c *memoryCache) Set(keyPath []string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store(strings.Join(keyPath, "."), append([]byte(nil), value...), ttl)
+	return nil
+}
+
+func (c *memoryCache) Increment(keyPath []string, delta int64, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := strings.Join(keyPath, ".")
+	entry, _ := c.lookup(key)
+	value := parseCounter(entry.value) + delta
+	c.store(key, []byte(strconv.FormatInt(value, 10)), ttl)
+	return value, nil
+}
+
+func (c *memoryCache) Delete(keyPath []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, strings.Join(keyPath, "."))
+	return nil
+}
+
+// Keys returns the keys starting with keyPath, sorted.
+func (c *memoryCache) Keys(keyPath []string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := strings.Join(keyPath, ".")
+	var keys []string
+	for key := range c.entries {
+		if _, ok := c.lookup(key); ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewMemoryCache().(*memoryCache)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set([]string{"account", "auth", "otp", "jane@zard.io"}, []byte("123456"), time.Minute))
+	require.NoError(t, c.Set([]string{"account", "auth", "user", "tokens", "ztkn_1"}, []byte("{}"), 0))
+	value, err := c.Get([]string{"account", "auth", "otp", "jane@zard.io"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("123456"), value)
+	keys, err := c.Keys([]string{"account", "auth"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"account.auth.otp.jane@zard.io", "account.auth.user.tokens.ztkn_1"}, keys)
+
+	now = now.Add(time.Minute)
+	_, err = c.Get([]string{"account", "auth", "otp", "jane@zard.io"})
+	assert.Error(t, err, "entries expire with their ttl")
+	keys, _ = c.Keys(nil)
+	assert.Equal(t, []string{"account.auth.user.tokens.ztkn_1"}, keys)
+
+	require.NoError(t, c.Delete([]string{"account", "auth", "user", "tokens", "ztkn_1"}))
+	keys, _ = c.Keys(nil)
+	assert.Empty(t, keys)
+}
+
+func TestMemoryCache_Increment(t *testing.T) {
+	c := NewMemoryCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = Increment(c, []string{"ratelimit", "login"}, 1, time.Minute)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(50), GetCounter(c, []string{"ratelimit", "login"}), "no increment is lost")
+}
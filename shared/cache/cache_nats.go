@@ -2,16 +2,31 @@ package cache
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"github.com/abdelrahman146/zard/shared/logger"
 	"github.com/abdelrahman146/zard/shared/provider"
 	"github.com/nats-io/nats.go"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// defaultBucketTTL is how long counters and values set with a ttl are kept after their last write,
+// until a longer ttl is asked for.
+const defaultBucketTTL = time.Hour
+
 type natsCache struct {
-	bucket nats.KeyValue
-	config *nats.KeyValueConfig
+	js       nats.JetStreamContext
+	bucket   nats.KeyValue // values set without ttl
+	expiring nats.KeyValue // <bucket>_expiring, values set with a ttl, stored along with when they expire
+	counters nats.KeyValue // <bucket>_counters, whose TTL grows to the longest ttl counters were incremented with
+	config   *nats.KeyValueConfig
+
+	mu          sync.Mutex
+	expiringTTL time.Duration
+	counterTTL  time.Duration
 }
 
 func NewNatsCache(nts provider.NatsProvider, config *nats.KeyValueConfig) Cache {
@@ -19,7 +34,7 @@ func NewNatsCache(nts provider.NatsProvider, config *nats.KeyValueConfig) Cache
 		config: config,
 	}
 	if err := c.init(nts.GetConn()); err != nil {
-		logger.GetLogger().Panic("failed to initialize nats cache", logger.Field("error", err))
+		logger.GetLogger().Named("cache").Panic("failed to initialize nats cache", logger.Field("error", err))
 	}
 	return c
 }
@@ -29,52 +44,224 @@ func (c *natsCache) init(nc *nats.Conn) error {
 	if err != nil {
 		return err
 	}
-	bucket, err := js.KeyValue(c.config.Bucket)
+	c.js = js
+	if c.bucket, err = openBucket(js, c.config.Bucket, 0); err != nil {
+		return err
+	}
+	if c.expiring, c.expiringTTL, err = openTTLBucket(js, c.config.Bucket+"_expiring"); err != nil {
+		return err
+	}
+	if c.counters, c.counterTTL, err = openTTLBucket(js, c.config.Bucket+"_counters"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// openTTLBucket opens a bucket whose TTL is grown by ensureTTL, and returns its current TTL.
+func openTTLBucket(js nats.JetStreamContext, name string) (nats.KeyValue, time.Duration, error) {
+	bucket, err := openBucket(js, name, defaultBucketTTL)
+	if err != nil {
+		return nil, 0, err
+	}
+	status, err := bucket.Status()
+	if err != nil {
+		return nil, 0, err
+	}
+	return bucket, status.TTL(), nil
+}
+
+func openBucket(js nats.JetStreamContext, name string, ttl time.Duration) (nats.KeyValue, error) {
+	bucket, err := js.KeyValue(name)
 	if err != nil {
 		bucket, err = js.CreateKeyValue(&nats.KeyValueConfig{
-			Bucket:  c.config.Bucket,
+			Bucket:  name,
 			Storage: nats.MemoryStorage,
+			TTL:     ttl,
 		})
-		if err != nil {
+	}
+	return bucket, err
+}
+
+// ensureTTL makes the keys of bucket, whose TTL is current, live at least ttl after their last
+// write. KV buckets expire keys by bucket rather than by key, so their TTL only ever grows.
+func (c *natsCache) ensureTTL(bucket string, current *time.Duration, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ttl <= *current {
+		return nil
+	}
+	info, err := c.js.StreamInfo("KV_" + bucket)
+	if err != nil {
+		return err
+	}
+	if info.Config.MaxAge < ttl {
+		config := info.Config
+		config.MaxAge = ttl
+		if _, err = c.js.UpdateStream(&config); err != nil {
 			return err
 		}
+		info.Config.MaxAge = ttl
 	}
-	c.bucket = bucket
+	*current = info.Config.MaxAge
 	return nil
 }
 
+// encodeExpiring prefixes value with when it expires, in Unix nanoseconds. The TTL of the
+// expiring bucket only bounds how long values are kept, it is the prefix that expires them on time.
+func encodeExpiring(value []byte, expiresAt time.Time) []byte {
+	data := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(data, uint64(expiresAt.UnixNano()))
+	return append(data, value...)
+}
+
+// decodeExpiring returns the value of data, and false when it expired by now.
+func decodeExpiring(data []byte, now time.Time) ([]byte, bool) {
+	if len(data) < 8 {
+		return nil, false
+	}
+	expiresAt := time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+	if !now.Before(expiresAt) {
+		return nil, false
+	}
+	return data[8:], true
+}
+
+// getExpiring returns the value of key in the expiring bucket, as not found once it expired.
+func (c *natsCache) getExpiring(key string) ([]byte, error) {
+	entry, err := c.expiring.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := decodeExpiring(entry.Value(), time.Now())
+	if !ok {
+		return nil, nats.ErrKeyNotFound
+	}
+	return value, nil
+}
+
+// Get returns the value at keyPath, looking counters up when no value was set there.
 func (c *natsCache) Get(keyPath []string) (value []byte, err error) {
 	key := strings.Join(keyPath, ".")
 	entry, err := c.bucket.Get(key)
-	if err != nil {
+	if err == nil {
+		return entry.Value(), nil
+	}
+	if !errors.Is(err, nats.ErrKeyNotFound) {
+		return nil, err
+	}
+	if value, err = c.getExpiring(key); !errors.Is(err, nats.ErrKeyNotFound) {
+		return value, err
+	}
+	if entry, err = c.counters.Get(key); err != nil {
 		return nil, err
 	}
 	return entry.Value(), nil
 }
 
+// Set stores value at keyPath, for ttl when it is positive. Values with a ttl are kept in the
+// expiring bucket, and the value of the other bucket is deleted so an older one does not outlive it.
 func (c *natsCache) Set(keyPath []string, value []byte, ttl time.Duration) error {
 	key := strings.Join(keyPath, ".")
-	_, err := c.bucket.Put(key, value)
-	return err
+	if ttl <= 0 {
+		if _, err := c.bucket.Put(key, value); err != nil {
+			return err
+		}
+		return deleteIfExists(c.expiring, key)
+	}
+	if err := c.ensureTTL(c.config.Bucket+"_expiring", &c.expiringTTL, ttl); err != nil {
+		return err
+	}
+	if _, err := c.expiring.Put(key, encodeExpiring(value, time.Now().Add(ttl))); err != nil {
+		return err
+	}
+	return deleteIfExists(c.bucket, key)
+}
+
+func deleteIfExists(bucket nats.KeyValue, key string) error {
+	if _, err := bucket.Get(key); err != nil {
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+	return bucket.Delete(key)
+}
+
+// Increment updates the counter with compare-and-set, retrying when another writer got there first.
+// Counters are kept in the counters bucket, and expire at least ttl after their last increment.
+func (c *natsCache) Increment(keyPath []string, delta int64, ttl time.Duration) (int64, error) {
+	if err := c.ensureTTL(c.config.Bucket+"_counters", &c.counterTTL, ttl); err != nil {
+		return 0, err
+	}
+	key := strings.Join(keyPath, ".")
+	for {
+		entry, err := c.counters.Get(key)
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			value := delta
+			if _, err = c.counters.Create(key, []byte(strconv.FormatInt(value, 10))); err == nil {
+				return value, nil
+			} else if !errors.Is(err, nats.ErrKeyExists) {
+				return 0, err
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		value := parseCounter(entry.Value()) + delta
+		if _, err = c.counters.Update(key, []byte(strconv.FormatInt(value, 10)), entry.Revision()); err == nil {
+			return value, nil
+		} else if !errors.Is(err, nats.ErrKeyExists) {
+			return 0, err
+		}
+	}
 }
 
 func (c *natsCache) Delete(keyPath []string) error {
 	key := strings.Join(keyPath, ".")
+	if _, err := c.counters.Get(key); err == nil {
+		if err = c.counters.Delete(key); err != nil {
+			return err
+		}
+	}
+	if err := deleteIfExists(c.expiring, key); err != nil {
+		return err
+	}
 	return c.bucket.Delete(key)
 }
 
+// Keys returns the keys starting with keyPath, of values set with or without ttl.
 func (c *natsCache) Keys(keyPath []string) ([]string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	pathPrefix := strings.Join(keyPath, ".")
-	lister, err := c.bucket.ListKeys()
+	keys, err := listKeys(ctx, c.bucket, pathPrefix)
+	if err != nil {
+		return nil, err
+	}
+	expiring, err := listKeys(ctx, c.expiring, pathPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range expiring {
+		if _, err := c.getExpiring(key); err == nil {
+			keys = append(keys, key)
+		} else if !errors.Is(err, nats.ErrKeyNotFound) {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func listKeys(ctx context.Context, bucket nats.KeyValue, pathPrefix string) ([]string, error) {
+	lister, err := bucket.ListKeys()
 	if err != nil {
 		return nil, err
 	}
 	defer func(lister nats.KeyLister) {
 		err := lister.Stop()
 		if err != nil {
-			logger.GetLogger().Error("failed to stop key lister", logger.Field("error", err))
+			logger.GetLogger().Named("cache").Error("failed to stop key lister", logger.Field("error", err))
 		}
 	}(lister)
 
@@ -84,8 +271,11 @@ func (c *natsCache) Keys(keyPath []string) ([]string, error) {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case key := <-lister.Keys():
-			if len(pathPrefix) == 0 || len(key) >= len(pathPrefix) && key[:len(pathPrefix)] == pathPrefix {
+		case key, ok := <-lister.Keys():
+			if !ok {
+				return keys, nil
+			}
+			if strings.HasPrefix(key, pathPrefix) {
 				keys = append(keys, key)
 			}
 		}
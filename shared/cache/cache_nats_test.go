@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestExpiringValues(t *testing.T) {
+	now := time.Now()
+	data := encodeExpiring([]byte("123456"), now.Add(time.Minute))
+
+	value, ok := decodeExpiring(data, now)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("123456"), value)
+
+	_, ok = decodeExpiring(data, now.Add(time.Minute))
+	assert.False(t, ok, "values expire at their ttl, not when the bucket drops them")
+
+	_, ok = decodeExpiring([]byte("short"), now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"strconv"
+	"time"
+)
+
+// Counter is implemented by caches that increment counters atomically.
+type Counter interface {
+	// Increment adds delta to the counter at keyPath, creating it when missing, and returns its new value.
+	Increment(keyPath []string, delta int64, ttl time.Duration) (int64, error)
+}
+
+// Increment adds delta to the counter at keyPath. It is atomic when c is a Counter, otherwise it
+// reads then writes the counter, and concurrent increments may be lost.
+func Increment(c Cache, keyPath []string, delta int64, ttl time.Duration) (int64, error) {
+	if counter, ok := c.(Counter); ok {
+		return counter.Increment(keyPath, delta, ttl)
+	}
+	value := delta
+	if current, err := c.Get(keyPath); err == nil {
+		value += parseCounter(current)
+	}
+	if err := c.Set(keyPath, []byte(strconv.FormatInt(value, 10)), ttl); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// GetCounter returns the counter at keyPath, or 0 when it does not exist.
+func GetCounter(c Cache, keyPath []string) int64 {
+	value, err := c.Get(keyPath)
+	if err != nil {
+		return 0
+	}
+	return parseCounter(value)
+}
+
+func parseCounter(value []byte) int64 {
+	n, _ := strconv.ParseInt(string(value), 10, 64)
+	return n
+}
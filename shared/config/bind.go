@@ -0,0 +1,219 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/validator"
+	playground "github.com/go-playground/validator/v10"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind populates the struct pointed to by target from conf and validates it.
+//
+// Fields are bound using the following tags:
+//   - config:   the key to read, relative to the key of the enclosing struct
+//   - default:  the value used when the key is not set
+//   - required: "true" reports the key as missing when it has no value and no default
+//   - validate: rules checked by the shared validator once every field is bound
+//
+// time.Duration fields accept Go duration strings ("5m") or plain numbers of seconds.
+// Every missing or invalid key is reported in a single validation error.
+func Bind(conf Config, target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errs.NewInternalError("config target must be a pointer to a struct", nil)
+	}
+	b := &binder{
+		conf:      conf,
+		problems:  make(map[string]string),
+		fieldKeys: make(map[string]string),
+	}
+	b.bindStruct(rv.Elem(), "", rv.Elem().Type().Name())
+	v := validator.NewValidator()
+	var invalid playground.ValidationErrors
+	if err := v.ValidateStruct(target); errors.As(err, &invalid) {
+		for _, e := range invalid {
+			key, ok := b.fieldKeys[e.StructNamespace()]
+			if !ok {
+				key = e.StructNamespace()
+			}
+			if _, ok := b.problems[key]; ok {
+				continue
+			}
+			for _, msg := range v.GetValidationErrors(playground.ValidationErrors{e}) {
+				b.problems[key] = msg
+			}
+		}
+	}
+	if len(b.problems) > 0 {
+		return newBindError(b.problems)
+	}
+	return nil
+}
+
+func newBindError(problems map[string]string) error {
+	keys := make([]string, 0, len(problems))
+	for key := range problems {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var report strings.Builder
+	report.WriteString("invalid configuration:")
+	for _, key := range keys {
+		report.WriteString("\n  " + key + ": " + problems[key])
+	}
+	return errs.NewValidationError(report.String(), problems)
+}
+
+type binder struct {
+	conf      Config
+	problems  map[string]string
+	fieldKeys map[string]string // struct namespace of a field -> config key, used to report validation errors by key
+}
+
+func (b *binder) bindStruct(v reflect.Value, prefix, namespace string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		if !value.CanSet() {
+			continue
+		}
+		tag, ok := field.Tag.Lookup("config")
+		if tag == "-" {
+			continue
+		}
+		key := joinKey(prefix, tag)
+		fieldNamespace := namespace + "." + field.Name
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			if ok || field.Anonymous {
+				b.bindStruct(value, key, fieldNamespace)
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+		b.fieldKeys[fieldNamespace] = key
+		b.bindField(value, field, key)
+	}
+}
+
+func (b *binder) bindField(value reflect.Value, field reflect.StructField, key string) {
+	var raw interface{}
+	switch def, hasDefault := field.Tag.Lookup("default"); {
+	case b.conf.IsSet(key):
+		raw = b.conf.Get(key)
+	case hasDefault:
+		raw = def
+	case field.Tag.Get("required") == "true":
+		b.problems[key] = "is required"
+		return
+	default:
+		return
+	}
+	if err := setValue(value, raw); err != nil {
+		b.problems[key] = fmt.Sprintf("invalid value %q: %s", fmt.Sprint(raw), err)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	switch {
+	case prefix == "":
+		return key
+	case key == "":
+		return prefix
+	default:
+		return prefix + "." + key
+	}
+}
+
+func setValue(v reflect.Value, raw interface{}) error {
+	if v.Type() == durationType {
+		d, err := parseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	s := strings.TrimSpace(fmt.Sprint(raw))
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(fmt.Sprint(raw))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("expected a boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected a positive integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := toSlice(raw)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), item); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// parseDuration accepts Go duration strings or plain numbers of seconds.
+func parseDuration(raw interface{}) (time.Duration, error) {
+	s := strings.TrimSpace(fmt.Sprint(raw))
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("expected a duration such as 30s or 5m")
+	}
+	return d, nil
+}
+
+func toSlice(raw interface{}) []interface{} {
+	rv := reflect.ValueOf(raw)
+	if rv.Kind() == reflect.Slice {
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+		return items
+	}
+	var items []interface{}
+	for _, item := range strings.Split(fmt.Sprint(raw), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
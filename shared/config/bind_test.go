@@ -0,0 +1,82 @@
+package config
+
+import (
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type testAuthConfig struct {
+	TokenTTL time.Duration `config:"tokenTTL" default:"24h"`
+	OtpTTL   time.Duration `config:"otpTTL" required:"true"`
+	Attempts int           `config:"attempts" default:"5" validate:"min=1"`
+}
+
+type testAppConfig struct {
+	Secret  string         `config:"secret" required:"true"`
+	Debug   bool           `config:"debug"`
+	Origins []string       `config:"origins" default:"a.com, b.com"`
+	Auth    testAuthConfig `config:"auth"`
+}
+
+func TestBind(t *testing.T) {
+	conf := NewViperConfig()
+	conf.Set("app.secret", "s3cr3t")
+	conf.Set("app.debug", "true")
+	conf.Set("app.auth.otpTTL", 300)
+	conf.Set("app.auth.tokenTTL", "1h30m")
+
+	type target struct {
+		App testAppConfig `config:"app"`
+	}
+	c := &target{}
+	assert.NoError(t, Bind(conf, c))
+	assert.Equal(t, "s3cr3t", c.App.Secret)
+	assert.True(t, c.App.Debug)
+	assert.Equal(t, []string{"a.com", "b.com"}, c.App.Origins)
+	assert.Equal(t, 5*time.Minute, c.App.Auth.OtpTTL)
+	assert.Equal(t, 90*time.Minute, c.App.Auth.TokenTTL)
+	assert.Equal(t, 5, c.App.Auth.Attempts)
+}
+
+func TestBind_ReportsEveryProblem(t *testing.T) {
+	conf := NewViperConfig()
+	conf.Set("app.debug", "maybe")
+	conf.Set("app.auth.attempts", 0)
+	conf.Set("app.auth.tokenTTL", "soon")
+
+	type target struct {
+		App testAppConfig `config:"app"`
+	}
+	err := Bind(conf, &target{})
+	customErr := errs.HandleError(err)
+	assert.Equal(t, 400, customErr.HttpCode)
+	assert.Equal(t, map[string]string{
+		"app.secret":        "is required",
+		"app.debug":         `invalid value "maybe": expected a boolean`,
+		"app.auth.otpTTL":   "is required",
+		"app.auth.tokenTTL": `invalid value "soon": expected a duration such as 30s or 5m`,
+		"app.auth.attempts": "Attempts must be 1 or greater",
+	}, customErr.Fields)
+	assert.Contains(t, err.Error(), "app.auth.otpTTL: is required")
+}
+
+func TestBind_ReportsValidationErrorsOfSameNamedFieldsByKey(t *testing.T) {
+	type ttlConfig struct {
+		TTL time.Duration `config:"ttl" default:"1m" validate:"gt=0"`
+	}
+	type target struct {
+		Session ttlConfig `config:"session"`
+		Otp     ttlConfig `config:"otp"`
+	}
+	conf := NewViperConfig()
+	conf.Set("session.ttl", 0)
+	err := Bind(conf, &target{})
+	assert.Equal(t, map[string]string{"session.ttl": "TTL must be greater than 0"}, errs.HandleError(err).Fields)
+}
+
+func TestBind_RejectsNonStructTargets(t *testing.T) {
+	var n int
+	assert.Error(t, Bind(NewViperConfig(), &n))
+}
@@ -1,14 +1,20 @@
 package config
 
 type Config interface {
+	Get(key string) interface{}
+	IsSet(key string) bool
 	GetString(key string) string
 	GetInt(key string) int
 	GetFloat(key string) float64
 	GetBool(key string) bool
 	Set(key string, value interface{})
+	// SetAll applies every value at once, so readers never observe a partial update.
+	SetAll(values map[string]interface{})
 }
 
-func setBulk(conf Config, prefix string, data map[string]interface{}) {
+// flatten converts nested maps into dotted keys, e.g. {"auth": {"otpTTL": 60}} becomes {"auth.otpTTL": 60}.
+func flatten(prefix string, data map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
 	for key, value := range data {
 		fullKey := key
 		if prefix != "" {
@@ -16,9 +22,12 @@ func setBulk(conf Config, prefix string, data map[string]interface{}) {
 		}
 		switch v := value.(type) {
 		case map[string]interface{}:
-			setBulk(conf, fullKey, v)
+			for k, val := range flatten(fullKey, v) {
+				flat[k] = val
+			}
 		default:
-			conf.Set(fullKey, v)
+			flat[fullKey] = v
 		}
 	}
+	return flat
 }
@@ -2,10 +2,12 @@ package config
 
 import (
 	"github.com/spf13/viper"
+	"sync"
 )
 
 type viperConfig struct {
-	v *viper.Viper
+	mu sync.RWMutex
+	v  *viper.Viper
 }
 
 func NewViperConfig() Config {
@@ -14,22 +16,52 @@ func NewViperConfig() Config {
 	return &viperConfig{v: v}
 }
 
+func (v *viperConfig) Get(key string) interface{} {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.v.Get(key)
+}
+
+func (v *viperConfig) IsSet(key string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.v.IsSet(key)
+}
+
 func (v *viperConfig) GetString(key string) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 	return v.v.GetString(key)
 }
 
 func (v *viperConfig) GetInt(key string) int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 	return v.v.GetInt(key)
 }
 
 func (v *viperConfig) GetFloat(key string) float64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 	return v.v.GetFloat64(key)
 }
 
 func (v *viperConfig) GetBool(key string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 	return v.v.GetBool(key)
 }
 
 func (v *viperConfig) Set(key string, value interface{}) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	v.v.Set(key, value)
 }
+
+func (v *viperConfig) SetAll(values map[string]interface{}) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for key, value := range values {
+		v.v.Set(key, value)
+	}
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/provider"
+	"github.com/abdelrahman146/zard/shared/redact"
+	"github.com/abdelrahman146/zard/shared/utils"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Layers are merged from the lowest to the highest priority, whatever the order
+// they were added in. A key set by a higher layer overrides the same key set by
+// a lower one:
+//
+//	defaults < files < consul < environment (ZARD_*) < command line flags
+//
+// Files are merged in the order they were added, so later files win.
+const (
+	PriorityDefaults = iota
+	PriorityFile
+	PriorityConsul
+	PriorityEnv
+	PriorityFlags
+)
+
+type layer struct {
+	priority int
+	source   string // set on layers that can be replaced, e.g. "consul:app"
+	values   map[string]interface{}
+	origins  map[string]string // key -> where the value came from, e.g. "env:ZARD_APP_SECRET"
+}
+
+// Loader merges configuration from several sources into a Config and
+// remembers which source every value came from.
+type Loader struct {
+	mu     sync.RWMutex
+	layers []layer
+	values map[string]interface{}
+	origin map[string]string
+}
+
+type DumpEntry struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+func NewLoader() *Loader {
+	return &Loader{
+		values: make(map[string]interface{}),
+		origin: make(map[string]string),
+	}
+}
+
+func (l *Loader) add(priority int, values map[string]interface{}, origin func(key string) string) {
+	l.replace(priority, "", values, origin)
+}
+
+// replace adds a layer, in place of the layer of the same source when there is one.
+func (l *Loader) replace(priority int, source string, values map[string]interface{}, origin func(key string) string) {
+	ly := layer{priority: priority, source: source, values: make(map[string]interface{}), origins: make(map[string]string)}
+	for key, value := range values {
+		key = strings.ToLower(key)
+		ly.values[key] = value
+		ly.origins[key] = origin(key)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.layers {
+		if source != "" && l.layers[i].source == source {
+			l.layers[i] = ly
+			return
+		}
+	}
+	l.layers = append(l.layers, ly)
+}
+
+func (l *Loader) Defaults(values map[string]interface{}) *Loader {
+	l.add(PriorityDefaults, flatten("", values), func(string) string { return "default" })
+	return l
+}
+
+// File loads a YAML or JSON file, chosen by its extension.
+func (l *Loader) File(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("unsupported config file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+	l.add(PriorityFile, flatten("", values), func(string) string { return "file:" + path })
+	return nil
+}
+
+// Consul loads the JSON document stored at kvPath. The document mirrors the full key tree, e.g. {"app": {"secret": "..."}}.
+func (l *Loader) Consul(c provider.ConsulProvider, kvPath string) error {
+	pair, _, err := c.GetClient().KV().Get(kvPath, nil)
+	if err != nil {
+		return err
+	}
+	values, err := decodeConsulPair(pair)
+	if err != nil {
+		return err
+	}
+	l.SetConsul(kvPath, values)
+	return nil
+}
+
+// SetConsul replaces the values of the document at kvPath, e.g. with the version a Watcher received.
+func (l *Loader) SetConsul(kvPath string, values map[string]interface{}) {
+	source := "consul:" + kvPath
+	l.replace(PriorityConsul, source, flatten("", values), func(string) string { return source })
+}
+
+// Env loads variables starting with prefix, mapping ZARD_APP_AUTH_OTPTTL to app.auth.otpttl.
+// Keys are case-insensitive, so it overrides app.auth.otpTTL.
+func (l *Loader) Env(prefix string, environ []string) *Loader {
+	values := make(map[string]interface{})
+	names := make(map[string]string)
+	for _, kv := range environ {
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) != 2 || !strings.HasPrefix(pair[0], prefix) {
+			continue
+		}
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(pair[0], prefix), "_", "."))
+		values[key] = utils.Utils.Strings.Parse(pair[1])
+		names[key] = pair[0]
+	}
+	l.add(PriorityEnv, values, func(key string) string { return "env:" + names[key] })
+	return l
+}
+
+// Flags loads --key=value and --key value arguments, e.g. --app.auth.otpTTL=5m.
+func (l *Loader) Flags(args []string) error {
+	values := make(map[string]interface{})
+	names := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			return fmt.Errorf("unexpected argument %q", arg)
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !ok {
+			if i+1 >= len(args) || strings.HasPrefix(args[i+1], "--") {
+				value = "true"
+			} else {
+				i++
+				value = args[i]
+			}
+		}
+		values[key] = utils.Utils.Strings.Parse(value)
+		names[strings.ToLower(key)] = key
+	}
+	l.add(PriorityFlags, values, func(key string) string { return "flag:--" + names[key] })
+	return nil
+}
+
+// Load merges every layer by priority and applies the result to conf.
+func (l *Loader) Load(conf Config) {
+	l.Reload(conf)
+}
+
+// Reload merges every layer again, applies the keys whose merged value changed to conf, and
+// returns them. Keys no layer sets anymore are unset and returned with a nil value.
+func (l *Loader) Reload(conf Config) map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	layers := make([]layer, len(l.layers))
+	copy(layers, l.layers)
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].priority < layers[j].priority })
+	values := make(map[string]interface{})
+	l.origin = make(map[string]string)
+	for _, ly := range layers {
+		for key, value := range ly.values {
+			values[key] = value
+			l.origin[key] = ly.origins[key]
+		}
+	}
+	changed := make(map[string]interface{})
+	for key, value := range values {
+		if old, ok := l.values[key]; !ok || !reflect.DeepEqual(old, value) {
+			changed[key] = value
+		}
+	}
+	for key := range l.values {
+		if _, ok := values[key]; !ok {
+			changed[key] = nil
+		}
+	}
+	l.values = values
+	conf.SetAll(changed)
+	return changed
+}
+
+// Source returns where the loaded value of key came from, or an empty string when it is not set.
+func (l *Loader) Source(key string) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.origin[strings.ToLower(key)]
+}
+
+// Dump returns every loaded value with its source. Secrets and URL passwords are redacted.
+func (l *Loader) Dump() map[string]DumpEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	dump := make(map[string]DumpEntry, len(l.values))
+	for key, value := range l.values {
+		dump[key] = DumpEntry{Value: redactValue(key, value), Source: l.origin[key]}
+	}
+	return dump
+}
+
+// DumpHandler serves the redacted dump as JSON, for debugging endpoints.
+func (l *Loader) DumpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(l.Dump())
+	})
+}
+
+func redactValue(key string, value interface{}) interface{} {
+	if redact.IsSensitiveKey(key) {
+		return redact.Mask
+	}
+	if s, ok := value.(string); ok && strings.Contains(s, "://") {
+		if u, err := url.Parse(s); err == nil {
+			return u.Redacted()
+		}
+	}
+	return value
+}
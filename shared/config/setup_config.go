@@ -2,39 +2,36 @@ package config
 
 import (
 	"encoding/json"
-	"github.com/abdelrahman146/zard/shared"
 	"github.com/abdelrahman146/zard/shared/provider"
+	capi "github.com/hashicorp/consul/api"
 	"github.com/joho/godotenv"
 	"os"
-	"strings"
 )
 
-func GetEnvConfig(conf Config) {
+// GetEnvConfig adds the ZARD_* environment variables, and those of a .env file, to loader and
+// reloads it into conf, e.g. ZARD_APP_SECRET sets app.secret.
+func GetEnvConfig(loader *Loader, conf Config) {
 	_ = godotenv.Load()
-	envs := make(map[string]interface{})
-	prefix := "ZARD_"
-	for _, key := range os.Environ() {
-		if strings.HasPrefix(key, prefix) {
-			pair := strings.SplitN(key, "=", 2)
-			pair[0] = strings.TrimPrefix(pair[0], prefix)
-			envs[pair[0]] = shared.Utils.Strings.Parse(pair[1])
-		}
-	}
-	setBulk(conf, "env", envs)
+	loader.Env("ZARD_", os.Environ()).Load(conf)
 }
 
-func GetConsulConfig(c provider.ConsulProvider, kvPath string, conf Config) error {
-	client := c.GetClient()
-	kv := client.KV()
-	pair, _, err := kv.Get(kvPath, nil)
-	if err != nil {
-		return err
-	}
-	var configData map[string]interface{}
-	err = json.Unmarshal(pair.Value, &configData)
-	if err != nil {
+// GetConsulConfig adds the document at kvPath to loader and reloads it into conf. Environment
+// variables and flags of loader keep overriding it, see Loader.
+func GetConsulConfig(c provider.ConsulProvider, kvPath string, loader *Loader, conf Config) error {
+	if err := loader.Consul(c, kvPath); err != nil {
 		return err
 	}
-	setBulk(conf, "consul", configData)
+	loader.Load(conf)
 	return nil
 }
+
+func decodeConsulPair(pair *capi.KVPair) (map[string]interface{}, error) {
+	configData := make(map[string]interface{})
+	if pair == nil || len(pair.Value) == 0 {
+		return configData, nil
+	}
+	if err := json.Unmarshal(pair.Value, &configData); err != nil {
+		return nil, err
+	}
+	return configData, nil
+}
@@ -0,0 +1,48 @@
+package config
+
+// Sub returns a view of the keys of conf under prefix, so that Sub(conf, "app.auth").GetInt("otpTTL")
+// reads app.auth.otpTTL. Writes go to conf under the same prefix.
+func Sub(conf Config, prefix string) Config {
+	return &subConfig{conf: conf, prefix: prefix + "."}
+}
+
+type subConfig struct {
+	conf   Config
+	prefix string
+}
+
+func (s *subConfig) Get(key string) interface{} {
+	return s.conf.Get(s.prefix + key)
+}
+
+func (s *subConfig) IsSet(key string) bool {
+	return s.conf.IsSet(s.prefix + key)
+}
+
+func (s *subConfig) GetString(key string) string {
+	return s.conf.GetString(s.prefix + key)
+}
+
+func (s *subConfig) GetInt(key string) int {
+	return s.conf.GetInt(s.prefix + key)
+}
+
+func (s *subConfig) GetFloat(key string) float64 {
+	return s.conf.GetFloat(s.prefix + key)
+}
+
+func (s *subConfig) GetBool(key string) bool {
+	return s.conf.GetBool(s.prefix + key)
+}
+
+func (s *subConfig) Set(key string, value interface{}) {
+	s.conf.Set(s.prefix+key, value)
+}
+
+func (s *subConfig) SetAll(values map[string]interface{}) {
+	prefixed := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		prefixed[s.prefix+key] = value
+	}
+	s.conf.SetAll(prefixed)
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/abdelrahman146/zard/shared/provider"
+	capi "github.com/hashicorp/consul/api"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Watcher keeps a Config in sync with a remote source and notifies
+// components when the values they depend on change.
+type Watcher interface {
+	Start()
+	Stop()
+	// OnChange registers callback for changes to keys starting with keyPrefix. Keys are reported
+	// in lower case, and deleted keys with a nil value.
+	OnChange(keyPrefix string, callback func(changed map[string]interface{}))
+}
+
+type ConsulWatcherConfig struct {
+	WaitTime   time.Duration // how long each blocking query waits for a change
+	RetryAfter time.Duration // delay before querying again after an error
+}
+
+type consulWatcher struct {
+	client    *capi.Client
+	kvPath    string
+	loader    *Loader
+	conf      Config
+	config    ConsulWatcherConfig
+	mu        sync.Mutex
+	callbacks []watchCallback
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+type watchCallback struct {
+	prefix string
+	fn     func(changed map[string]interface{})
+}
+
+// NewConsulWatcher watches kvPath using Consul blocking queries. Every version replaces the Consul
+// layer of loader, which is reloaded into conf, so environment variables and flags keep overriding it.
+// Callbacks are notified of the keys whose merged value changed.
+func NewConsulWatcher(c provider.ConsulProvider, kvPath string, loader *Loader, conf Config, config ConsulWatcherConfig) Watcher {
+	if config.WaitTime <= 0 {
+		config.WaitTime = 5 * time.Minute
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = 5 * time.Second
+	}
+	return &consulWatcher{
+		client: c.GetClient(),
+		kvPath: kvPath,
+		loader: loader,
+		conf:   conf,
+		config: config,
+	}
+}
+
+func (w *consulWatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	go w.run(ctx)
+}
+
+func (w *consulWatcher) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+	w.cancel = nil
+}
+
+func (w *consulWatcher) OnChange(keyPrefix string, callback func(changed map[string]interface{})) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, watchCallback{prefix: keyPrefix, fn: callback})
+}
+
+func (w *consulWatcher) run(ctx context.Context) {
+	defer close(w.done)
+	kv := w.client.KV()
+	var index uint64
+	for {
+		opts := (&capi.QueryOptions{WaitIndex: index, WaitTime: w.config.WaitTime}).WithContext(ctx)
+		pair, meta, err := kv.Get(w.kvPath, opts)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.GetLogger().Named("config").Warn("failed to watch consul config", logger.Field("path", w.kvPath), logger.Field("error", err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.config.RetryAfter):
+			}
+			continue
+		}
+		// the index can go backwards when the key is recreated, start over in that case
+		if meta.LastIndex < index {
+			index = 0
+			continue
+		}
+		if meta.LastIndex == index {
+			continue
+		}
+		index = meta.LastIndex
+		if err = w.apply(pair); err != nil {
+			logger.GetLogger().Named("config").Warn("failed to apply consul config", logger.Field("path", w.kvPath), logger.Field("error", err))
+		}
+	}
+}
+
+func (w *consulWatcher) apply(pair *capi.KVPair) error {
+	configData, err := decodeConsulPair(pair)
+	if err != nil {
+		return err
+	}
+	w.loader.SetConsul(w.kvPath, configData)
+	changed := w.loader.Reload(w.conf)
+
+	w.mu.Lock()
+	callbacks := w.callbacks
+	w.mu.Unlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, cb := range callbacks {
+		matched := make(map[string]interface{})
+		for key, value := range changed {
+			if strings.HasPrefix(key, strings.ToLower(cb.prefix)) {
+				matched[key] = value
+			}
+		}
+		if len(matched) > 0 {
+			cb.fn(matched)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/provider"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConsul serves a single KV key and honours blocking queries on it.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	value   []byte
+	changed chan struct{}
+}
+
+func newFakeConsul(value map[string]interface{}) *fakeConsul {
+	f := &fakeConsul{changed: make(chan struct{})}
+	f.put(value)
+	return f
+}
+
+func (f *fakeConsul) put(value map[string]interface{}) {
+	data, _ := json.Marshal(value)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index++
+	f.value = data
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	f.mu.Lock()
+	if index != 0 && index == f.index {
+		changed := f.changed
+		f.mu.Unlock()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-time.After(time.Second):
+		}
+		f.mu.Lock()
+	}
+	defer f.mu.Unlock()
+	w.Header().Set("X-Consul-Index", fmt.Sprint(f.index))
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprintf(w, `[{"Key":"app","Value":%q,"CreateIndex":1,"ModifyIndex":%d}]`,
+		base64.StdEncoding.EncodeToString(f.value), f.index)
+}
+
+func TestConsulWatcher(t *testing.T) {
+	consul := newFakeConsul(map[string]interface{}{"app": map[string]interface{}{
+		"auth":    map[string]interface{}{"otpTTL": 60, "tokenTTL": 3600},
+		"feature": map[string]interface{}{"signup": true},
+	}})
+	srv := httptest.NewServer(consul)
+	defer srv.Close()
+
+	conf := NewViperConfig()
+	loader := NewLoader().Env("ZARD_", []string{"ZARD_APP_AUTH_TOKENTTL=7200"})
+	loader.Load(conf)
+	w := NewConsulWatcher(provider.InitConsulProvider(srv.URL), "app", loader, conf, ConsulWatcherConfig{WaitTime: time.Second})
+	changes := make(chan map[string]interface{}, 10)
+	w.OnChange("app.auth", func(changed map[string]interface{}) {
+		changes <- changed
+	})
+	w.Start()
+	defer w.Stop()
+
+	assert.Equal(t, map[string]interface{}{"app.auth.otpttl": float64(60)}, <-changes)
+	assert.True(t, conf.GetBool("app.feature.signup"))
+	assert.Equal(t, 7200, conf.GetInt("app.auth.tokenTTL"), "the environment overrides consul")
+
+	consul.put(map[string]interface{}{"app": map[string]interface{}{
+		"auth": map[string]interface{}{"otpTTL": 120, "tokenTTL": 60},
+	}})
+	select {
+	case changed := <-changes:
+		assert.Equal(t, map[string]interface{}{"app.auth.otpttl": float64(120)}, changed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("change was not delivered")
+	}
+	assert.Equal(t, 120, conf.GetInt("app.auth.otpTTL"))
+	assert.Equal(t, "consul:app", loader.Source("app.auth.otpTTL"))
+	assert.Equal(t, 7200, conf.GetInt("app.auth.tokenTTL"), "the environment still overrides consul")
+	assert.False(t, conf.IsSet("app.feature.signup"))
+}
+
+func TestConsulWatcher_IgnoresUnrelatedChanges(t *testing.T) {
+	consul := newFakeConsul(map[string]interface{}{"app": map[string]interface{}{"feature": map[string]interface{}{"signup": true}}})
+	srv := httptest.NewServer(consul)
+	defer srv.Close()
+
+	conf := NewViperConfig()
+	w := NewConsulWatcher(provider.InitConsulProvider(srv.URL), "app", NewLoader(), conf, ConsulWatcherConfig{WaitTime: time.Second})
+	calls := 0
+	w.OnChange("app.auth", func(changed map[string]interface{}) { calls++ })
+	w.Start()
+	assert.Eventually(t, func() bool { return conf.GetBool("app.feature.signup") }, time.Second, 5*time.Millisecond)
+	consul.put(map[string]interface{}{"app": map[string]interface{}{"feature": map[string]interface{}{"signup": false}}})
+	assert.Eventually(t, func() bool { return !conf.GetBool("app.feature.signup") }, 2*time.Second, 5*time.Millisecond)
+	w.Stop()
+	assert.Equal(t, 0, calls)
+}
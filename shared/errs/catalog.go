@@ -0,0 +1,209 @@
+package errs
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/text/language"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Entry documents an error code clients can rely on.
+type Entry struct {
+	Code     string `json:"code"`
+	Kind     Kind   `json:"kind"`     // errors.Is(err, Kind) matches errors of this code, defaults to Kind(Code)
+	HttpCode int    `json:"httpCode"` // defaults to the kind's
+	Message  string `json:"message"`  // default message, in English
+	Key      string `json:"key"`      // i18n key of the message, defaults to errors.<code in lower case>
+}
+
+// New creates an error of this code, wrapping err.
+func (e Entry) New(err error) CustomError {
+	return CustomError{
+		s:        e.Message,
+		Code:     e.Code,
+		Desc:     e.Kind.Desc(),
+		HttpCode: e.HttpCode,
+		Original: err,
+		stack:    callers(e.HttpCode, 1),
+	}
+}
+
+// Catalog registers error codes with their localized messages.
+type Catalog interface {
+	// Register adds an entry and returns it with its defaults filled in. It panics when the code is
+	// already registered, so codes stay unique across packages.
+	Register(entry Entry) Entry
+	Lookup(code string) (Entry, bool)
+	// Entries returns every entry sorted by code.
+	Entries() []Entry
+	// AddTranslations adds messages by i18n key for a BCP 47 language, e.g. "ar" or "fr-CA".
+	AddTranslations(lang string, messages map[string]string) error
+	// LoadTranslations reads <lang>.json files holding messages by i18n key from the root of fsys.
+	LoadTranslations(fsys fs.FS) error
+	// Localize returns the message of code in the language best matching an Accept-Language
+	// header, falling back to the default message. It returns "" for unknown codes.
+	Localize(code, acceptLanguage string) string
+	// WriteListing writes the entries as a markdown table or, with format "json", a JSON array.
+	WriteListing(w io.Writer, format string) error
+}
+
+// DefaultCatalog holds the generic kinds and the codes registered with Register.
+var DefaultCatalog = NewCatalog()
+
+func init() {
+	for kind := range kinds {
+		DefaultCatalog.Register(Entry{Code: string(kind), Message: kind.Desc()})
+	}
+}
+
+// Register adds an entry to DefaultCatalog, see Catalog.Register.
+func Register(entry Entry) Entry {
+	return DefaultCatalog.Register(entry)
+}
+
+type catalog struct {
+	mu           sync.RWMutex
+	entries      map[string]Entry
+	translations map[language.Tag]map[string]string
+	tags         []language.Tag // tags[0] stands for the default messages
+	matcher      language.Matcher
+}
+
+func NewCatalog() Catalog {
+	c := &catalog{
+		entries:      make(map[string]Entry),
+		translations: make(map[language.Tag]map[string]string),
+		tags:         []language.Tag{language.English},
+	}
+	c.matcher = language.NewMatcher(c.tags)
+	return c
+}
+
+func (c *catalog) Register(entry Entry) Entry {
+	if entry.Code == "" {
+		panic("errs: registering an error without code")
+	}
+	if entry.Kind == "" {
+		entry.Kind = Kind(entry.Code)
+	}
+	if entry.HttpCode == 0 {
+		entry.HttpCode = entry.Kind.HttpCode()
+	}
+	if entry.Key == "" {
+		entry.Key = "errors." + strings.ToLower(entry.Code)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[entry.Code]; ok {
+		panic("errs: error code " + entry.Code + " registered twice")
+	}
+	c.entries[entry.Code] = entry
+	return entry
+}
+
+func (c *catalog) Lookup(code string) (Entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[code]
+	return entry, ok
+}
+
+func (c *catalog) Entries() []Entry {
+	c.mu.RLock()
+	entries := make([]Entry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	c.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+	return entries
+}
+
+func (c *catalog) AddTranslations(lang string, messages map[string]string) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return fmt.Errorf("invalid language %q: %w", lang, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	translations, ok := c.translations[tag]
+	if !ok {
+		translations = make(map[string]string, len(messages))
+		c.translations[tag] = translations
+		c.tags = append(c.tags, tag)
+		c.matcher = language.NewMatcher(c.tags)
+	}
+	for key, message := range messages {
+		translations[key] = message
+	}
+	return nil
+}
+
+func (c *catalog) LoadTranslations(fsys fs.FS) error {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("invalid translations in %s: %w", file, err)
+		}
+		if err := c.AddTranslations(strings.TrimSuffix(path.Base(file), ".json"), messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *catalog) Localize(code, acceptLanguage string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[code]
+	if !ok {
+		return ""
+	}
+	preferred, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(preferred) == 0 {
+		return entry.Message
+	}
+	_, index, confidence := c.matcher.Match(preferred...)
+	if confidence == language.No || index == 0 {
+		return entry.Message
+	}
+	if message, ok := c.translations[c.tags[index]][entry.Key]; ok {
+		return message
+	}
+	return entry.Message
+}
+
+func (c *catalog) WriteListing(w io.Writer, format string) error {
+	entries := c.Entries()
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	case "", "markdown":
+		if _, err := fmt.Fprint(w, "| Code | HTTP status | Kind | Message | i18n key |\n| --- | --- | --- | --- | --- |\n"); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			message := strings.ReplaceAll(e.Message, "|", `\|`)
+			if _, err := fmt.Fprintf(w, "| `%s` | %d | `%s` | %s | `%s` |\n", e.Code, e.HttpCode, string(e.Kind), message, e.Key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported listing format %q", format)
+}
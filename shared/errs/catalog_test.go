@@ -0,0 +1,76 @@
+package errs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCatalog_RegisterDefaults(t *testing.T) {
+	c := NewCatalog()
+	entry := c.Register(Entry{Code: "EMAIL_TAKEN", Kind: ErrConflict, Message: "Email already exists"})
+	assert.Equal(t, Entry{Code: "EMAIL_TAKEN", Kind: ErrConflict, HttpCode: 409, Message: "Email already exists", Key: "errors.email_taken"}, entry)
+	found, ok := c.Lookup("EMAIL_TAKEN")
+	assert.True(t, ok)
+	assert.Equal(t, entry, found)
+	assert.Panics(t, func() { c.Register(Entry{Code: "EMAIL_TAKEN"}) })
+}
+
+func TestDefaultCatalog_HasKinds(t *testing.T) {
+	entry, ok := DefaultCatalog.Lookup(string(ErrUnprocessableEntity))
+	require.True(t, ok)
+	assert.Equal(t, 422, entry.HttpCode)
+	assert.Equal(t, ErrUnprocessableEntity, entry.Kind)
+}
+
+func TestEntry_NewMatchesKind(t *testing.T) {
+	entry := Register(Entry{Code: "TEST_ORDER_LOCKED", Kind: ErrConflict, Message: "Order is locked"})
+	err := Annotate(entry.New(errors.New("locked by job")), "unable to update order")
+	assert.ErrorIs(t, err, ErrConflict)
+	customErr := HandleError(err)
+	assert.Equal(t, "TEST_ORDER_LOCKED", customErr.Code)
+	assert.Equal(t, 409, customErr.HttpCode)
+	assert.Equal(t, "unable to update order", customErr.Message())
+}
+
+func TestCatalog_Localize(t *testing.T) {
+	c := NewCatalog()
+	c.Register(Entry{Code: "EMAIL_TAKEN", Kind: ErrConflict, Message: "Email already exists"})
+	c.Register(Entry{Code: "PHONE_TAKEN", Kind: ErrConflict, Message: "Phone already exists"})
+	require.NoError(t, c.LoadTranslations(fstest.MapFS{
+		"ar.json": {Data: []byte(`{"errors.email_taken": "البريد الإلكتروني مستخدم بالفعل"}`)},
+		"fr.json": {Data: []byte(`{"errors.email_taken": "L'adresse e-mail existe déjà"}`)},
+	}))
+	assert.Equal(t, "البريد الإلكتروني مستخدم بالفعل", c.Localize("EMAIL_TAKEN", "ar-EG,ar;q=0.9,en;q=0.8"))
+	assert.Equal(t, "L'adresse e-mail existe déjà", c.Localize("EMAIL_TAKEN", "de;q=0.9, fr;q=0.8"))
+	assert.Equal(t, "Email already exists", c.Localize("EMAIL_TAKEN", "en-US"))
+	assert.Equal(t, "Email already exists", c.Localize("EMAIL_TAKEN", ""))
+	assert.Equal(t, "Email already exists", c.Localize("EMAIL_TAKEN", "ja"))
+	assert.Equal(t, "Phone already exists", c.Localize("PHONE_TAKEN", "ar"), "missing translations fall back to the default message")
+	assert.Empty(t, c.Localize("UNKNOWN_CODE", "ar"))
+	assert.Error(t, c.LoadTranslations(fstest.MapFS{"fr.json": {Data: []byte(`[]`)}}))
+}
+
+func TestCatalog_WriteListing(t *testing.T) {
+	c := NewCatalog()
+	c.Register(Entry{Code: "PHONE_TAKEN", Kind: ErrConflict, Message: "Phone | already exists"})
+	c.Register(Entry{Code: "EMAIL_TAKEN", Kind: ErrConflict, Message: "Email already exists"})
+
+	var markdown bytes.Buffer
+	require.NoError(t, c.WriteListing(&markdown, "markdown"))
+	assert.Equal(t, "| Code | HTTP status | Kind | Message | i18n key |\n| --- | --- | --- | --- | --- |\n"+
+		"| `EMAIL_TAKEN` | 409 | `CONFLICT` | Email already exists | `errors.email_taken` |\n"+
+		"| `PHONE_TAKEN` | 409 | `CONFLICT` | Phone \\| already exists | `errors.phone_taken` |\n", markdown.String())
+
+	var listing bytes.Buffer
+	require.NoError(t, c.WriteListing(&listing, "json"))
+	var entries []Entry
+	require.NoError(t, json.Unmarshal(listing.Bytes(), &entries))
+	assert.Equal(t, c.Entries(), entries)
+
+	assert.Error(t, c.WriteListing(&listing, "yaml"))
+}
@@ -0,0 +1,100 @@
+// Package dberr translates GORM and Postgres errors into errs kinds, so callers
+// can tell a missing record or a duplicate key from a database failure.
+package dberr
+
+import (
+	"context"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// Postgres SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	UniqueViolation      = "23505"
+	ForeignKeyViolation  = "23503"
+	NotNullViolation     = "23502"
+	CheckViolation       = "23514"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+	LockNotAvailable     = "55P03"
+	QueryCanceled        = "57014"
+)
+
+// Translate returns err as an errs.CustomError of the kind matching its cause:
+//   - gorm.ErrRecordNotFound is ErrNotFound
+//   - unique violations are ErrConflict, naming the constraint
+//   - foreign key violations are ErrUnprocessableEntity, or ErrConflict when deleting a referenced row
+//   - not null and check violations, and invalid data, are ErrBadRequest
+//   - serialization failures and deadlocks are ErrConflict, the transaction can be retried
+//   - statement and lock timeouts, and expired contexts, are ErrTimeout
+//   - connection failures are ErrServiceUnavailable
+//
+// Anything else is ErrInternal. Errors that already have a kind are returned unchanged.
+func Translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := errs.As(err); ok {
+		return err
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return translatePgError(pgErr, err)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errs.NewNotFoundError("record not found", err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errs.NewConflictError("record already exists", err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return errs.NewUnprocessableEntityError("referenced record does not exist", err)
+	case errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err):
+		return errs.NewTimeoutError("database query timed out", err)
+	case pgconn.SafeToRetry(err):
+		return errs.NewServiceUnavailableError("database is unavailable", err)
+	}
+	return errs.NewInternalError("database query failed", err)
+}
+
+func translatePgError(pgErr *pgconn.PgError, err error) error {
+	switch pgErr.Code {
+	case UniqueViolation:
+		return errs.NewConflictError("record already exists ("+pgErr.ConstraintName+")", err)
+	case ForeignKeyViolation:
+		// Postgres reports deletes of referenced rows as "update or delete on table ..."
+		if strings.HasPrefix(pgErr.Message, "update or delete") {
+			return errs.NewConflictError("record is still referenced ("+pgErr.ConstraintName+")", err)
+		}
+		return errs.NewUnprocessableEntityError("referenced record does not exist ("+pgErr.ConstraintName+")", err)
+	case NotNullViolation:
+		return errs.NewBadRequestError("missing value for "+pgErr.ColumnName, err)
+	case CheckViolation:
+		return errs.NewBadRequestError("invalid value ("+pgErr.ConstraintName+")", err)
+	case SerializationFailure, DeadlockDetected:
+		return errs.NewConflictError("concurrent update, try again", err)
+	case LockNotAvailable, QueryCanceled:
+		return errs.NewTimeoutError("database query timed out", err)
+	}
+	if len(pgErr.Code) < 2 {
+		return errs.NewInternalError("database query failed", err)
+	}
+	switch pgErr.Code[:2] {
+	case "22": // data exception
+		return errs.NewBadRequestError("invalid value", err)
+	case "08", "53", "57": // connection exception, insufficient resources, operator intervention
+		return errs.NewServiceUnavailableError("database is unavailable", err)
+	}
+	return errs.NewInternalError("database query failed", err)
+}
+
+// Constraint returns the name of the constraint err violated, or "" when err is not a constraint violation.
+func Constraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package dberr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind errs.Kind
+	}{
+		{"record not found", gorm.ErrRecordNotFound, errs.ErrNotFound},
+		{"duplicated key", gorm.ErrDuplicatedKey, errs.ErrConflict},
+		{"unique violation", &pgconn.PgError{Code: UniqueViolation, ConstraintName: "users_email_key"}, errs.ErrConflict},
+		{"foreign key on insert", &pgconn.PgError{Code: ForeignKeyViolation, Message: `insert or update on table "users" violates foreign key constraint "fk_users_org"`}, errs.ErrUnprocessableEntity},
+		{"foreign key on delete", &pgconn.PgError{Code: ForeignKeyViolation, Message: `update or delete on table "organizations" violates foreign key constraint "fk_users_org" on table "users"`}, errs.ErrConflict},
+		{"not null violation", &pgconn.PgError{Code: NotNullViolation, ColumnName: "email"}, errs.ErrBadRequest},
+		{"invalid text", &pgconn.PgError{Code: "22P02"}, errs.ErrBadRequest},
+		{"serialization failure", &pgconn.PgError{Code: SerializationFailure}, errs.ErrConflict},
+		{"deadlock", &pgconn.PgError{Code: DeadlockDetected}, errs.ErrConflict},
+		{"statement timeout", &pgconn.PgError{Code: QueryCanceled}, errs.ErrTimeout},
+		{"context deadline", context.DeadlineExceeded, errs.ErrTimeout},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, errs.ErrServiceUnavailable},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, errs.ErrInternal},
+		{"unknown", errors.New("boom"), errs.ErrInternal},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Translate(fmt.Errorf("query: %w", test.err))
+			assert.ErrorIs(t, err, test.kind)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestTranslate_UniqueViolationNamesConstraint(t *testing.T) {
+	err := Translate(&pgconn.PgError{Code: UniqueViolation, ConstraintName: "users_email_key"})
+	assert.Contains(t, err.Error(), "users_email_key")
+	assert.Equal(t, "users_email_key", Constraint(err))
+	assert.Equal(t, 409, errs.HandleError(err).HttpCode)
+}
+
+func TestTranslate_KeepsKindsAndNil(t *testing.T) {
+	assert.NoError(t, Translate(nil))
+	err := errs.NewForbiddenError("no access", nil)
+	assert.Equal(t, err, Translate(err))
+}
+
+func TestAnnotateKeepsTranslatedKind(t *testing.T) {
+	err := errs.Annotate(Translate(gorm.ErrRecordNotFound), "user not found")
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	assert.Equal(t, "user not found", errs.HandleError(err).Message())
+	assert.ErrorIs(t, errs.Annotate(errors.New("boom"), "failed"), errs.ErrInternal)
+	assert.NoError(t, errs.Annotate(nil, "failed"))
+}
@@ -1,5 +1,7 @@
 package errs
 
+// CustomError is the error every layer returns so its kind, fields and cause reach the caller.
+// It is used as a value; HandleError also finds it when a *CustomError is in the chain.
 type CustomError struct {
 	s        string
 	Desc     string            `json:"desc"`
@@ -7,8 +9,89 @@ type CustomError struct {
 	HttpCode int               `json:"httpCode"`
 	Original error             `json:"error,omitempty"`
 	Fields   map[string]string `json:"fields,omitempty"`
+	stack    []uintptr
 }
 
+// Error returns the message followed by the message of the error it wraps, if any.
 func (e CustomError) Error() string {
+	if e.Original != nil {
+		return e.s + ": " + e.Original.Error()
+	}
 	return e.s
 }
+
+// Message returns the message without the wrapped error.
+func (e CustomError) Message() string {
+	return e.s
+}
+
+func (e CustomError) Unwrap() error {
+	return e.Original
+}
+
+// Is reports whether e is of kind target, so errors.Is(err, errs.ErrNotFound) works anywhere in a chain.
+func (e CustomError) Is(target error) bool {
+	kind, ok := target.(Kind)
+	return ok && (Kind(e.Code) == kind || e.Kind() == kind)
+}
+
+// Kind returns the kind of e's code, which is the code itself unless DefaultCatalog files it under another kind.
+func (e CustomError) Kind() Kind {
+	if entry, ok := DefaultCatalog.Lookup(e.Code); ok {
+		return entry.Kind
+	}
+	return Kind(e.Code)
+}
+
+func (e CustomError) customError() CustomError {
+	return e
+}
+
+// NewCustomError creates an error of an arbitrary kind, e.g. one decoded from another service's reply.
+func NewCustomError(text, code, desc string, httpCode int, fields map[string]string) CustomError {
+	return CustomError{
+		s:        text,
+		Code:     code,
+		Desc:     desc,
+		HttpCode: httpCode,
+		Fields:   fields,
+	}
+}
+
+// Wrap annotates err with a kind and a message. It returns nil when err is nil.
+func Wrap(err error, kind Kind, text string) error {
+	if err == nil {
+		return nil
+	}
+	return newError(kind, text, err)
+}
+
+// Annotate adds a message to err and keeps the code and fields of the first CustomError in its
+// chain, so a not found error from a repo stays a not found error. Errors of no kind become
+// internal errors. It returns nil when err is nil.
+func Annotate(err error, text string) error {
+	if err == nil {
+		return nil
+	}
+	customErr, ok := As(err)
+	if !ok {
+		return newError(ErrInternal, text, err)
+	}
+	// the stack of the annotated error points at its origin, which is where it is worth looking
+	annotated := customErr
+	annotated.s, annotated.Original = text, err
+	return annotated
+}
+
+// AnnotateKind annotates err like Annotate when it is of kind, and returns it unchanged otherwise,
+// so "user not found" does not describe a database outage and "failed to create user" does not
+// hide which record already exists. Errors of no kind are internal errors.
+func AnnotateKind(err error, kind Kind, text string) error {
+	if err == nil {
+		return nil
+	}
+	if customErr, ok := As(err); ok && customErr.Kind() != kind || !ok && kind != ErrInternal {
+		return err
+	}
+	return Annotate(err, text)
+}
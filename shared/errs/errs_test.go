@@ -0,0 +1,100 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Wrap(cause, ErrServiceUnavailable, "unable to reach the cache")
+	assert.Equal(t, "unable to reach the cache: connection refused", err.Error())
+	assert.ErrorIs(t, err, cause)
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, Wrap(nil, ErrInternal, "nothing happened"))
+
+	customErr := HandleError(err)
+	assert.Equal(t, 503, customErr.HttpCode)
+	assert.Equal(t, "unable to reach the cache", customErr.Message())
+	assert.Equal(t, ErrServiceUnavailable, customErr.Kind())
+}
+
+func TestHandleError_FindsCustomErrorInChain(t *testing.T) {
+	notFound := NewNotFoundError("user not found", nil)
+	for name, err := range map[string]error{
+		"value":   fmt.Errorf("loading profile: %w", notFound),
+		"pointer": fmt.Errorf("loading profile: %w", &notFound),
+		"kind":    fmt.Errorf("loading profile: %w", ErrNotFound),
+		"joined":  errors.Join(errors.New("cache miss"), notFound),
+	} {
+		customErr := HandleError(err)
+		assert.Equal(t, 404, customErr.HttpCode, name)
+		assert.Equal(t, "NOT_FOUND", customErr.Code, name)
+		assert.ErrorIs(t, err, ErrNotFound, name)
+	}
+}
+
+func TestHandleError_Unknown(t *testing.T) {
+	cause := errors.New("boom")
+	customErr := HandleError(cause)
+	assert.Equal(t, 520, customErr.HttpCode)
+	assert.Equal(t, cause, customErr.Original)
+
+	var nilPointer *CustomError
+	assert.Equal(t, ErrUnknown, HandleError(fmt.Errorf("wrapped: %w", nilPointer)).Kind())
+}
+
+func TestConstructors(t *testing.T) {
+	assert.Equal(t, 422, NewUnprocessableEntityError("invalid state", nil).HttpCode)
+	assert.Equal(t, "UNPROCESSABLE_ENTITY", NewUnprocessableEntityError("invalid state", nil).Code)
+	validation := NewValidationError("invalid request", map[string]string{"email": "is required"})
+	assert.ErrorIs(t, validation, ErrValidation)
+	assert.Equal(t, map[string]string{"email": "is required"}, validation.Fields)
+}
+
+func TestAnnotateKind(t *testing.T) {
+	notFound := HandleError(AnnotateKind(NewNotFoundError("record not found", nil), ErrNotFound, "user not found"))
+	assert.Equal(t, "user not found", notFound.Message())
+
+	outage := HandleError(AnnotateKind(NewServiceUnavailableError("database is unavailable", nil), ErrNotFound, "user not found"))
+	assert.Equal(t, "database is unavailable", outage.Message())
+	assert.Equal(t, 503, outage.HttpCode)
+
+	conflict := HandleError(AnnotateKind(NewConflictError("record already exists (users_email_key)", nil), ErrInternal, "failed to create user"))
+	assert.Equal(t, "record already exists (users_email_key)", conflict.Message())
+
+	internal := HandleError(AnnotateKind(errors.New("boom"), ErrInternal, "failed to create user"))
+	assert.Equal(t, "failed to create user", internal.Message())
+	assert.NoError(t, AnnotateKind(nil, ErrNotFound, "user not found"))
+}
+
+func newRepoError() error {
+	return NewInternalError("unable to query users", errors.New("connection reset"))
+}
+
+func TestStackTrace_ServerErrors(t *testing.T) {
+	err := HandleError(Annotate(newRepoError(), "unable to list users"))
+	stack := err.StackTrace()
+	require.NotEmpty(t, stack)
+	assert.Equal(t, "github.com/abdelrahman146/zard/shared/errs.newRepoError", stack[0].Function)
+	assert.Contains(t, err.Stack(), "errs_test.go")
+	assert.Equal(t, "unable to list users", err.Message())
+
+	assert.Empty(t, NewNotFoundError("user not found", nil).StackTrace(), "client errors have no stack trace")
+	assert.Empty(t, HandleError(errors.New("boom")).StackTrace())
+}
+
+func TestFingerprint(t *testing.T) {
+	first := HandleError(newRepoError()).Fingerprint()
+	assert.Len(t, first, 32)
+	assert.Equal(t, first, HandleError(newRepoError()).Fingerprint(), "the same origin has the same fingerprint")
+	assert.Equal(t, first, HandleError(Annotate(newRepoError(), "unable to list users")).Fingerprint())
+	assert.NotEqual(t, first, NewInternalError("unable to query users", nil).Fingerprint())
+	assert.Equal(t, HandleError(errors.New("boom")).Fingerprint(), HandleError(errors.New("boom")).Fingerprint())
+	assert.Equal(t, HandleError(errors.New("dial tcp 10.0.0.1:5432")).Fingerprint(), HandleError(errors.New("dial tcp 10.0.0.2:5432")).Fingerprint(), "causes are left out")
+	assert.NotEqual(t, NewBadRequestError("invalid email", nil).Fingerprint(), NewBadRequestError("invalid phone", nil).Fingerprint())
+}
@@ -2,12 +2,33 @@ package errs
 
 import "errors"
 
+// customError is implemented by CustomError, *CustomError and Kind, so a single
+// errors.As finds whichever of them comes first in a chain.
+type customError interface {
+	error
+	customError() CustomError
+}
+
+// As returns the first CustomError in err's chain, whether it was returned as a value,
+// a pointer or a bare Kind.
+func As(err error) (CustomError, bool) {
+	var target customError
+	if !errors.As(err, &target) {
+		return CustomError{}, false
+	}
+	if p, ok := target.(*CustomError); ok && p == nil {
+		return CustomError{}, false
+	}
+	return target.customError(), true
+}
+
+// HandleError returns the first CustomError in err's chain, or an unknown error wrapping err.
 func HandleError(err error) CustomError {
-	var customError CustomError
-	switch {
-	case errors.As(err, &customError):
-		return err.(CustomError)
-	default:
-		return NewUnknownError(err)
+	if customErr, ok := As(err); ok {
+		return customErr
 	}
+	// where the error was handled says nothing about where it happened
+	unknown := NewUnknownError(err)
+	unknown.stack = nil
+	return unknown
 }
@@ -0,0 +1,90 @@
+package errs
+
+// Kind classifies errors and doubles as a sentinel: errors.Is(err, errs.ErrNotFound) reports
+// whether err wraps a CustomError of that kind. A Kind can also be returned as an error itself.
+type Kind string
+
+const (
+	ErrValidation           Kind = "VALIDATION_ERROR"
+	ErrBadRequest           Kind = "BAD_REQUEST"
+	ErrUnauthorized         Kind = "UNAUTHORIZED"
+	ErrPaymentRequired      Kind = "PAYMENT_REQUIRED"
+	ErrForbidden            Kind = "FORBIDDEN"
+	ErrNotFound             Kind = "NOT_FOUND"
+	ErrMethodNotAllowed     Kind = "METHOD_NOT_ALLOWED"
+	ErrNotAcceptable        Kind = "NOT_ACCEPTABLE"
+	ErrTimeout              Kind = "TIMEOUT"
+	ErrConflict             Kind = "CONFLICT"
+	ErrPayloadTooLarge      Kind = "PAYLOAD_TOO_LARGE"
+	ErrUnsupportedMediaType Kind = "UNSUPPORTED_MEDIA_TYPE"
+	ErrExpectationFailed    Kind = "EXPECTATION_FAILED"
+	ErrUnprocessableEntity  Kind = "UNPROCESSABLE_ENTITY"
+	ErrTooManyRequests      Kind = "TOO_MANY_REQUESTS"
+	ErrInternal             Kind = "INTERNAL_ERROR"
+	ErrNotImplemented       Kind = "NOT_IMPLEMENTED"
+	ErrServiceUnavailable   Kind = "SERVICE_UNAVAILABLE"
+	ErrUnknown              Kind = "UNKNOWN_ERROR"
+)
+
+type kindInfo struct {
+	desc     string
+	httpCode int
+}
+
+var kinds = map[Kind]kindInfo{
+	ErrValidation:           {"Could not understand the request due to invalid syntax.", 400},
+	ErrBadRequest:           {"Could not understand the request due to invalid syntax.", 400},
+	ErrUnauthorized:         {"Authentication required for the target resource.", 401},
+	ErrPaymentRequired:      {"Payment required for the target resource.", 402},
+	ErrForbidden:            {"Request understood, but refused", 403},
+	ErrNotFound:             {"Resource could not be found", 404},
+	ErrMethodNotAllowed:     {"Request method not supported for the target resource.", 405},
+	ErrNotAcceptable:        {"Request not acceptable for the target resource.", 406},
+	ErrTimeout:              {"Request timeout", 408},
+	ErrConflict:             {"Uncompleted request due to a conflict with the current state of the target resource.", 409},
+	ErrPayloadTooLarge:      {"Request payload too large", 413},
+	ErrUnsupportedMediaType: {"Unsupported media type", 415},
+	ErrExpectationFailed:    {"Expectation failed", 417},
+	ErrUnprocessableEntity:  {"Well formed request, but was unable to be followed due to semantic errors.", 422},
+	ErrTooManyRequests:      {"Too many requests", 429},
+	ErrInternal:             {"Encountered an unexpected condition that prevented it from fulfilling the request.", 500},
+	ErrNotImplemented:       {"The server does not support the functionality required to fulfill the request.", 501},
+	ErrServiceUnavailable:   {"The server is currently unavailable.", 503},
+	ErrUnknown:              {"An unknown error occurred.", 520},
+}
+
+func (k Kind) Error() string {
+	return k.Desc()
+}
+
+func (k Kind) Desc() string {
+	if info, ok := kinds[k]; ok {
+		return info.desc
+	}
+	return kinds[ErrUnknown].desc
+}
+
+func (k Kind) HttpCode() int {
+	if info, ok := kinds[k]; ok {
+		return info.httpCode
+	}
+	return kinds[ErrUnknown].httpCode
+}
+
+func (k Kind) customError() CustomError {
+	e := newError(k, k.Desc(), nil)
+	e.stack = nil
+	return e
+}
+
+// newError must be called by the exported constructors directly, so the stack starts at their caller.
+func newError(kind Kind, text string, err error) CustomError {
+	return CustomError{
+		s:        text,
+		Code:     string(kind),
+		Desc:     kind.Desc(),
+		HttpCode: kind.HttpCode(),
+		Original: err,
+		stack:    callers(kind.HttpCode(), 2),
+	}
+}
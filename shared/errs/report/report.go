@@ -0,0 +1,81 @@
+// Package report sends server errors, with their stack traces and fingerprints,
+// to a pluggable Reporter such as the logs, a file or a Sentry compatible server.
+package report
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/abdelrahman146/zard/shared/redact"
+	"sync"
+	"time"
+)
+
+// Event is a reported error. Its message is redacted, see redact.String.
+type Event struct {
+	ID          string            `json:"eventId"`
+	Time        time.Time         `json:"time"`
+	Fingerprint string            `json:"fingerprint"`
+	Code        string            `json:"code"`
+	HttpCode    int               `json:"httpCode"`
+	Message     string            `json:"message"`
+	Stack       []errs.Frame      `json:"stack,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"` // correlation IDs of the failed request
+}
+
+type Reporter interface {
+	Report(ctx context.Context, event Event) error
+}
+
+var (
+	mu       sync.RWMutex
+	reporter = NewLogReporter()
+)
+
+// SetReporter replaces the reporter used by Report, which logs errors by default.
+func SetReporter(r Reporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	reporter = r
+}
+
+// NewEvent describes err, taking its tags from the correlation IDs of ctx.
+func NewEvent(ctx context.Context, err error) Event {
+	customErr := errs.HandleError(err)
+	return Event{
+		ID:          newEventID(),
+		Time:        time.Now().UTC(),
+		Fingerprint: customErr.Fingerprint(),
+		Code:        customErr.Code,
+		HttpCode:    customErr.HttpCode,
+		Message:     redact.String(customErr.Error()),
+		Stack:       customErr.StackTrace(),
+		Tags:        logger.CorrelationIDs(ctx),
+	}
+}
+
+// Report sends err to the reporter when it is a server error and returns the event ID, or ""
+// when nothing was reported. Failures to report are logged, never returned.
+func Report(ctx context.Context, err error) string {
+	if err == nil || errs.HandleError(err).HttpCode < 500 {
+		return ""
+	}
+	event := NewEvent(ctx, err)
+	mu.RLock()
+	r := reporter
+	mu.RUnlock()
+	if reportErr := r.Report(ctx, event); reportErr != nil {
+		logger.FromContext(ctx).Named("report").Warn("unable to report error",
+			logger.Field("eventId", event.ID), logger.Field("error", reportErr))
+	}
+	return event.ID
+}
+
+// newEventID returns 32 hex characters, the format Sentry expects.
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,100 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrQueueFull      = errors.New("report queue is full, event dropped")
+	ErrReporterClosed = errors.New("reporter is closed")
+)
+
+type AsyncConfig struct {
+	QueueSize int // events waiting to be sent, further ones are dropped, defaults to 100
+}
+
+// AsyncReporter sends events in the background, so reporting never holds up a request.
+type AsyncReporter interface {
+	Reporter
+	// Close stops accepting events and waits until the queued ones are sent or ctx is done.
+	Close(ctx context.Context) error
+	// Dropped returns how many events were dropped because the queue was full.
+	Dropped() int64
+}
+
+type queuedEvent struct {
+	ctx   context.Context
+	event Event
+}
+
+type asyncReporter struct {
+	next    Reporter
+	queue   chan queuedEvent
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Int64
+}
+
+// NewAsyncReporter queues events for a single worker sending them to next. Events reported while
+// the queue is full are dropped with ErrQueueFull, and failures of next are logged.
+func NewAsyncReporter(next Reporter, config AsyncConfig) AsyncReporter {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 100
+	}
+	r := &asyncReporter{
+		next:  next,
+		queue: make(chan queuedEvent, config.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *asyncReporter) Report(ctx context.Context, event Event) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return ErrReporterClosed
+	}
+	select {
+	case r.queue <- queuedEvent{ctx: context.WithoutCancel(ctx), event: event}:
+		return nil
+	default:
+		r.dropped.Add(1)
+		return ErrQueueFull
+	}
+}
+
+func (r *asyncReporter) run() {
+	defer close(r.done)
+	for queued := range r.queue {
+		if err := r.next.Report(queued.ctx, queued.event); err != nil {
+			logger.FromContext(queued.ctx).Named("report").Warn("unable to report error",
+				logger.Field("eventId", queued.event.ID), logger.Field("error", err))
+		}
+	}
+}
+
+func (r *asyncReporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *asyncReporter) Dropped() int64 {
+	return r.dropped.Load()
+}
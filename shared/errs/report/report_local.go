@@ -0,0 +1,53 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"io"
+	"strings"
+	"sync"
+)
+
+type logReporter struct{}
+
+// NewLogReporter logs events at error level with the logger of their context.
+func NewLogReporter() Reporter {
+	return logReporter{}
+}
+
+func (logReporter) Report(ctx context.Context, event Event) error {
+	var stack strings.Builder
+	for _, frame := range event.Stack {
+		fmt.Fprintf(&stack, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	logger.FromContext(ctx).Named("report").Error(event.Message,
+		logger.Field("eventId", event.ID),
+		logger.Field("code", event.Code),
+		logger.Field("fingerprint", event.Fingerprint),
+		logger.Field("stack", stack.String()),
+	)
+	return nil
+}
+
+type fileReporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewFileReporter writes events as JSON lines to w, e.g. a file from logger.NewFileOutput.
+func NewFileReporter(w io.Writer) Reporter {
+	return &fileReporter{w: w}
+}
+
+func (r *fileReporter) Report(_ context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.w.Write(append(data, '\n'))
+	return err
+}
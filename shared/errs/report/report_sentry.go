@@ -0,0 +1,137 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type SentryConfig struct {
+	DSN         string // https://<key>@<host>/<project id>
+	Environment string
+	Release     string
+	ServerName  string       // defaults to the host name
+	Client      *http.Client // defaults to a client with a 5 seconds timeout
+	QueueSize   int          // events waiting to be sent, further ones are dropped, defaults to 100
+}
+
+type sentryReporter struct {
+	config   SentryConfig
+	endpoint string
+	auth     string
+}
+
+// NewSentryReporter sends events to the store endpoint of a Sentry compatible server, in the
+// background, see NewAsyncReporter. Close it on shutdown to send the queued events.
+func NewSentryReporter(config SentryConfig) (AsyncReporter, error) {
+	r, err := newSentryReporter(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReporter(r, AsyncConfig{QueueSize: config.QueueSize}), nil
+}
+
+func newSentryReporter(config SentryConfig) (*sentryReporter, error) {
+	dsn, err := url.Parse(config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	project := strings.TrimPrefix(dsn.Path, "/")
+	if dsn.User == nil || dsn.User.Username() == "" || project == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: expected %s", "https://<key>@<host>/<project id>")
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	if config.ServerName == "" {
+		config.ServerName, _ = os.Hostname()
+	}
+	return &sentryReporter{
+		config:   config,
+		endpoint: fmt.Sprintf("%s://%s/api/%s/store/", dsn.Scheme, dsn.Host, project),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=zard/1.0, sentry_key=%s", dsn.User.Username()),
+	}, nil
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Message     string            `json:"message"`
+	Fingerprint []string          `json:"fingerprint"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Exception   sentryExceptions  `json:"exception"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+}
+
+func (r *sentryReporter) Report(ctx context.Context, event Event) error {
+	exception := sentryException{Type: event.Code, Value: event.Message}
+	if len(event.Stack) > 0 {
+		// sentry lists frames from the outermost call to the innermost one
+		frames := make([]sentryFrame, len(event.Stack))
+		for i, frame := range event.Stack {
+			frames[len(frames)-1-i] = sentryFrame{Function: frame.Function, AbsPath: frame.File, Lineno: frame.Line}
+		}
+		exception.Stacktrace = &sentryStacktrace{Frames: frames}
+	}
+	body, err := json.Marshal(sentryEvent{
+		EventID:     event.ID,
+		Timestamp:   event.Time.Format(time.RFC3339),
+		Level:       "error",
+		Platform:    "go",
+		Message:     event.Message,
+		Fingerprint: []string{event.Fingerprint},
+		Environment: r.config.Environment,
+		Release:     r.config.Release,
+		ServerName:  r.config.ServerName,
+		Tags:        event.Tags,
+		Exception:   sentryExceptions{Values: []sentryException{exception}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+	resp, err := r.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
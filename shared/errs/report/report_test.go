@@ -0,0 +1,139 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type recordingReporter struct {
+	events []Event
+}
+
+func (r *recordingReporter) Report(_ context.Context, event Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func useReporter(t *testing.T, r Reporter) {
+	SetReporter(r)
+	t.Cleanup(func() { SetReporter(NewLogReporter()) })
+}
+
+func TestReport_OnlyServerErrors(t *testing.T) {
+	recorder := &recordingReporter{}
+	useReporter(t, recorder)
+	ctx := logger.WithCorrelationID(context.Background(), logger.RequestIDKey, "req-1")
+
+	assert.Empty(t, Report(ctx, nil))
+	assert.Empty(t, Report(ctx, errs.NewNotFoundError("user not found", nil)))
+	id := Report(ctx, errs.NewInternalError("unable to connect", errors.New("dial postgres://zard:hunter2@db/zard")))
+
+	require.Len(t, recorder.events, 1)
+	event := recorder.events[0]
+	assert.Equal(t, id, event.ID)
+	assert.Len(t, event.ID, 32)
+	assert.Equal(t, "INTERNAL_ERROR", event.Code)
+	assert.Equal(t, 500, event.HttpCode)
+	assert.Equal(t, "unable to connect: dial postgres://zard:xxxxx@db/zard", event.Message)
+	assert.NotEmpty(t, event.Fingerprint)
+	require.NotEmpty(t, event.Stack)
+	assert.Equal(t, "github.com/abdelrahman146/zard/shared/errs/report.TestReport_OnlyServerErrors", event.Stack[0].Function)
+	assert.Equal(t, map[string]string{logger.RequestIDKey: "req-1"}, event.Tags)
+}
+
+func TestFileReporter(t *testing.T) {
+	var out bytes.Buffer
+	r := NewFileReporter(&out)
+	event := NewEvent(context.Background(), errs.NewInternalError("boom", nil))
+	require.NoError(t, r.Report(context.Background(), event))
+	require.NoError(t, r.Report(context.Background(), event))
+
+	decoder := json.NewDecoder(&out)
+	var first Event
+	require.NoError(t, decoder.Decode(&first))
+	assert.Equal(t, event.Fingerprint, first.Fingerprint)
+	assert.Equal(t, event.Stack, first.Stack)
+	assert.True(t, decoder.More())
+}
+
+func TestSentryReporter(t *testing.T) {
+	var auth, path string
+	var body map[string]any
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth, path = req.Header.Get("X-Sentry-Auth"), req.URL.Path
+		data, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(data, &body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer stub.Close()
+
+	r, err := NewSentryReporter(SentryConfig{DSN: "http://public-key@" + stub.Listener.Addr().String() + "/42", Environment: "test", Release: "1.2.3"})
+	require.NoError(t, err)
+	event := NewEvent(context.Background(), errs.NewInternalError("boom", nil))
+	require.NoError(t, r.Report(context.Background(), event))
+	require.NoError(t, r.Close(context.Background()))
+
+	assert.Equal(t, "/api/42/store/", path)
+	assert.Contains(t, auth, "sentry_key=public-key")
+	assert.Equal(t, event.ID, body["event_id"])
+	assert.Equal(t, "test", body["environment"])
+	assert.Equal(t, []any{event.Fingerprint}, body["fingerprint"])
+	exception := body["exception"].(map[string]any)["values"].([]any)[0].(map[string]any)
+	assert.Equal(t, "INTERNAL_ERROR", exception["type"])
+	frames := exception["stacktrace"].(map[string]any)["frames"].([]any)
+	assert.Equal(t, event.Stack[0].Function, frames[len(frames)-1].(map[string]any)["function"], "the innermost frame comes last")
+}
+
+func TestSentryReporter_Failures(t *testing.T) {
+	_, err := NewSentryReporter(SentryConfig{DSN: "http://sentry.local/42"})
+	assert.Error(t, err)
+
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer stub.Close()
+	r, err := newSentryReporter(SentryConfig{DSN: "http://key@" + stub.Listener.Addr().String() + "/42"})
+	require.NoError(t, err)
+	assert.Error(t, r.Report(context.Background(), NewEvent(context.Background(), errs.NewInternalError("boom", nil))))
+}
+
+type blockingReporter struct {
+	release chan struct{}
+	events  chan Event
+}
+
+func (r *blockingReporter) Report(_ context.Context, event Event) error {
+	<-r.release
+	r.events <- event
+	return nil
+}
+
+func TestAsyncReporter_DropsEventsWhenFull(t *testing.T) {
+	next := &blockingReporter{release: make(chan struct{}), events: make(chan Event, 3)}
+	r := NewAsyncReporter(next, AsyncConfig{QueueSize: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	event := NewEvent(ctx, errs.NewInternalError("boom", nil))
+
+	require.NoError(t, r.Report(ctx, event))
+	assert.Eventually(t, func() bool { return len(r.(*asyncReporter).queue) == 0 }, time.Second, time.Millisecond, "the worker holds the first event")
+	require.NoError(t, r.Report(ctx, event))
+	assert.ErrorIs(t, r.Report(ctx, event), ErrQueueFull)
+	assert.Equal(t, int64(1), r.Dropped())
+	cancel() // queued events outlive the request
+
+	close(next.release)
+	require.NoError(t, r.Close(context.Background()))
+	assert.Len(t, next.events, 2)
+	assert.ErrorIs(t, r.Report(context.Background(), event), ErrReporterClosed)
+}
@@ -0,0 +1,73 @@
+package errs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const maxStackDepth = 32
+
+// Frame is a function call in a stack trace.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// callers records the stack of server errors, skip frames above the caller of callers.
+// Client errors are expected, so they are not worth the cost of a stack trace.
+func callers(httpCode, skip int) []uintptr {
+	if httpCode < 500 {
+		return nil
+	}
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
+
+// StackTrace returns where e was created, innermost call first. Only server errors have one.
+func (e CustomError) StackTrace() []Frame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(e.stack)
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			stack = append(stack, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			return stack
+		}
+	}
+}
+
+// Stack formats the stack trace like a panic does, for logs.
+func (e CustomError) Stack() string {
+	var b strings.Builder
+	for _, frame := range e.StackTrace() {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return b.String()
+}
+
+// Fingerprint identifies the place an error comes from, to group its occurrences. It hashes the
+// code with the functions of the stack trace, ignoring line numbers so edits elsewhere in a file
+// keep it stable. Errors without stack trace are grouped by code and message instead, leaving out
+// the wrapped error, whose text varies between occurrences.
+func (e CustomError) Fingerprint() string {
+	hash := sha256.New()
+	hash.Write([]byte(e.Code))
+	stack := e.StackTrace()
+	for _, frame := range stack {
+		hash.Write([]byte("\n" + frame.Function))
+	}
+	if len(stack) == 0 {
+		hash.Write([]byte("\n" + e.Message()))
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestZapLogger_Console(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := NewZapLoggerWithConfig(ZapLoggerConfig{Level: zapcore.InfoLevel, Service: "account", Format: FormatConsole, Outputs: []io.Writer{buf}})
+	assert.NoError(t, err)
+	l.Debug("hidden")
+	l.With(Field("requestId", "req_1")).Info("signed in")
+	line := buf.String()
+	assert.Contains(t, line, "signed in")
+	assert.Contains(t, line, `"requestId": "req_1"`)
+	assert.NotContains(t, line, "hidden")
+	assert.False(t, strings.HasPrefix(line, "{"))
+}
+
+func TestZapLogger_RejectsUnknownFormat(t *testing.T) {
+	_, err := NewZapLoggerWithConfig(ZapLoggerConfig{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestZapLogger_FileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "account.log")
+	file := NewFileOutput(FileOutputConfig{Path: path, MaxSizeMB: 1})
+	defer file.Close()
+	l, err := NewZapLoggerWithConfig(ZapLoggerConfig{Level: zapcore.InfoLevel, Service: "account", Outputs: []io.Writer{file}})
+	assert.NoError(t, err)
+	l.Info("written", Field("userId", "usr_1"))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	line := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(data, &line))
+	assert.Equal(t, "written", line["msg"])
+	assert.Equal(t, "account", line["service"])
+	assert.Equal(t, "usr_1", line["userId"])
+}
+
+func TestSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewSlogLogger(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo, AddSource: true}))
+	l.Debug("hidden")
+	l.With(Field("requestId", "req_1")).Warn("slow", Field("elapsed", "2s"))
+
+	line := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "WARN", line["level"])
+	assert.Equal(t, "slow", line["msg"])
+	assert.Equal(t, "req_1", line["requestId"])
+	assert.Equal(t, "2s", line["elapsed"])
+	assert.Contains(t, line["source"].(map[string]interface{})["file"], "backends_test.go")
+}
+
+func TestSlogHandler(t *testing.T) {
+	var entries []entry
+	rec := &recordingLogger{entries: &entries}
+	log := slog.New(NewSlogHandler(rec)).With("component", "nats").WithGroup("http")
+	log.Error("request failed", "status", 500, slog.Group("client", "ip", "10.0.0.1"))
+	assert.Equal(t, []entry{{msg: "request failed", fields: map[string]interface{}{
+		"component":      "nats",
+		"http.status":    int64(500),
+		"http.client.ip": "10.0.0.1",
+	}}}, entries)
+}
+
+func TestSlogHandler_UsesContextLogger(t *testing.T) {
+	var entries []entry
+	ctx := IntoContext(context.Background(), &recordingLogger{entries: &entries})
+	ctx = WithCorrelationID(ctx, RequestIDKey, "req_1")
+	slog.New(NewSlogHandler(nil)).InfoContext(ctx, "from library")
+	assert.Equal(t, []entry{{msg: "from library", fields: map[string]interface{}{RequestIDKey: "req_1"}}}, entries)
+}
+
+func TestZapLogger_RedactsSensitiveFields(t *testing.T) {
+	type login struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Code     string `json:"code" sensitive:"true"`
+	}
+	buf := &bytes.Buffer{}
+	l, err := NewZapLoggerWithConfig(ZapLoggerConfig{Level: zapcore.InfoLevel, Outputs: []io.Writer{buf}})
+	assert.NoError(t, err)
+	l.With(Field("token", "ztkn_1")).Info("login",
+		Field("request", login{Email: "jane@example.com", Password: "hunter2", Code: "123456"}),
+		Field("error", errors.New("connect postgres://zard:hunter2@db/zard")),
+	)
+
+	line := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "******", line["token"])
+	assert.Equal(t, map[string]interface{}{"email": "jane@example.com", "password": "******", "code": "******"}, line["request"])
+	assert.Equal(t, "connect postgres://zard:xxxxx@db/zard", line["error"])
+	assert.NotContains(t, buf.String(), "hunter2")
+}
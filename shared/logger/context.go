@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Correlation IDs carried by a context. They are added to every line logged
+// through FromContext and propagated to other services by RPC and PubSub.
+const (
+	RequestIDKey   = "requestId"
+	UserIDKey      = "userId"
+	WorkspaceIDKey = "workspaceId"
+)
+
+// correlationHeaderPrefix prefixes the message headers correlation IDs travel in, e.g. Zard-Correlation-requestId.
+const correlationHeaderPrefix = "Zard-Correlation-"
+
+type contextKey struct{}
+
+type scope struct {
+	logger Logger
+	ids    map[string]string
+}
+
+func scopeOf(ctx context.Context) scope {
+	if ctx == nil {
+		return scope{}
+	}
+	s, _ := ctx.Value(contextKey{}).(scope)
+	return s
+}
+
+// IntoContext stores l in ctx. Correlation IDs already in ctx are kept and still added to l's lines.
+func IntoContext(ctx context.Context, l Logger) context.Context {
+	s := scopeOf(ctx)
+	return context.WithValue(ctx, contextKey{}, scope{logger: l, ids: s.ids})
+}
+
+// FromContext returns the logger stored in ctx, or the global one, with the
+// correlation IDs of ctx attached. It never returns nil.
+func FromContext(ctx context.Context) Logger {
+	s := scopeOf(ctx)
+	l := s.logger
+	if l == nil {
+		l = GetLogger()
+	}
+	if l == nil {
+		return nopLogger{}
+	}
+	if len(s.ids) == 0 {
+		return l
+	}
+	keys := make([]string, 0, len(s.ids))
+	for key := range s.ids {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]F, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, Field(key, s.ids[key]))
+	}
+	return l.With(fields...)
+}
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID key, e.g. RequestIDKey.
+func WithCorrelationID(ctx context.Context, key, value string) context.Context {
+	return WithCorrelationIDs(ctx, map[string]string{key: value})
+}
+
+// WithCorrelationIDs returns a copy of ctx carrying ids on top of the ones it already has.
+func WithCorrelationIDs(ctx context.Context, ids map[string]string) context.Context {
+	s := scopeOf(ctx)
+	merged := make(map[string]string, len(s.ids)+len(ids))
+	for key, value := range s.ids {
+		merged[key] = value
+	}
+	for key, value := range ids {
+		if value != "" {
+			merged[key] = value
+		}
+	}
+	return context.WithValue(ctx, contextKey{}, scope{logger: s.logger, ids: merged})
+}
+
+// CorrelationIDs returns the correlation IDs carried by ctx.
+func CorrelationIDs(ctx context.Context) map[string]string {
+	ids := make(map[string]string)
+	for key, value := range scopeOf(ctx).ids {
+		ids[key] = value
+	}
+	return ids
+}
+
+// CorrelationHeader encodes the correlation IDs of ctx as message headers.
+func CorrelationHeader(ctx context.Context) map[string][]string {
+	header := make(map[string][]string)
+	for key, value := range scopeOf(ctx).ids {
+		header[correlationHeaderPrefix+key] = []string{value}
+	}
+	return header
+}
+
+// ContextFromHeader returns a copy of ctx carrying the correlation IDs found in header.
+func ContextFromHeader(ctx context.Context, header map[string][]string) context.Context {
+	ids := make(map[string]string)
+	for name, values := range header {
+		if strings.HasPrefix(name, correlationHeaderPrefix) && len(values) > 0 {
+			ids[strings.TrimPrefix(name, correlationHeaderPrefix)] = values[0]
+		}
+	}
+	if len(ids) == 0 {
+		return ctx
+	}
+	return WithCorrelationIDs(ctx, ids)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...F)       {}
+func (nopLogger) Info(string, ...F)        {}
+func (nopLogger) Warn(string, ...F)        {}
+func (nopLogger) Error(string, ...F)       {}
+func (nopLogger) Panic(msg string, _ ...F) { panic(msg) }
+func (n nopLogger) With(...F) Logger       { return n }
+func (n nopLogger) Named(string) Logger    { return n }
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type entry struct {
+	msg    string
+	fields map[string]interface{}
+}
+
+type recordingLogger struct {
+	fields  []F
+	entries *[]entry
+}
+
+func (l *recordingLogger) log(msg string, fields []F) {
+	e := entry{msg: msg, fields: make(map[string]interface{})}
+	for _, f := range append(append([]F{}, l.fields...), fields...) {
+		e.fields[f.Key] = f.Value
+	}
+	*l.entries = append(*l.entries, e)
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...F) { l.log(msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...F)  { l.log(msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields ...F)  { l.log(msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...F) { l.log(msg, fields) }
+func (l *recordingLogger) Panic(msg string, fields ...F) { l.log(msg, fields) }
+func (l *recordingLogger) With(fields ...F) Logger {
+	return &recordingLogger{fields: append(append([]F{}, l.fields...), fields...), entries: l.entries}
+}
+
+func (l *recordingLogger) Named(name string) Logger {
+	return l.With(Field("logger", name))
+}
+
+func TestFromContext(t *testing.T) {
+	var entries []entry
+	ctx := IntoContext(context.Background(), &recordingLogger{entries: &entries})
+	ctx = WithCorrelationID(ctx, RequestIDKey, "req_1")
+	ctx = WithCorrelationID(ctx, UserIDKey, "usr_1")
+
+	FromContext(ctx).With(Field("component", "auth")).Info("signed in", Field("method", "password"))
+	assert.Equal(t, []entry{{msg: "signed in", fields: map[string]interface{}{
+		RequestIDKey: "req_1",
+		UserIDKey:    "usr_1",
+		"component":  "auth",
+		"method":     "password",
+	}}}, entries)
+}
+
+func TestFromContext_FallsBackToNop(t *testing.T) {
+	assert.NotPanics(t, func() {
+		FromContext(context.Background()).Info("dropped")
+	})
+}
+
+func TestCorrelationHeader_RoundTrip(t *testing.T) {
+	ctx := WithCorrelationIDs(context.Background(), map[string]string{RequestIDKey: "req_1", WorkspaceIDKey: "wrk_1"})
+	header := CorrelationHeader(ctx)
+	header["Content-Type"] = []string{"application/json"}
+
+	received := ContextFromHeader(context.Background(), header)
+	assert.Equal(t, map[string]string{RequestIDKey: "req_1", WorkspaceIDKey: "wrk_1"}, CorrelationIDs(received))
+	assert.Empty(t, CorrelationIDs(ContextFromHeader(context.Background(), nil)))
+}
@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+)
+
+type FileOutputConfig struct {
+	Path       string
+	MaxSizeMB  int  // rotate once the file reaches this size, defaults to 100
+	MaxBackups int  // rotated files to keep, 0 keeps them all
+	MaxAgeDays int  // delete rotated files older than this, 0 keeps them forever
+	Compress   bool // gzip rotated files
+}
+
+// NewFileOutput returns a writer appending to config.Path and rotating it by size,
+// to be passed to ZapLoggerConfig.Outputs or slog.NewJSONHandler.
+func NewFileOutput(config FileOutputConfig) io.WriteCloser {
+	return &lumberjack.Logger{
+		Filename:   config.Path,
+		MaxSize:    config.MaxSizeMB,
+		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAgeDays,
+		Compress:   config.Compress,
+		LocalTime:  true,
+	}
+}
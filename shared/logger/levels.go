@@ -0,0 +1,291 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Levels controls the level of a logger and of its named sub-loggers, e.g. "cache" or
+// "repo.user", at runtime. A named logger follows its closest configured parent, then the
+// global level. Temporary overrides revert by themselves once they expire.
+type Levels struct {
+	mu        sync.Mutex
+	global    zap.AtomicLevel
+	base      zapcore.Level
+	named     map[string]zapcore.Level
+	overrides map[string]levelOverride
+	effective atomic.Pointer[map[string]zapcore.Level]
+	now       func() time.Time
+	afterFunc func(d time.Duration, f func()) *time.Timer
+}
+
+type levelOverride struct {
+	level zapcore.Level
+	until time.Time
+	timer *time.Timer
+}
+
+// LevelState describes the level a logger was configured with. Until is set for temporary overrides.
+type LevelState struct {
+	Level string     `json:"level"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+type LevelsSnapshot struct {
+	Global  LevelState            `json:"global"`
+	Loggers map[string]LevelState `json:"loggers"`
+}
+
+func NewLevels(level zapcore.Level) *Levels {
+	l := &Levels{
+		global:    zap.NewAtomicLevelAt(level),
+		base:      level,
+		named:     make(map[string]zapcore.Level),
+		overrides: make(map[string]levelOverride),
+		now:       time.Now,
+		afterFunc: time.AfterFunc,
+	}
+	l.apply()
+	return l
+}
+
+// Global returns the zap level shared by every logger without a level of its own.
+func (l *Levels) Global() zap.AtomicLevel {
+	return l.global
+}
+
+// Enabler returns the level enabler of the named logger, "" being the root logger.
+func (l *Levels) Enabler(name string) zapcore.LevelEnabler {
+	if name == "" {
+		return l.global
+	}
+	return namedLevel{levels: l, name: name}
+}
+
+// Level returns the level currently in effect for the named logger.
+func (l *Levels) Level(name string) zapcore.Level {
+	levels := *l.effective.Load()
+	for ; name != ""; name = parentName(name) {
+		if level, ok := levels[name]; ok {
+			return level
+		}
+	}
+	return l.global.Level()
+}
+
+// SetLevel sets the level of the named logger, or the global level when name is "".
+// It replaces any temporary override of that logger.
+func (l *Levels) SetLevel(name string, level zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dropOverride(name)
+	if name == "" {
+		l.base = level
+	} else {
+		l.named[name] = level
+	}
+	l.apply()
+}
+
+// SetLevelFor changes the level of the named logger for d, e.g. debug for 10 minutes,
+// then restores the level it was configured with.
+func (l *Levels) SetLevelFor(name string, level zapcore.Level, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dropOverride(name)
+	override := levelOverride{level: level, until: l.now().Add(d)}
+	override.timer = l.afterFunc(d, func() { l.expire(name, override.until) })
+	l.overrides[name] = override
+	l.apply()
+}
+
+// Reset removes the level and the override of the named logger, so it follows its parent again.
+// Resetting "" only removes the override of the global level.
+func (l *Levels) Reset(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dropOverride(name)
+	delete(l.named, name)
+	l.apply()
+}
+
+func (l *Levels) Snapshot() LevelsSnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	snapshot := LevelsSnapshot{Global: LevelState{Level: l.base.String()}, Loggers: make(map[string]LevelState)}
+	for name, level := range l.named {
+		snapshot.Loggers[name] = LevelState{Level: level.String()}
+	}
+	for name, override := range l.overrides {
+		until := override.until
+		state := LevelState{Level: override.level.String(), Until: &until}
+		if name == "" {
+			snapshot.Global = state
+		} else {
+			snapshot.Loggers[name] = state
+		}
+	}
+	return snapshot
+}
+
+func (l *Levels) expire(name string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// the override may have been replaced since the timer was started
+	if override, ok := l.overrides[name]; ok && override.until.Equal(until) {
+		delete(l.overrides, name)
+		l.apply()
+	}
+}
+
+func (l *Levels) dropOverride(name string) {
+	if override, ok := l.overrides[name]; ok {
+		override.timer.Stop()
+		delete(l.overrides, name)
+	}
+}
+
+// apply publishes the levels in effect. It must be called with mu held.
+func (l *Levels) apply() {
+	levels := make(map[string]zapcore.Level, len(l.named)+len(l.overrides))
+	for name, level := range l.named {
+		levels[name] = level
+	}
+	global := l.base
+	for name, override := range l.overrides {
+		if name == "" {
+			global = override.level
+		} else {
+			levels[name] = override.level
+		}
+	}
+	l.effective.Store(&levels)
+	l.global.SetLevel(global)
+}
+
+func parentName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+type namedLevel struct {
+	levels *Levels
+	name   string
+}
+
+func (n namedLevel) Enabled(level zapcore.Level) bool {
+	return level >= n.levels.Level(n.name)
+}
+
+// ApplyConfig applies levels read from configuration: <prefix>.level sets the global level
+// and <prefix>.levels.<name> the level of a named logger. Keys with a nil value, as reported
+// by config.Watcher for deleted keys, are reset.
+func (l *Levels) ApplyConfig(prefix string, values map[string]interface{}) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var problems []string
+	for _, key := range keys {
+		var name string
+		switch {
+		case strings.EqualFold(key, prefix+".level"):
+		case strings.HasPrefix(strings.ToLower(key), strings.ToLower(prefix+".levels.")):
+			name = key[len(prefix+".levels."):]
+		default:
+			continue
+		}
+		if values[key] == nil {
+			if name == "" {
+				continue
+			}
+			l.Reset(name)
+			continue
+		}
+		level, err := zapcore.ParseLevel(fmt.Sprint(values[key]))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		l.SetLevel(name, level)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid log levels: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// OnConfigChange returns a callback for config.Watcher.OnChange applying the levels under prefix.
+func (l *Levels) OnConfigChange(prefix string) func(changed map[string]interface{}) {
+	return func(changed map[string]interface{}) {
+		if err := l.ApplyConfig(prefix, changed); err != nil {
+			GetLogger().Warn("failed to apply log levels", Field("error", err))
+		}
+	}
+}
+
+type levelRequest struct {
+	Logger   string `json:"logger"`
+	Level    string `json:"level"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// Handler serves the levels for admin endpoints:
+//
+//	GET                                     lists the configured levels
+//	PUT {"logger": "cache", "level": "debug", "duration": "10m"}
+//	                                        sets a level, for a while when duration is set; "" is the global logger
+//	DELETE ?logger=cache                    makes the logger follow its parent again
+func (l *Levels) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			req := levelRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeLevelError(w, "invalid request body")
+				return
+			}
+			level, err := zapcore.ParseLevel(req.Level)
+			if err != nil {
+				writeLevelError(w, err.Error())
+				return
+			}
+			if req.Duration == "" {
+				l.SetLevel(req.Logger, level)
+				break
+			}
+			d, err := time.ParseDuration(req.Duration)
+			if err != nil || d <= 0 {
+				writeLevelError(w, fmt.Sprintf("invalid duration %q", req.Duration))
+				return
+			}
+			l.SetLevelFor(req.Logger, level, d)
+		case http.MethodDelete:
+			l.Reset(r.URL.Query().Get("logger"))
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(l.Snapshot())
+	})
+}
+
+func writeLevelError(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
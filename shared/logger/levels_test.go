@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestZapLogger(t *testing.T, levels *Levels) (Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	l, err := NewZapLoggerWithConfig(ZapLoggerConfig{Service: "account", Outputs: []io.Writer{buf}, Levels: levels})
+	assert.NoError(t, err)
+	return l, buf
+}
+
+func TestLevels_NamedLoggers(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	l, buf := newTestZapLogger(t, levels)
+	cache := l.Named("cache")
+	userRepo := l.Named("repo").Named("user")
+
+	cache.Debug("cache hidden")
+	levels.SetLevel("cache", zapcore.DebugLevel)
+	cache.Debug("cache shown")
+	l.Debug("root hidden")
+
+	levels.SetLevel("repo", zapcore.ErrorLevel)
+	userRepo.Warn("repo hidden")
+	assert.Equal(t, zapcore.ErrorLevel, levels.Level("repo.user"))
+	levels.Reset("repo")
+	userRepo.Warn("repo shown")
+
+	out := buf.String()
+	assert.Contains(t, out, "cache shown")
+	assert.Contains(t, out, `"logger":"cache"`)
+	assert.Contains(t, out, `"logger":"repo.user"`)
+	for _, hidden := range []string{"cache hidden", "root hidden", "repo hidden"} {
+		assert.NotContains(t, out, hidden)
+	}
+}
+
+func TestLevels_TemporaryOverride(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	now := time.Now()
+	var expire func()
+	levels.now = func() time.Time { return now }
+	levels.afterFunc = func(d time.Duration, f func()) *time.Timer {
+		expire = f
+		return time.NewTimer(time.Hour)
+	}
+	l, buf := newTestZapLogger(t, levels)
+
+	levels.SetLevelFor("", zapcore.DebugLevel, 10*time.Minute)
+	l.Named("pubsub").Debug("while debugging")
+	snapshot := levels.Snapshot()
+	assert.Equal(t, "debug", snapshot.Global.Level)
+	assert.Equal(t, now.Add(10*time.Minute), *snapshot.Global.Until)
+
+	expire()
+	l.Debug("after expiry")
+	assert.Equal(t, zapcore.InfoLevel, levels.Global().Level())
+	assert.Contains(t, buf.String(), "while debugging")
+	assert.NotContains(t, buf.String(), "after expiry")
+}
+
+func TestLevels_ApplyConfig(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	err := levels.ApplyConfig("consul.log", map[string]interface{}{
+		"consul.log.level":        "warn",
+		"consul.log.levels.cache": "debug",
+		"consul.log.levels.repo":  "loud",
+		"consul.feature.signup":   true,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, zapcore.WarnLevel, levels.Level(""))
+	assert.Equal(t, zapcore.DebugLevel, levels.Level("cache"))
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("repo"))
+
+	levels.OnConfigChange("consul.log")(map[string]interface{}{"consul.log.levels.cache": nil})
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("cache"))
+}
+
+func TestLevels_Handler(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	handler := levels.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("PUT", "/debug/log-levels", strings.NewReader(`{"logger": "cache", "level": "debug", "duration": "5m"}`)))
+	assert.Equal(t, 200, rec.Code)
+	snapshot := LevelsSnapshot{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &snapshot))
+	assert.Equal(t, "debug", snapshot.Loggers["cache"].Level)
+	assert.NotNil(t, snapshot.Loggers["cache"].Until)
+	assert.Equal(t, zapcore.DebugLevel, levels.Level("cache"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("PUT", "/debug/log-levels", strings.NewReader(`{"level": "verbose"}`)))
+	assert.Equal(t, 400, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("DELETE", "/debug/log-levels?logger=cache", nil))
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, zapcore.InfoLevel, levels.Level("cache"))
+}
@@ -13,6 +13,10 @@ type Logger interface {
 	Warn(msg string, fields ...F)
 	Error(msg string, fields ...F)
 	Panic(msg string, fields ...F)
+	// With returns a logger that adds fields to every line it writes.
+	With(fields ...F) Logger
+	// Named returns a sub-logger for a component, e.g. "cache" or "repo".
+	Named(name string) Logger
 }
 
 var (
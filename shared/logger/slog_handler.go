@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogHandler sends records logged through slog, e.g. by third-party libraries, to a Logger.
+type slogHandler struct {
+	logger Logger
+	group  string
+	fields []F
+}
+
+// NewSlogHandler returns a slog.Handler writing to l. When l is nil, every record is written to
+// logger.FromContext of the context it was logged with, so slog.InfoContext carries correlation IDs.
+// Install it with slog.SetDefault(slog.New(logger.NewSlogHandler(nil))).
+// Level filtering is left to the Logger.
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	l := h.logger
+	if l == nil {
+		l = FromContext(ctx)
+	}
+	fields := append([]F{}, h.fields...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, h.group, attr)
+		return true
+	})
+	switch {
+	case record.Level >= slog.LevelError:
+		l.Error(record.Message, fields...)
+	case record.Level >= slog.LevelWarn:
+		l.Warn(record.Message, fields...)
+	case record.Level >= slog.LevelInfo:
+		l.Info(record.Message, fields...)
+	default:
+		l.Debug(record.Message, fields...)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := append([]F{}, h.fields...)
+	for _, attr := range attrs {
+		fields = appendAttr(fields, h.group, attr)
+	}
+	return &slogHandler{logger: h.logger, group: h.group, fields: fields}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, group: h.group + name + ".", fields: h.fields}
+}
+
+// appendAttr flattens groups into dotted keys, e.g. http.status.
+func appendAttr(fields []F, prefix string, attr slog.Attr) []F {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range value.Group() {
+			fields = appendAttr(fields, prefix, member)
+		}
+		return fields
+	}
+	if attr.Key == "" {
+		return fields
+	}
+	return append(fields, Field(prefix+attr.Key, value.Any()))
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"context"
+	"github.com/abdelrahman146/zard/shared/redact"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// SlogLogger writes through a slog.Handler, so any slog backend can be used as a Logger.
+type SlogLogger struct {
+	handler slog.Handler
+}
+
+func NewSlogLogger(handler slog.Handler) Logger {
+	return &SlogLogger{handler: handler}
+}
+
+func slogAttrs(fields []F) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, redact.Value(field.Key, field.Value)))
+	}
+	return attrs
+}
+
+func (sl *SlogLogger) log(level slog.Level, msg string, fields []F) {
+	ctx := context.Background()
+	if !sl.handler.Enabled(ctx, level) {
+		return
+	}
+	// skip runtime.Callers, log and the level method so the source points at the caller
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	record.AddAttrs(slogAttrs(fields)...)
+	_ = sl.handler.Handle(ctx, record)
+}
+
+func (sl *SlogLogger) Debug(msg string, fields ...F) {
+	sl.log(slog.LevelDebug, msg, fields)
+}
+
+func (sl *SlogLogger) Info(msg string, fields ...F) {
+	sl.log(slog.LevelInfo, msg, fields)
+}
+
+func (sl *SlogLogger) Warn(msg string, fields ...F) {
+	sl.log(slog.LevelWarn, msg, fields)
+}
+
+func (sl *SlogLogger) Error(msg string, fields ...F) {
+	sl.log(slog.LevelError, msg, fields)
+}
+
+func (sl *SlogLogger) Panic(msg string, fields ...F) {
+	sl.log(slog.LevelError, msg, fields)
+	panic(msg)
+}
+
+func (sl *SlogLogger) With(fields ...F) Logger {
+	return &SlogLogger{handler: sl.handler.WithAttrs(slogAttrs(fields))}
+}
+
+// Named adds the name as a "logger" attribute; levels are left to the handler.
+func (sl *SlogLogger) Named(name string) Logger {
+	return sl.With(Field("logger", name))
+}
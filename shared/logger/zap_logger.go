@@ -1,80 +1,153 @@
 package logger
 
 import (
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/redact"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"io"
+	"os"
 )
 
 type ZapLogger struct {
 	logger  *zap.Logger
 	service string
+	name    string
+	levels  *Levels
+}
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console" // human-readable, colored lines for local development
+)
+
+type ZapLoggerConfig struct {
+	Level   zapcore.Level
+	Service string
+	Format  string      // FormatJSON by default
+	Outputs []io.Writer // stdout by default, see NewFileOutput for rotated files
+	Levels  *Levels     // changes levels at runtime, defaults to NewLevels(Level)
 }
 
 func NewZapLogger(level zapcore.Level, service string) (Logger, error) {
-	config := zap.Config{
-		Encoding:         "json",
-		Level:            zap.NewAtomicLevelAt(level),
-		OutputPaths:      []string{"stdout"},
-		ErrorOutputPaths: []string{"stderr"},
-		EncoderConfig: zapcore.EncoderConfig{
-			TimeKey:        "time",
-			LevelKey:       "level",
-			NameKey:        "logger",
-			CallerKey:      "caller",
-			MessageKey:     "msg",
-			StacktraceKey:  "stacktrace",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    zapcore.LowercaseLevelEncoder,
-			EncodeTime:     zapcore.ISO8601TimeEncoder,
-			EncodeDuration: zapcore.StringDurationEncoder,
-			EncodeCaller:   zapcore.ShortCallerEncoder,
-		},
+	return NewZapLoggerWithConfig(ZapLoggerConfig{Level: level, Service: service})
+}
+
+func NewZapLoggerWithConfig(config ZapLoggerConfig) (Logger, error) {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+	var encoder zapcore.Encoder
+	switch config.Format {
+	case "", FormatJSON:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case FormatConsole:
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, fmt.Errorf("unsupported log format %q", config.Format)
+	}
+	outputs := config.Outputs
+	if len(outputs) == 0 {
+		outputs = []io.Writer{os.Stdout}
 	}
-	logger, err := config.Build()
-	if err != nil {
-		return nil, err
+	syncers := make([]zapcore.WriteSyncer, 0, len(outputs))
+	for _, output := range outputs {
+		syncers = append(syncers, zapcore.AddSync(output))
 	}
-	logger = logger.WithOptions(zap.AddCaller(), zap.AddCallerSkip(1))
-	logger = logger.With(zap.String("service", service))
-	return &ZapLogger{logger: logger, service: service}, nil
+	if config.Levels == nil {
+		config.Levels = NewLevels(config.Level)
+	}
+	// the levels are checked by levelCore, the inner core writes whatever reaches it
+	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(syncers...), zapcore.DebugLevel)
+	core = &levelCore{Core: core, level: config.Levels.Enabler("")}
+	logger := zap.New(core, zap.ErrorOutput(zapcore.Lock(os.Stderr)), zap.AddCaller(), zap.AddCallerSkip(1))
+	logger = logger.With(zap.String("service", config.Service))
+	return &ZapLogger{logger: logger, service: config.Service, levels: config.Levels}, nil
 }
 
-func (zl *ZapLogger) Debug(msg string, fields ...F) {
-	var zapFields []zap.Field
-	for i := 0; i < len(fields); i++ {
-		zapFields = append(zapFields, zap.Any(fields[i].Key, fields[i].Value))
+// zapFields encodes fields with their sensitive values masked, see redact.Value.
+func zapFields(fields []F) []zap.Field {
+	zfs := make([]zap.Field, 0, len(fields))
+	for _, field := range fields {
+		zfs = append(zfs, zap.Any(field.Key, redact.Value(field.Key, field.Value)))
 	}
-	zl.logger.Debug(msg, zapFields...)
+	return zfs
 }
 
-func (zl *ZapLogger) Info(msg string, fields ...F) {
-	var zapFields []zap.Field
-	for _, field := range fields {
-		zapFields = append(zapFields, zap.Any(field.Key, field.Value))
+// levelCore filters entries with a level enabler that can change at runtime.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
 	}
-	zl.logger.Info(msg, zapFields...)
+	return checked
 }
 
-func (zl *ZapLogger) Warn(msg string, fields ...F) {
-	var zapFields []zap.Field
-	for _, field := range fields {
-		zapFields = append(zapFields, zap.Any(field.Key, field.Value))
+func (zl *ZapLogger) With(fields ...F) Logger {
+	return &ZapLogger{logger: zl.logger.With(zapFields(fields)...), service: zl.service, name: zl.name, levels: zl.levels}
+}
+
+// Named returns a sub-logger whose level can be changed on its own through Levels.
+// Names nest with dots, so Named("repo").Named("user") is "repo.user".
+func (zl *ZapLogger) Named(name string) Logger {
+	fullName := name
+	if zl.name != "" {
+		fullName = zl.name + "." + name
 	}
-	zl.logger.Warn(msg, zapFields...)
+	level := zl.levels.Enabler(fullName)
+	logger := zl.logger.Named(name).WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if lc, ok := core.(*levelCore); ok {
+			core = lc.Core
+		}
+		return &levelCore{Core: core, level: level}
+	}))
+	return &ZapLogger{logger: logger, service: zl.service, name: fullName, levels: zl.levels}
+}
+
+// Levels returns the levels controlling this logger and its named sub-loggers.
+func (zl *ZapLogger) Levels() *Levels {
+	return zl.levels
+}
+
+func (zl *ZapLogger) Debug(msg string, fields ...F) {
+	zl.logger.Debug(msg, zapFields(fields)...)
+}
+
+func (zl *ZapLogger) Info(msg string, fields ...F) {
+	zl.logger.Info(msg, zapFields(fields)...)
+}
+
+func (zl *ZapLogger) Warn(msg string, fields ...F) {
+	zl.logger.Warn(msg, zapFields(fields)...)
 }
 
 func (zl *ZapLogger) Error(msg string, fields ...F) {
-	var zapFields []zap.Field
-	for _, field := range fields {
-		zapFields = append(zapFields, zap.Any(field.Key, field.Value))
-	}
-	zl.logger.Error(msg, zapFields...)
+	zl.logger.Error(msg, zapFields(fields)...)
 }
 
 func (zl *ZapLogger) Panic(msg string, fields ...F) {
-	var zapFields []zap.Field
-	for _, field := range fields {
-		zapFields = append(zapFields, zap.Any(field.Key, field.Value))
-	}
-	zl.logger.Panic(msg, zapFields...)
+	zl.logger.Panic(msg, zapFields(fields)...)
 }
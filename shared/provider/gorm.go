@@ -5,7 +5,9 @@ import (
 	"github.com/abdelrahman146/zard/shared/logger"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 	"net/url"
+	"time"
 )
 
 type GormProvider interface {
@@ -24,7 +26,13 @@ func InitGormProvider(address string) GormProvider {
 	if err != nil {
 		logger.GetLogger().Panic("Failed to parse the database URL", logger.Field("address", address), logger.Field("error", err))
 	}
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: NewGormLogger(GormLoggerConfig{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  gormlogger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
 	if err != nil {
 		logger.GetLogger().Panic("Failed to connect to the database", logger.Field("address", address), logger.Field("error", err))
 	}
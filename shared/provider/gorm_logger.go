@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"time"
+)
+
+type GormLoggerConfig struct {
+	SlowThreshold             time.Duration // queries slower than this are logged as warnings, 0 disables it
+	LogLevel                  gormlogger.LogLevel
+	IgnoreRecordNotFoundError bool
+}
+
+type gormLogger struct {
+	config GormLoggerConfig
+}
+
+// NewGormLogger sends GORM's logs to the "repo" logger of logger.FromContext, so queries run with
+// db.WithContext(ctx) are logged with the correlation IDs of ctx.
+// Every query is logged at debug level when LogLevel is gormlogger.Info.
+func NewGormLogger(config GormLoggerConfig) gormlogger.Interface {
+	return &gormLogger{config: config}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	config := l.config
+	config.LogLevel = level
+	return &gormLogger{config: config}
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Info {
+		logger.FromContext(ctx).Named("repo").Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Warn {
+		logger.FromContext(ctx).Named("repo").Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Error {
+		logger.FromContext(ctx).Named("repo").Error(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.config.LogLevel <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	fields := func() []logger.F {
+		sql, rows := fc()
+		return []logger.F{logger.Field("sql", sql), logger.Field("rows", rows), logger.Field("elapsed", elapsed)}
+	}
+	switch {
+	case err != nil && l.config.LogLevel >= gormlogger.Error && !(l.config.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound)):
+		logger.FromContext(ctx).Named("repo").Error("database query failed", append(fields(), logger.Field("error", err))...)
+	case l.config.SlowThreshold > 0 && elapsed > l.config.SlowThreshold && l.config.LogLevel >= gormlogger.Warn:
+		logger.FromContext(ctx).Named("repo").Warn("slow database query", append(fields(), logger.Field("threshold", l.config.SlowThreshold))...)
+	case l.config.LogLevel >= gormlogger.Info:
+		logger.FromContext(ctx).Named("repo").Debug("database query", fields()...)
+	}
+}
@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	fields  []logger.F
+	entries *[]string
+}
+
+func (l *recordingLogger) record(level, msg string) {
+	*l.entries = append(*l.entries, level+" "+msg)
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...logger.F) { l.record("debug", msg) }
+func (l *recordingLogger) Info(msg string, fields ...logger.F)  { l.record("info", msg) }
+func (l *recordingLogger) Warn(msg string, fields ...logger.F)  { l.record("warn", msg) }
+func (l *recordingLogger) Error(msg string, fields ...logger.F) { l.record("error", msg) }
+func (l *recordingLogger) Panic(msg string, fields ...logger.F) { l.record("panic", msg) }
+func (l *recordingLogger) With(fields ...logger.F) logger.Logger {
+	return &recordingLogger{fields: append(l.fields, fields...), entries: l.entries}
+}
+
+func (l *recordingLogger) Named(name string) logger.Logger {
+	return l.With(logger.Field("logger", name))
+}
+
+func TestGormLogger(t *testing.T) {
+	var entries []string
+	ctx := logger.IntoContext(context.Background(), &recordingLogger{entries: &entries})
+	query := func() (string, int64) { return "SELECT 1", 1 }
+	l := NewGormLogger(GormLoggerConfig{SlowThreshold: time.Second, LogLevel: gormlogger.Warn, IgnoreRecordNotFoundError: true})
+
+	l.Trace(ctx, time.Now(), query, nil)
+	l.Trace(ctx, time.Now(), query, gorm.ErrRecordNotFound)
+	l.Trace(ctx, time.Now().Add(-2*time.Second), query, nil)
+	l.Trace(ctx, time.Now(), query, errors.New("connection reset"))
+	l.Info(ctx, "hidden %d", 1)
+	l.LogMode(gormlogger.Info).Trace(ctx, time.Now(), query, nil)
+	l.LogMode(gormlogger.Silent).Trace(ctx, time.Now(), query, errors.New("ignored"))
+
+	assert.Equal(t, []string{"warn slow database query", "error database query failed", "debug database query"}, entries)
+}
@@ -6,7 +6,8 @@ type AuthOTPCreated struct {
 	Value     string        `json:"value"`
 	Target    string        `json:"target"`
 	Reason    string        `json:"reason"`
-	Otp       string        `json:"otp"`
+	Otp       string        `json:"otp" sensitive:"true"`
+	Hash      string        `json:"hash" sensitive:"true"` // carried by the links sent along with the otp
 	Ttl       time.Duration `json:"ttl"`
 	Timestamp time.Time     `json:"timestamp"`
 }
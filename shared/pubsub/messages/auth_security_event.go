@@ -0,0 +1,34 @@
+package messages
+
+import "time"
+
+const (
+	SecurityEventAccountLocked   = "account.locked"
+	SecurityEventAccountUnlocked = "account.unlocked"
+	SecurityEventOtpInvalidated  = "otp.invalidated"
+)
+
+// AuthSecurityEvent reports brute-force protections kicking in, and their reversal by an admin.
+type AuthSecurityEvent struct {
+	Event       string    `json:"event"`
+	UserID      string    `json:"userId,omitempty"`
+	Target      string    `json:"target"` // the email of the account, or the value an OTP was sent to
+	Attempts    int       `json:"attempts"`
+	LockedUntil time.Time `json:"lockedUntil,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+func (m *AuthSecurityEvent) Stream() string {
+	return ""
+}
+
+func (m *AuthSecurityEvent) Subject() string {
+	return "account.auth.security"
+}
+
+func (m *AuthSecurityEvent) Consumer(group string) string {
+	if group != "" {
+		return "account_auth_security_" + group
+	}
+	return "account_auth_security"
+}
@@ -6,4 +6,4 @@ type Message interface {
 	Consumer(group string) string
 }
 
-var Messages = []Message{&AuthOTPCreated{}, &UserCreatedMessage{}}
+var Messages = []Message{&AuthOTPCreated{}, &AuthSecurityEvent{}, &UserCreatedMessage{}}
@@ -1,12 +1,15 @@
 package pubsub
 
 import (
+	"context"
 	"github.com/abdelrahman146/zard/shared/pubsub/messages"
 )
 
+// PubSub publishes the correlation IDs of ctx along with every message, and
+// handlers receive a ctx carrying them for logger.FromContext.
 type PubSub interface {
-	Publish(message messages.Message) error
-	Subscribe(message messages.Message, handler func(received []byte) error) (Subscription, error)
+	Publish(ctx context.Context, message messages.Message) error
+	Subscribe(message messages.Message, handler func(ctx context.Context, received []byte) error) (Subscription, error)
 }
 
 type Subscription interface {
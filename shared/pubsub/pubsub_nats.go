@@ -1,7 +1,9 @@
 package pubsub
 
 import (
+	"context"
 	"encoding/json"
+	"github.com/abdelrahman146/zard/shared/errs/report"
 	"github.com/abdelrahman146/zard/shared/logger"
 	"github.com/abdelrahman146/zard/shared/provider"
 	"github.com/abdelrahman146/zard/shared/pubsub/messages"
@@ -48,28 +50,40 @@ func setupStreams(js nats.JetStreamContext) {
 			Storage:   nats.FileStorage,
 		})
 		if err != nil {
-			logger.GetLogger().Panic("failed to create stream", logger.Field("error", err))
+			logger.GetLogger().Named("pubsub").Panic("failed to create stream", logger.Field("error", err))
 		}
 	}
 }
 
-func (p *natsPubSub) Publish(message messages.Message) error {
+func (p *natsPubSub) Publish(ctx context.Context, message messages.Message) error {
 	data, _ := json.Marshal(message)
+	msg := &nats.Msg{Subject: message.Subject(), Data: data, Header: logger.CorrelationHeader(ctx)}
 	switch {
 	case message.Stream() == "":
-		return p.nts.GetConn().Publish(message.Subject(), data)
+		return p.nts.GetConn().PublishMsg(msg)
 	default:
-		_, err := p.nts.GetJs().Publish(message.Subject(), data)
+		_, err := p.nts.GetJs().PublishMsg(msg)
 		return err
 	}
 }
 
-func (p *natsPubSub) Subscribe(message messages.Message, handler func(received []byte) error) (Subscription, error) {
+// handle runs handler with the correlation IDs of msg and logs its failure, since it is retried rather than returned to anyone.
+func handle(msg *nats.Msg, handler func(ctx context.Context, received []byte) error) error {
+	ctx := logger.ContextFromHeader(context.Background(), msg.Header)
+	err := handler(ctx, msg.Data)
+	// server errors are reported with their stack trace, other failures are only logged
+	if report.Report(ctx, err) == "" && err != nil {
+		logger.FromContext(ctx).Named("pubsub").Warn("failed to handle message", logger.Field("subject", msg.Subject), logger.Field("error", err))
+	}
+	return err
+}
+
+func (p *natsPubSub) Subscribe(message messages.Message, handler func(ctx context.Context, received []byte) error) (Subscription, error) {
 	consumer := message.Consumer(p.config.Group)
 	switch {
 	case message.Stream() == "":
 		sub, err := p.nts.GetConn().QueueSubscribe(message.Subject(), consumer, func(msg *nats.Msg) {
-			if err := handler(msg.Data); err != nil {
+			if err := handle(msg, handler); err != nil {
 				_ = msg.Nak()
 			} else {
 				_ = msg.Ack()
@@ -78,7 +92,7 @@ func (p *natsPubSub) Subscribe(message messages.Message, handler func(received [
 		return sub, err
 	default:
 		sub, err := p.nts.GetJs().QueueSubscribe(message.Subject(), consumer, func(natsMsg *nats.Msg) {
-			if err := handler(natsMsg.Data); err != nil {
+			if err := handle(natsMsg, handler); err != nil {
 				_ = natsMsg.NakWithDelay(p.config.ResendAfter)
 			} else {
 				_ = natsMsg.Ack()
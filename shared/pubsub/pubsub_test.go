@@ -1,6 +1,7 @@
 package pubsub
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/abdelrahman146/zard/shared/pubsub/messages"
 	"github.com/stretchr/testify/assert"
@@ -14,11 +15,11 @@ func TestPubsub(t *testing.T) {
 	activity := &messages.NewActivity{
 		AppID: "test",
 	}
-	m.EXPECT().Publish(activity).Return(nil)
+	m.EXPECT().Publish(gomock.Any(), activity).Return(nil)
 	m.EXPECT().Subscribe(activity, gomock.Any()).Return(nil, nil)
-	err := m.Publish(activity)
+	err := m.Publish(context.Background(), activity)
 	assert.NoError(t, err)
-	_, err = m.Subscribe(activity, func(received []byte) error {
+	_, err = m.Subscribe(activity, func(ctx context.Context, received []byte) error {
 		a := &messages.NewActivity{}
 		err := json.Unmarshal(received, a)
 		return err
@@ -0,0 +1,79 @@
+package query
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// Scope applies spec to a list query: Where then Paginate.
+func Scope(spec Spec) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(Where(spec), Paginate(spec))
+	}
+}
+
+// Where applies the filters and the search of spec, and is the scope to count the total with.
+// Columns come from Options and values are bound as parameters, so requests cannot inject SQL.
+func Where(spec Spec) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, f := range spec.Filters {
+			values := make([]interface{}, len(f.Values))
+			for i, v := range f.Values {
+				values[i] = v
+			}
+			db = db.Where(clause.IN{Column: clause.Column{Name: f.Column}, Values: values})
+		}
+		if spec.Search != "" && len(spec.searchColumns) > 0 {
+			pattern := "%" + escapeLike(spec.Search) + "%"
+			matches := make([]clause.Expression, len(spec.searchColumns))
+			for i, column := range spec.searchColumns {
+				matches[i] = clause.Like{Column: clause.Column{Name: column}, Value: pattern}
+			}
+			db = db.Where(clause.Or(matches...))
+		}
+		return db
+	}
+}
+
+// Paginate orders the query by the sort of spec and selects its page, by cursor or by offset.
+func Paginate(spec Spec) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, s := range spec.Sort {
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: s.Column}, Desc: s.Desc})
+		}
+		if spec.IsCursor() {
+			db = db.Where(after(spec.Sort, spec.After))
+		}
+		if spec.Limit > 0 {
+			db = db.Limit(spec.Limit)
+		}
+		return db.Offset(spec.Offset())
+	}
+}
+
+// after matches the rows sorted after values, e.g. for a sort by a then b:
+// a > ? OR (a = ? AND b > ?).
+func after(sorts []Sort, values []interface{}) clause.Expression {
+	alternatives := make([]clause.Expression, len(sorts))
+	for i, s := range sorts {
+		conditions := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, clause.Eq{Column: clause.Column{Name: sorts[j].Column}, Value: values[j]})
+		}
+		column := clause.Column{Name: s.Column}
+		if s.Desc {
+			conditions = append(conditions, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			conditions = append(conditions, clause.Gt{Column: column, Value: values[i]})
+		}
+		alternatives[i] = clause.And(conditions...)
+	}
+	return clause.Or(alternatives...)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
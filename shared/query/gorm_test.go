@@ -0,0 +1,39 @@
+package query
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type record struct {
+	ID string
+}
+
+func dryRun(t *testing.T, spec Spec) (string, []interface{}) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	stmt := db.Table("users").Where(`"orgId" = ?`, "org_1").Scopes(Scope(spec)).Find(&[]record{}).Statement
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func TestScope(t *testing.T) {
+	spec, err := Parse(map[string]string{"page": "2", "limit": "10", "sort": "name", "filter[status]": "active", "q": "50%"}, testOptions)
+	require.NoError(t, err)
+	sql, vars := dryRun(t, spec)
+	assert.Equal(t, `SELECT * FROM "users" WHERE "orgId" = $1 AND "status" = $2 AND ("name" LIKE $3 OR "email" LIKE $4) ORDER BY "name","id" LIMIT $5 OFFSET $6`, sql)
+	assert.Equal(t, []interface{}{"org_1", "active", `%50\%%`, `%50\%%`, 10, 10}, vars)
+}
+
+func TestScope_Cursor(t *testing.T) {
+	spec, err := Parse(map[string]string{"limit": "2", "sort": "name"}, testOptions)
+	require.NoError(t, err)
+	cursor := NextCursor(spec, []item{{ID: "usr_1", Name: "Adam"}, {ID: "usr_2", Name: "Jane"}})
+	spec, err = Parse(map[string]string{"limit": "2", "sort": "name", "cursor": cursor}, testOptions)
+	require.NoError(t, err)
+	sql, vars := dryRun(t, spec)
+	assert.Equal(t, `SELECT * FROM "users" WHERE "orgId" = $1 AND ("name" > $2 OR ("name" = $3 AND "id" > $4)) ORDER BY "name","id" LIMIT $5`, sql)
+	assert.Equal(t, []interface{}{"org_1", "Jane", "Jane", "usr_2", 2}, vars)
+}
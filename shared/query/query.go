@@ -0,0 +1,222 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Options whitelists what clients may sort and filter a list by. Fields are named as in the JSON
+// of the listed items, and mapped to their columns, which are never taken from the request.
+type Options struct {
+	DefaultLimit int               // defaults to DefaultLimit
+	MaxLimit     int               // caps the limit clients ask for, defaults to MaxLimit
+	DefaultSort  string            // e.g. "-createdAt", used when the request has no sort
+	Sortable     map[string]string // field -> column
+	Filterable   map[string]string // field -> column
+	Searchable   []string          // columns matched by the q parameter
+	IDColumn     string            // breaks ties between equal sort values, defaults to "id"
+}
+
+type Sort struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// Filter matches rows whose column equals one of values.
+type Filter struct {
+	Field  string
+	Column string
+	Values []string
+}
+
+// Spec is a parsed list request: which page, in which order, and which items.
+type Spec struct {
+	Page    int
+	Limit   int
+	Sort    []Sort // ends with the ID column, so that the order is total
+	Filters []Filter
+	Search  string
+	// After holds the sort values of the last item of the previous page when paginating by cursor.
+	After          []interface{}
+	searchColumns  []string
+	sortSignature  string
+	cursorPaginate bool
+}
+
+// IsCursor reports whether the spec pages by cursor rather than by page number.
+func (s Spec) IsCursor() bool {
+	return s.cursorPaginate
+}
+
+// Offset returns how many items come before the page, zero when paginating by cursor.
+func (s Spec) Offset() int {
+	if s.cursorPaginate || s.Page < 1 {
+		return 0
+	}
+	return (s.Page - 1) * s.Limit
+}
+
+// FromRequest parses the query string of ctx, see Parse.
+func FromRequest(ctx *fiber.Ctx, opts Options) (Spec, error) {
+	return Parse(ctx.Queries(), opts)
+}
+
+// Parse reads a list request from query parameters:
+//   - page and limit, e.g. page=2&limit=50, limits over the maximum are capped
+//   - cursor, the nextCursor of the previous page, which takes over from page
+//   - sort, comma separated fields with a leading - for descending order, e.g. sort=-createdAt,name
+//   - filter[field], comma separated values the field has to equal one of, e.g. filter[status]=active
+//   - q, a keyword the searchable columns are matched against
+//
+// Every invalid parameter is reported in a single validation error.
+func Parse(params map[string]string, opts Options) (Spec, error) {
+	opts = withDefaults(opts)
+	problems := make(map[string]string)
+	spec := Spec{Page: 1, Limit: opts.DefaultLimit, Search: strings.TrimSpace(params["q"]), searchColumns: opts.Searchable}
+	if raw, ok := params["page"]; ok {
+		if page, err := strconv.Atoi(raw); err != nil || page < 1 {
+			problems["page"] = "must be a positive number"
+		} else {
+			spec.Page = page
+		}
+	}
+	if raw, ok := params["limit"]; ok {
+		if limit, err := strconv.Atoi(raw); err != nil || limit < 1 {
+			problems["limit"] = "must be a positive number"
+		} else {
+			spec.Limit = min(limit, opts.MaxLimit)
+		}
+	}
+	sortParam, ok := params["sort"]
+	if !ok || sortParam == "" {
+		sortParam = opts.DefaultSort
+	}
+	spec.Sort, spec.sortSignature = parseSort(sortParam, opts, problems)
+	for key, value := range params {
+		field, ok := strings.CutPrefix(key, "filter[")
+		if !ok || !strings.HasSuffix(field, "]") {
+			continue
+		}
+		field = strings.TrimSuffix(field, "]")
+		column, ok := opts.Filterable[field]
+		if !ok {
+			problems[key] = "is not a filterable field"
+			continue
+		}
+		spec.Filters = append(spec.Filters, Filter{Field: field, Column: column, Values: strings.Split(value, ",")})
+	}
+	if raw := params["cursor"]; raw != "" {
+		after, err := decodeCursor(raw, spec.sortSignature, len(spec.Sort))
+		if err != nil {
+			problems["cursor"] = err.Error()
+		}
+		spec.After = after
+		spec.cursorPaginate = true
+	}
+	if len(problems) > 0 {
+		return Spec{}, errs.NewValidationError("invalid list query", problems)
+	}
+	return spec, nil
+}
+
+func withDefaults(opts Options) Options {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = DefaultLimit
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = MaxLimit
+	}
+	if opts.IDColumn == "" {
+		opts.IDColumn = "id"
+	}
+	return opts
+}
+
+// parseSort returns the sort of param followed by the ID column, and a signature binding cursors to it.
+func parseSort(param string, opts Options, problems map[string]string) ([]Sort, string) {
+	var sorts []Sort
+	hasID := false
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		column, ok := opts.Sortable[field]
+		if !ok {
+			problems["sort"] = "cannot sort by " + field
+			continue
+		}
+		hasID = hasID || column == opts.IDColumn
+		sorts = append(sorts, Sort{Field: field, Column: column, Desc: desc})
+	}
+	if !hasID {
+		desc := len(sorts) > 0 && sorts[len(sorts)-1].Desc
+		sorts = append(sorts, Sort{Field: "id", Column: opts.IDColumn, Desc: desc})
+	}
+	signature := make([]string, len(sorts))
+	for i, s := range sorts {
+		signature[i] = s.Field
+		if s.Desc {
+			signature[i] = "-" + s.Field
+		}
+	}
+	return sorts, strings.Join(signature, ",")
+}
+
+type cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+func decodeCursor(raw string, signature string, size int) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errors.New("is malformed")
+	}
+	var c cursor
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, errors.New("is malformed")
+	}
+	if c.Sort != signature || len(c.Values) != size {
+		return nil, errors.New("does not match the sort")
+	}
+	return c.Values, nil
+}
+
+// NextCursor returns the cursor of the page after items, or "" when items is the last page.
+// The sort values are read from the JSON of the last item.
+func NextCursor[T any](spec Spec, items []T) string {
+	if len(items) == 0 || len(items) < spec.Limit {
+		return ""
+	}
+	data, err := json.Marshal(items[len(items)-1])
+	if err != nil {
+		return ""
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return ""
+	}
+	c := cursor{Sort: spec.sortSignature, Values: make([]interface{}, len(spec.Sort))}
+	for i, s := range spec.Sort {
+		c.Values[i] = fields[s.Field]
+	}
+	data, err = json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,81 @@
+package query
+
+import (
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var testOptions = Options{
+	DefaultSort: "-createdAt",
+	Sortable:    map[string]string{"id": "id", "name": "name", "createdAt": "createdAt"},
+	Filterable:  map[string]string{"status": "status"},
+	Searchable:  []string{"name", "email"},
+}
+
+type item struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+}
+
+func TestParse_Defaults(t *testing.T) {
+	spec, err := Parse(map[string]string{}, testOptions)
+	require.NoError(t, err)
+	assert.Equal(t, 1, spec.Page)
+	assert.Equal(t, DefaultLimit, spec.Limit)
+	assert.Equal(t, 0, spec.Offset())
+	assert.False(t, spec.IsCursor())
+	assert.Equal(t, []Sort{{Field: "createdAt", Column: "createdAt", Desc: true}, {Field: "id", Column: "id", Desc: true}}, spec.Sort)
+}
+
+func TestParse(t *testing.T) {
+	spec, err := Parse(map[string]string{
+		"page":           "3",
+		"limit":          "500",
+		"sort":           "name,-id",
+		"filter[status]": "active,invited",
+		"q":              " jane ",
+	}, testOptions)
+	require.NoError(t, err)
+	assert.Equal(t, MaxLimit, spec.Limit, "limits are capped")
+	assert.Equal(t, 2*MaxLimit, spec.Offset())
+	assert.Equal(t, []Sort{{Field: "name", Column: "name"}, {Field: "id", Column: "id", Desc: true}}, spec.Sort)
+	assert.Equal(t, []Filter{{Field: "status", Column: "status", Values: []string{"active", "invited"}}}, spec.Filters)
+	assert.Equal(t, "jane", spec.Search)
+}
+
+func TestParse_RejectsInvalidParameters(t *testing.T) {
+	_, err := Parse(map[string]string{
+		"page":             "0",
+		"limit":            "ten",
+		"sort":             "password",
+		"filter[password]": "secret",
+		"cursor":           "not-a-cursor",
+	}, testOptions)
+	require.ErrorIs(t, err, errs.ErrValidation)
+	var customErr errs.CustomError
+	require.ErrorAs(t, err, &customErr)
+	for _, param := range []string{"page", "limit", "sort", "filter[password]", "cursor"} {
+		assert.Contains(t, customErr.Fields, param)
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	spec, err := Parse(map[string]string{"limit": "2", "sort": "name"}, testOptions)
+	require.NoError(t, err)
+	items := []item{{ID: "usr_1", Name: "Adam"}, {ID: "usr_2", Name: "Jane"}}
+	cursor := NextCursor(spec, items)
+	require.NotEmpty(t, cursor)
+	assert.Empty(t, NextCursor(spec, items[:1]), "a short page is the last one")
+
+	next, err := Parse(map[string]string{"limit": "2", "sort": "name", "cursor": cursor}, testOptions)
+	require.NoError(t, err)
+	assert.True(t, next.IsCursor())
+	assert.Equal(t, []interface{}{"Jane", "usr_2"}, next.After)
+	assert.Equal(t, 0, next.Offset())
+
+	_, err = Parse(map[string]string{"sort": "-name", "cursor": cursor}, testOptions)
+	assert.Error(t, err, "a cursor only continues the sort it was made for")
+}
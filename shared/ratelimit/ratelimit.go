@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/config"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy allows Limit requests per Window, a policy without a window does not limit anything.
+// Name scopes the counters, so route groups sharing a cache need distinct names.
+type Policy struct {
+	Name   string        `json:"name"`
+	Limit  int           `json:"limit" config:"limit" validate:"gte=0"`
+	Window time.Duration `json:"window" config:"window" validate:"gte=0"`
+}
+
+// LoadPolicy returns policy with the limit and window set under app.rateLimit.<name> in conf,
+// e.g. app.rateLimit.login.window, keeping the values of policy for the keys that are not set.
+func LoadPolicy(conf config.Config, policy Policy) (Policy, error) {
+	err := config.Bind(config.Sub(conf, "app.rateLimit."+policy.Name), &policy)
+	return policy, err
+}
+
+// Result is the outcome of Limiter.Allow, and what the RateLimit-* headers are made of.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the current window ends
+	RetryAfter time.Duration // until a request is allowed again, zero when allowed
+}
+
+type Limiter interface {
+	Policy() Policy
+	// Allow counts a request of key and reports whether it is within the policy.
+	Allow(key string) (Result, error)
+}
+
+type slidingWindowLimiter struct {
+	cache  cache.Cache
+	policy Policy
+	now    func() time.Time
+}
+
+// NewLimiter returns a sliding window limiter keeping its counters in c, so that every instance
+// of a service shares them. A request is weighed against the requests of the current window plus
+// the share of the previous window that still overlaps the last policy.Window. Rejected requests
+// count too, so clients hammering a limited route stay limited.
+func NewLimiter(c cache.Cache, policy Policy) Limiter {
+	return &slidingWindowLimiter{cache: c, policy: policy, now: time.Now}
+}
+
+func (l *slidingWindowLimiter) Policy() Policy {
+	return l.policy
+}
+
+func (l *slidingWindowLimiter) Allow(key string) (Result, error) {
+	window := l.policy.Window
+	if window <= 0 {
+		return Result{Allowed: true, Limit: l.policy.Limit, Remaining: l.policy.Limit}, nil
+	}
+	now := l.now()
+	index := now.UnixNano() / int64(window)
+	elapsed := time.Duration(now.UnixNano() - index*int64(window))
+	current, err := cache.Increment(l.cache, l.keyPath(key, index), 1, 2*window)
+	if err != nil {
+		return Result{}, err
+	}
+	if current == 1 {
+		// best effort, the counters are only read for two windows
+		_ = l.cache.Delete(l.keyPath(key, index-2))
+	}
+	previous := cache.GetCounter(l.cache, l.keyPath(key, index-1))
+	weight := float64(window-elapsed) / float64(window)
+	estimate := float64(previous)*weight + float64(current)
+	limit := float64(l.policy.Limit)
+	result := Result{
+		Allowed:   estimate <= limit,
+		Limit:     l.policy.Limit,
+		Remaining: int(math.Max(0, math.Floor(limit-estimate))),
+		Reset:     window - elapsed,
+	}
+	if !result.Allowed {
+		result.RetryAfter = l.retryAfter(float64(previous), float64(current), elapsed)
+	}
+	return result, nil
+}
+
+// retryAfter returns how long until the estimate of the next request falls within the limit.
+func (l *slidingWindowLimiter) retryAfter(previous, current float64, elapsed time.Duration) time.Duration {
+	window := float64(l.policy.Window)
+	limit := float64(l.policy.Limit)
+	if current < limit {
+		// the previous window decays until previous*(window-e)/window + current+1 <= limit
+		at := window * (1 - (limit-current-1)/previous)
+		return time.Duration(math.Max(0, at-float64(elapsed)))
+	}
+	// the current window becomes the previous one, and decays the same way
+	at := window * (1 - (limit-1)/current)
+	return time.Duration(window-float64(elapsed)) + time.Duration(math.Max(0, at))
+}
+
+func (l *slidingWindowLimiter) keyPath(key string, index int64) []string {
+	return []string{"ratelimit", l.policy.Name, sanitize(key), strconv.FormatInt(index, 10)}
+}
+
+// sanitize keeps keys within the characters cache keys accept, IPv6 addresses have colons.
+func sanitize(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, key)
+}
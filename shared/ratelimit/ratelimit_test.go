@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"github.com/abdelrahman146/zard/shared/cache"
+	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(c cache.Cache, policy Policy, now *time.Time) Limiter {
+	l := NewLimiter(c, policy).(*slidingWindowLimiter)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(0, 0).Add(time.Hour)
+	limiter := newTestLimiter(cache.NewMemoryCache(), Policy{Name: "login", Limit: 3, Window: time.Minute}, &now)
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow("ip:127.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+		assert.Equal(t, time.Minute, result.Reset)
+	}
+	result, err := limiter.Allow("ip:127.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Greater(t, result.RetryAfter, time.Minute, "the requests of this window still weigh on the next one")
+
+	result, _ = limiter.Allow("ip:::1")
+	assert.True(t, result.Allowed, "keys are limited separately")
+
+	// the previous window decays: 3 of its requests and 1 of the next one are still too many at a third
+	now = now.Add(time.Minute + 20*time.Second)
+	result, _ = limiter.Allow("ip:127.0.0.1")
+	assert.False(t, result.Allowed)
+
+	now = now.Add(result.RetryAfter)
+	result, _ = limiter.Allow("ip:127.0.0.1")
+	assert.True(t, result.Allowed, "retry after is long enough")
+
+	now = now.Add(2 * time.Minute)
+	result, _ = limiter.Allow("ip:127.0.0.1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestLimiter_CleansUpOldWindows(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := cache.NewMemoryCache()
+	limiter := newTestLimiter(c, Policy{Name: "otp", Limit: 1, Window: time.Second}, &now)
+	for i := 0; i < 10; i++ {
+		_, err := limiter.Allow("user:usr_1")
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+	keys, err := c.Keys(nil)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestLimiter_WithoutWindowAllowsEverything(t *testing.T) {
+	now := time.Now()
+	limiter := newTestLimiter(cache.NewMemoryCache(), Policy{Name: "off"}, &now)
+	result, err := limiter.Allow("ip:127.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestLoadPolicy(t *testing.T) {
+	conf := config.NewViperConfig()
+	conf.Set("app.rateLimit.login.window", "10m")
+	policy, err := LoadPolicy(conf, Policy{Name: "login", Limit: 10, Window: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, Policy{Name: "login", Limit: 10, Window: 10 * time.Minute}, policy)
+
+	conf.Set("app.rateLimit.login.limit", -1)
+	_, err = LoadPolicy(conf, policy)
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package redact
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+	Pin      string `json:"pin" sensitive:"true"`
+	Hint     string `json:"-"`
+}
+
+type session struct {
+	credentials
+	ID        string            `json:"id"`
+	ApiKeyTTL time.Duration     `json:"apiKeyTTL"`
+	Headers   map[string]string `json:"headers"`
+	Devices   []*credentials    `json:"devices"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+func TestIsSensitiveKey(t *testing.T) {
+	for _, key := range []string{"password", "app.secret", "accessToken", "apiKey", "api_key", "otp", "Authorization"} {
+		assert.True(t, IsSensitiveKey(key), key)
+	}
+	for _, key := range []string{"email", "app.auth.otpTTL", "apiKeyTTL", "tokens.count"} {
+		assert.False(t, IsSensitiveKey(key), key)
+	}
+}
+
+func TestValue_Struct(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	value := Value("session", &session{
+		credentials: credentials{Email: "jane@example.com", Password: "hunter2", Pin: "1234", Hint: "pet"},
+		ID:          "ses_1",
+		ApiKeyTTL:   time.Hour,
+		Headers:     map[string]string{"Authorization": "Bearer abc", "Accept": "*/*"},
+		Devices:     []*credentials{{Email: "jane@example.com"}},
+		CreatedAt:   created,
+	})
+	assert.Equal(t, map[string]interface{}{
+		"email":     "jane@example.com",
+		"password":  Mask,
+		"pin":       Mask,
+		"id":        "ses_1",
+		"apiKeyTTL": time.Hour,
+		"headers":   map[string]interface{}{"Authorization": Mask, "Accept": "*/*"},
+		"devices":   []interface{}{map[string]interface{}{"email": "jane@example.com", "password": nil, "pin": nil}},
+		"createdAt": created,
+	}, value)
+}
+
+func TestValue_LeavesHarmlessValuesUnchanged(t *testing.T) {
+	type point struct{ X, Y int }
+	p := point{X: 1, Y: 2}
+	assert.Equal(t, p, Value("point", p))
+	assert.Equal(t, 42, Value("count", 42))
+	assert.Equal(t, Mask, Value("user.password", "hunter2"))
+	assert.Nil(t, Value("nothing", nil))
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t,
+		"dial postgres://zard:xxxxx@db:5432/zard failed, password="+Mask+` {"apiKey": "`+Mask+`"}`,
+		String(`dial postgres://zard:hunter2@db:5432/zard failed, password=hunter2 {"apiKey": "zky_1"}`))
+	assert.Equal(t, "invalid login: "+Mask, Value("error", errors.New("invalid login: "+Mask)))
+	assert.Equal(t, "token: "+Mask, Value("error", errors.New("token: abc")))
+}
@@ -0,0 +1,52 @@
+package rpc
+
+import "time"
+
+type BulkheadConfig struct {
+	MaxConcurrent int           // in-flight requests allowed per subject, 0 disables the bulkhead
+	MaxWait       time.Duration // how long a request waits for a free slot before being rejected
+}
+
+type bulkhead struct {
+	slots   chan struct{}
+	maxWait time.Duration
+}
+
+func newBulkhead(config BulkheadConfig) *bulkhead {
+	if config.MaxConcurrent <= 0 {
+		return &bulkhead{}
+	}
+	return &bulkhead{
+		slots:   make(chan struct{}, config.MaxConcurrent),
+		maxWait: config.MaxWait,
+	}
+}
+
+func (b *bulkhead) acquire() bool {
+	if b.slots == nil {
+		return true
+	}
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if b.maxWait <= 0 {
+		return false
+	}
+	timer := time.NewTimer(b.maxWait)
+	defer timer.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (b *bulkhead) release() {
+	if b.slots == nil {
+		return
+	}
+	<-b.slots
+}
@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"sync"
+	"time"
+)
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+type BreakerConfig struct {
+	FailureThreshold int           // consecutive failures before the breaker opens, 0 disables the breaker
+	OpenTimeout      time.Duration // how long the breaker stays open before allowing half-open probes
+	HalfOpenProbes   int           // concurrent probes allowed while half-open, successes needed to close again
+}
+
+type circuitBreaker struct {
+	mu       sync.Mutex
+	config   BreakerConfig
+	state    BreakerState
+	failures int
+	probes   int
+	passed   int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func newCircuitBreaker(config BreakerConfig, now func() time.Time) *circuitBreaker {
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = 1
+	}
+	return &circuitBreaker{config: config, now: now}
+}
+
+// allow reports whether a call may proceed. Every allowed call must be
+// followed by exactly one call to done.
+func (b *circuitBreaker) allow() bool {
+	if b.config.FailureThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.config.OpenTimeout {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probes = 0
+		b.passed = 0
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probes >= b.config.HalfOpenProbes {
+			return false
+		}
+		b.probes++
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) done(failed bool) {
+	if b.config.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerHalfOpen:
+		b.probes--
+		if failed {
+			b.trip()
+			return
+		}
+		b.passed++
+		if b.passed >= b.config.HalfOpenProbes {
+			b.state = BreakerClosed
+			b.failures = 0
+		}
+	case BreakerClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.trip()
+		}
+	}
+}
+
+func (b *circuitBreaker) trip() {
+	b.state = BreakerOpen
+	b.openedAt = b.now()
+	b.failures = 0
+	b.probes = 0
+	b.passed = 0
+}
+
+func (b *circuitBreaker) currentState() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
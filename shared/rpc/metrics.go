@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type Stats struct {
+	Requests  int64         `json:"requests"`
+	Successes int64         `json:"successes"`
+	Failures  int64         `json:"failures"`
+	Retries   int64         `json:"retries"`
+	Rejected  int64         `json:"rejected"`
+	InFlight  int64         `json:"inFlight"`
+	Latency   time.Duration `json:"latency"` // cumulative latency of completed attempts
+	Breaker   string        `json:"breaker"`
+}
+
+type metrics struct {
+	requests  atomic.Int64
+	successes atomic.Int64
+	failures  atomic.Int64
+	retries   atomic.Int64
+	rejected  atomic.Int64
+	inFlight  atomic.Int64
+	latency   atomic.Int64
+}
+
+func (m *metrics) observe(start time.Time) {
+	m.latency.Add(int64(time.Since(start)))
+}
+
+func (m *metrics) snapshot() Stats {
+	return Stats{
+		Requests:  m.requests.Load(),
+		Successes: m.successes.Load(),
+		Failures:  m.failures.Load(),
+		Retries:   m.retries.Load(),
+		Rejected:  m.rejected.Load(),
+		InFlight:  m.inFlight.Load(),
+		Latency:   time.Duration(m.latency.Load()),
+	}
+}
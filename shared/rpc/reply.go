@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/errs/report"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+)
+
+// Reply is the envelope every handler responds with, so errors raised by a
+// remote use case reach the caller with their original kind and fields.
+type Reply struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ReplyError     `json:"error,omitempty"`
+}
+
+type ReplyError struct {
+	Message  string            `json:"message"`
+	Code     string            `json:"code"`
+	Desc     string            `json:"desc"`
+	HttpCode int               `json:"httpCode"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+func NewReply(result interface{}, err error) []byte {
+	reply := Reply{}
+	if err == nil {
+		data, mErr := json.Marshal(result)
+		if mErr != nil {
+			err = errs.NewInternalError("unable to encode rpc result", mErr)
+		} else {
+			reply.Result = data
+		}
+	}
+	if err != nil {
+		reply.Error = toReplyError(err)
+	}
+	data, _ := json.Marshal(reply)
+	return data
+}
+
+func toReplyError(err error) *ReplyError {
+	customErr := errs.HandleError(err)
+	return &ReplyError{
+		Message:  customErr.Message(),
+		Code:     customErr.Code,
+		Desc:     customErr.Desc,
+		HttpCode: customErr.HttpCode,
+		Fields:   customErr.Fields,
+	}
+}
+
+func fromReplyError(e *ReplyError) error {
+	return errs.NewCustomError(e.Message, e.Code, e.Desc, e.HttpCode, e.Fields)
+}
+
+func ParseReply(data []byte, result interface{}) error {
+	reply := Reply{}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return errs.NewInternalError("unable to decode rpc reply", err)
+	}
+	if reply.Error != nil {
+		return fromReplyError(reply.Error)
+	}
+	if result == nil || len(reply.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(reply.Result, result); err != nil {
+		return errs.NewInternalError("unable to decode rpc result", err)
+	}
+	return nil
+}
+
+// Handler adapts a typed handler to the raw handler expected by RPC.Handle.
+// Server errors are logged with the correlation IDs of the request.
+func Handler[T any](handle func(ctx context.Context, req *T) (interface{}, error)) func(ctx context.Context, req []byte) []byte {
+	return func(ctx context.Context, data []byte) []byte {
+		req := new(T)
+		if err := json.Unmarshal(data, req); err != nil {
+			return NewReply(nil, errs.NewBadRequestError("invalid rpc request", err))
+		}
+		result, err := handle(ctx, req)
+		report.Report(ctx, err)
+		return NewReply(result, err)
+	}
+}
+
+// Call sends req and decodes the reply into R.
+func Call[R any](ctx context.Context, r RPC, req requests.Request) (*R, error) {
+	data, err := r.Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	result := new(R)
+	if err = ParseReply(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
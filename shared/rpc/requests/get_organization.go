@@ -0,0 +1,28 @@
+package requests
+
+type GetOrganizationRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+func (r *GetOrganizationRequest) Subject() string {
+	return "identity.organization.get"
+}
+
+func (r *GetOrganizationRequest) Consumer(group string) string {
+	return "identity_organization_get_" + group
+}
+
+func (r *GetOrganizationRequest) Idempotent() bool {
+	return true
+}
+
+type GetOrganizationResponse struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Website *string `json:"website"`
+	Email   string  `json:"email"`
+	Phone   *string `json:"phone"`
+	Country string  `json:"country"`
+	City    string  `json:"city"`
+	Address string  `json:"address"`
+}
@@ -12,7 +12,17 @@ func (r *GetUserRequest) Consumer(group string) string {
 	return "identity_user_get_" + group
 }
 
+func (r *GetUserRequest) Idempotent() bool {
+	return true
+}
+
 type GetUserResponse struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Email           string  `json:"email"`
+	Phone           *string `json:"phone"`
+	IsEmailVerified bool    `json:"isEmailVerified"`
+	IsPhoneVerified bool    `json:"isPhoneVerified"`
+	Active          bool    `json:"active"`
+	OrgID           string  `json:"orgId"`
 }
@@ -0,0 +1,22 @@
+package requests
+
+type GetUsersRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=100,dive,required"`
+}
+
+func (r *GetUsersRequest) Subject() string {
+	return "identity.user.batch"
+}
+
+func (r *GetUsersRequest) Consumer(group string) string {
+	return "identity_user_batch_" + group
+}
+
+func (r *GetUsersRequest) Idempotent() bool {
+	return true
+}
+
+type GetUsersResponse struct {
+	Users   []GetUserResponse `json:"users"`
+	Missing []string          `json:"missing,omitempty"`
+}
@@ -0,0 +1,24 @@
+package requests
+
+type GetWorkspaceRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+func (r *GetWorkspaceRequest) Subject() string {
+	return "identity.workspace.get"
+}
+
+func (r *GetWorkspaceRequest) Consumer(group string) string {
+	return "identity_workspace_get_" + group
+}
+
+func (r *GetWorkspaceRequest) Idempotent() bool {
+	return true
+}
+
+type GetWorkspaceResponse struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Website *string `json:"website"`
+	OrgID   string  `json:"orgId"`
+}
@@ -5,4 +5,17 @@ type Request interface {
 	Consumer(group string) string
 }
 
-var Requests = []Request{&GetUserRequest{}}
+// IdempotentRequest is implemented by requests that can be safely retried
+// because handling them more than once has no side effects.
+type IdempotentRequest interface {
+	Request
+	Idempotent() bool
+}
+
+var Requests = []Request{
+	&GetUserRequest{},
+	&GetUsersRequest{},
+	&GetWorkspaceRequest{},
+	&GetOrganizationRequest{},
+	&ResolveApiKeyRequest{},
+}
@@ -0,0 +1,21 @@
+package requests
+
+type ResolveApiKeyRequest struct {
+	ApiKey string `json:"apiKey" validate:"required" sensitive:"true"`
+}
+
+func (r *ResolveApiKeyRequest) Subject() string {
+	return "identity.workspace.apikey.resolve"
+}
+
+func (r *ResolveApiKeyRequest) Consumer(group string) string {
+	return "identity_workspace_apikey_resolve_" + group
+}
+
+func (r *ResolveApiKeyRequest) Idempotent() bool {
+	return true
+}
+
+type ResolveApiKeyResponse struct {
+	WorkspaceID string `json:"workspaceId"`
+}
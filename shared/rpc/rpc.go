@@ -1,10 +1,28 @@
 package rpc
 
 import (
+	"context"
 	"github.com/abdelrahman146/zard/shared/rpc/requests"
+	"time"
 )
 
+// RPC sends the correlation IDs of the caller's ctx along with every request, and
+// handlers receive a ctx carrying them, so logger.FromContext(ctx) ties both sides together.
 type RPC interface {
-	Request(req requests.Request) (resp []byte, err error)
-	Handle(req requests.Request, handler func(req []byte) (resp []byte)) error
+	Request(ctx context.Context, req requests.Request) (resp []byte, err error)
+	Handle(req requests.Request, handler func(ctx context.Context, req []byte) (resp []byte)) error
+	// Gather sends req to every queue group handling it and collects replies until the gather
+	// timeout or the deadline of ctx passes, or the maximum number of replies is reached.
+	// Cancelling ctx fails it along with the replies collected so far.
+	Gather(ctx context.Context, req requests.Request, opts GatherOptions) (resps [][]byte, err error)
+	// Stream sends req to the responders of every queue group handling it and passes every chunk
+	// the first of them to answer sends to handler, within the deadline of ctx.
+	// The timeout of the transport bounds the wait for each chunk rather than the whole stream.
+	Stream(ctx context.Context, req requests.Request, handler func(chunk []byte) error) error
+	HandleStream(req requests.Request, handler func(ctx context.Context, req []byte, send func(chunk []byte) error) error) error
+}
+
+type GatherOptions struct {
+	Timeout time.Duration // defaults to the transport timeout
+	Max     int           // stop after this many replies, 0 collects until the deadline
 }
@@ -0,0 +1,234 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+	"github.com/abdelrahman146/zard/shared/validator"
+	"github.com/nats-io/nats.go"
+	"sync"
+	"time"
+)
+
+type replyHandler = func(ctx context.Context, req []byte) []byte
+type streamHandler = func(ctx context.Context, req []byte, send func(chunk []byte) error) error
+
+// MemoryBus routes requests between MemoryRPC instances living in the same process.
+// Share one bus between services to wire them together in a single test binary.
+type MemoryBus struct {
+	mu      sync.RWMutex
+	replies map[string][]*memoryQueue[replyHandler]  // subject -> queue groups
+	streams map[string][]*memoryQueue[streamHandler] // subject -> queue groups
+}
+
+type memoryQueue[H any] struct {
+	name     string
+	mu       sync.Mutex
+	handlers []H
+	next     int
+}
+
+func (q *memoryQueue[H]) pick() H {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	h := q.handlers[q.next%len(q.handlers)]
+	q.next++
+	return h
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		replies: make(map[string][]*memoryQueue[replyHandler]),
+		streams: make(map[string][]*memoryQueue[streamHandler]),
+	}
+}
+
+func subscribe[H any](mu *sync.RWMutex, groups map[string][]*memoryQueue[H], subject, queue string, handler H) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, q := range groups[subject] {
+		if q.name == queue {
+			q.mu.Lock()
+			q.handlers = append(q.handlers, handler)
+			q.mu.Unlock()
+			return
+		}
+	}
+	groups[subject] = append(groups[subject], &memoryQueue[H]{name: queue, handlers: []H{handler}})
+}
+
+// pick returns one handler per queue group, rotating between the members of each group.
+func pick[H any](mu *sync.RWMutex, groups map[string][]*memoryQueue[H], subject string) []H {
+	mu.RLock()
+	defer mu.RUnlock()
+	var picked []H
+	for _, q := range groups[subject] {
+		picked = append(picked, q.pick())
+	}
+	return picked
+}
+
+type memoryRPC struct {
+	bus    *MemoryBus
+	v      validator.Validator
+	config MemoryRPCConfig
+}
+
+type MemoryRPCConfig struct {
+	Timeout time.Duration // zero waits for the handler indefinitely
+	Group   string
+}
+
+func NewMemoryRPC(bus *MemoryBus, v validator.Validator, config MemoryRPCConfig) RPC {
+	return &memoryRPC{
+		bus:    bus,
+		v:      v,
+		config: config,
+	}
+}
+
+func (m *memoryRPC) encode(req requests.Request) ([]byte, error) {
+	if err := m.v.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	return json.Marshal(req)
+}
+
+// handlerContext hands the caller's correlation IDs to a handler the way they travel over the wire,
+// so handlers never see values the NATS transport would not deliver.
+func handlerContext(ctx context.Context) context.Context {
+	return logger.ContextFromHeader(context.Background(), logger.CorrelationHeader(ctx))
+}
+
+// timeout returns a channel that fires after d, or never when d is zero.
+func timeout(d time.Duration) (<-chan time.Time, func()) {
+	if d <= 0 {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
+
+func (m *memoryRPC) scatter(ctx context.Context, req requests.Request) (<-chan []byte, int, error) {
+	data, err := m.encode(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	handlers := pick(&m.bus.mu, m.bus.replies, req.Subject())
+	replies := make(chan []byte, len(handlers))
+	hctx := handlerContext(ctx)
+	for _, handler := range handlers {
+		go func(handler replyHandler) {
+			replies <- handler(hctx, data)
+		}(handler)
+	}
+	return replies, len(handlers), nil
+}
+
+func (m *memoryRPC) Request(ctx context.Context, req requests.Request) (resp []byte, err error) {
+	replies, n, err := m.scatter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nats.ErrNoResponders
+	}
+	expired, stop := timeout(m.config.Timeout)
+	defer stop()
+	select {
+	case resp = <-replies:
+		return resp, nil
+	case <-expired:
+		return nil, nats.ErrTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *memoryRPC) Handle(req requests.Request, handler func(ctx context.Context, req []byte) (resp []byte)) error {
+	subscribe(&m.bus.mu, m.bus.replies, req.Subject(), req.Consumer(m.config.Group), handler)
+	return nil
+}
+
+func (m *memoryRPC) Gather(ctx context.Context, req requests.Request, opts GatherOptions) (resps [][]byte, err error) {
+	replies, n, err := m.scatter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = m.config.Timeout
+	}
+	if opts.Max <= 0 || opts.Max > n {
+		opts.Max = n
+	}
+	expired, stop := timeout(opts.Timeout)
+	defer stop()
+	for len(resps) < opts.Max {
+		select {
+		case resp := <-replies:
+			resps = append(resps, resp)
+		case <-expired:
+			return resps, nil
+		case <-ctx.Done():
+			return resps, gatherDone(ctx)
+		}
+	}
+	return resps, nil
+}
+
+// gatherDone is the error of a gather whose ctx is done. A passed deadline ends it like its
+// timeout does, with the replies gathered so far; a cancellation fails it.
+func gatherDone(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// Stream is served by every queue group handling the subject, as it is over NATS.
+func (m *memoryRPC) Stream(ctx context.Context, req requests.Request, handler func(chunk []byte) error) error {
+	data, err := m.encode(req)
+	if err != nil {
+		return err
+	}
+	handlers := pick(&m.bus.mu, m.bus.streams, req.Subject())
+	if len(handlers) == 0 {
+		return nats.ErrNoResponders
+	}
+	frames := make(chan []byte)
+	done := make(chan struct{})
+	defer close(done)
+	hctx := handlerContext(ctx)
+	for _, h := range handlers {
+		go func(h streamHandler) {
+			w := newStreamWriter(func(frame []byte) error {
+				select {
+				case frames <- frame:
+					return nil
+				case <-done:
+					return nats.ErrConnectionClosed
+				}
+			})
+			_ = w.close(h(hctx, data, w.send))
+		}(h)
+	}
+	return readStream(func() ([]byte, error) {
+		expired, stop := timeout(m.config.Timeout)
+		defer stop()
+		select {
+		case frame := <-frames:
+			return frame, nil
+		case <-expired:
+			return nil, nats.ErrTimeout
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}, handler)
+}
+
+func (m *memoryRPC) HandleStream(req requests.Request, handler func(ctx context.Context, req []byte, send func(chunk []byte) error) error) error {
+	subscribe(&m.bus.mu, m.bus.streams, req.Subject(), req.Consumer(m.config.Group), handler)
+	return nil
+}
@@ -0,0 +1,285 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/logger"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+	"github.com/abdelrahman146/zard/shared/validator"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryRPC_RequestReply(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	server := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "account"})
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "payment", Timeout: time.Second})
+
+	err := server.Handle(&requests.GetUserRequest{}, Handler(func(ctx context.Context, req *requests.GetUserRequest) (interface{}, error) {
+		return &requests.GetUserResponse{ID: req.ID, Name: "Jane"}, nil
+	}))
+	assert.NoError(t, err)
+
+	user, err := Call[requests.GetUserResponse](context.Background(), client, &requests.GetUserRequest{ID: "usr_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "usr_1", user.ID)
+	assert.Equal(t, "Jane", user.Name)
+}
+
+func TestMemoryRPC_ValidatesRequests(t *testing.T) {
+	client := NewMemoryRPC(NewMemoryBus(), validator.NewValidator(), MemoryRPCConfig{})
+	_, err := client.Request(context.Background(), &requests.GetUserRequest{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, nats.ErrNoResponders)
+}
+
+func TestMemoryRPC_NoResponders(t *testing.T) {
+	client := NewMemoryRPC(NewMemoryBus(), validator.NewValidator(), MemoryRPCConfig{})
+	_, err := client.Request(context.Background(), &requests.GetUserRequest{ID: "usr_1"})
+	assert.ErrorIs(t, err, nats.ErrNoResponders)
+}
+
+func TestMemoryRPC_Timeout(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	release := make(chan struct{})
+	defer close(release)
+	server := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "account"})
+	_ = server.Handle(&requests.GetUserRequest{}, func(ctx context.Context, req []byte) []byte {
+		<-release
+		return nil
+	})
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{Timeout: 10 * time.Millisecond})
+	_, err := client.Request(context.Background(), &requests.GetUserRequest{ID: "usr_1"})
+	assert.ErrorIs(t, err, nats.ErrTimeout)
+}
+
+func TestMemoryRPC_HonoursContext(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	release := make(chan struct{})
+	defer close(release)
+	server := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "account"})
+	_ = server.Handle(&requests.GetUserRequest{}, func(ctx context.Context, req []byte) []byte {
+		<-release
+		return nil
+	})
+	_ = server.HandleStream(&listRequest{}, func(ctx context.Context, req []byte, send func(chunk []byte) error) error {
+		<-release
+		return nil
+	})
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{Timeout: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.Request(ctx, &requests.GetUserRequest{ID: "usr_1"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the deadline of ctx comes before the timeout")
+	resps, err := client.Gather(ctx, &requests.GetUserRequest{ID: "usr_1"}, GatherOptions{})
+	assert.NoError(t, err, "a gather ends at the deadline of ctx")
+	assert.Empty(t, resps)
+	assert.ErrorIs(t, client.Stream(ctx, &listRequest{}, func(chunk []byte) error { return nil }), context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = client.Request(ctx, &requests.GetUserRequest{ID: "usr_1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = client.Gather(ctx, &requests.GetUserRequest{ID: "usr_1"}, GatherOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMemoryRPC_QueueGroupLoadBalancing(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	hits := make([]int, 3)
+	for i := range hits {
+		i := i
+		instance := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "account"})
+		_ = instance.Handle(&requests.GetUserRequest{}, func(ctx context.Context, req []byte) []byte {
+			hits[i]++
+			return NewReply(nil, nil)
+		})
+	}
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{Timeout: time.Second})
+	for i := 0; i < 6; i++ {
+		_, err := client.Request(context.Background(), &requests.GetUserRequest{ID: "usr_1"})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []int{2, 2, 2}, hits)
+}
+
+type listRequest struct {
+	Count int `json:"count" validate:"gte=0"`
+}
+
+func (r *listRequest) Subject() string              { return "test.list" }
+func (r *listRequest) Consumer(group string) string { return "test_list_" + group }
+
+func TestMemoryRPC_Gather(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	for _, service := range []string{"account", "payment", "alert"} {
+		service := service
+		instance := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: service})
+		_ = instance.Handle(&requests.GetUserRequest{}, Handler(func(ctx context.Context, req *requests.GetUserRequest) (interface{}, error) {
+			return &requests.GetUserResponse{ID: req.ID, Name: service}, nil
+		}))
+	}
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{Timeout: time.Second})
+
+	users, err := GatherCall[requests.GetUserResponse](context.Background(), client, &requests.GetUserRequest{ID: "usr_1"}, GatherOptions{})
+	assert.NoError(t, err)
+	var names []string
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	assert.ElementsMatch(t, []string{"account", "payment", "alert"}, names)
+
+	resps, err := client.Gather(context.Background(), &requests.GetUserRequest{ID: "usr_1"}, GatherOptions{Max: 2})
+	assert.NoError(t, err)
+	assert.Len(t, resps, 2)
+}
+
+func TestGatherCall_ReturnsErrorReplies(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	ok := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "account"})
+	_ = ok.Handle(&requests.GetUserRequest{}, Handler(func(ctx context.Context, req *requests.GetUserRequest) (interface{}, error) {
+		return &requests.GetUserResponse{ID: req.ID}, nil
+	}))
+	failing := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "payment"})
+	_ = failing.Handle(&requests.GetUserRequest{}, Handler(func(ctx context.Context, req *requests.GetUserRequest) (interface{}, error) {
+		return nil, errs.NewNotFoundError("user not found", nil)
+	}))
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{Timeout: time.Second})
+
+	users, err := GatherCall[requests.GetUserResponse](context.Background(), client, &requests.GetUserRequest{ID: "usr_1"}, GatherOptions{})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	assert.Len(t, users, 1, "the successful replies are returned along with the error")
+}
+
+func TestMemoryRPC_GatherStopsAtDeadline(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	release := make(chan struct{})
+	defer close(release)
+	fast := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "fast"})
+	_ = fast.Handle(&requests.GetUserRequest{}, func(ctx context.Context, req []byte) []byte { return NewReply(nil, nil) })
+	slow := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "slow"})
+	_ = slow.Handle(&requests.GetUserRequest{}, func(ctx context.Context, req []byte) []byte {
+		<-release
+		return nil
+	})
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{})
+	resps, err := client.Gather(context.Background(), &requests.GetUserRequest{ID: "usr_1"}, GatherOptions{Timeout: 20 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.Len(t, resps, 1)
+}
+
+func TestMemoryRPC_Stream(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	server := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "account"})
+	err := server.HandleStream(&listRequest{}, StreamHandler(func(ctx context.Context, req *listRequest, send func(item interface{}) error) error {
+		for i := 0; i < req.Count; i++ {
+			if err := send(&requests.GetUserResponse{ID: "usr_" + string(rune('a'+i))}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	assert.NoError(t, err)
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{Timeout: time.Second})
+
+	var ids []string
+	err = StreamCall(context.Background(), client, &listRequest{Count: 3}, func(user *requests.GetUserResponse) error {
+		ids = append(ids, user.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"usr_a", "usr_b", "usr_c"}, ids)
+}
+
+func TestMemoryRPC_StreamReadsTheFirstQueueGroup(t *testing.T) {
+	v := validator.NewValidator()
+	for i := 0; i < 10; i++ {
+		started := make(chan struct{})
+		// payment starts streaming once account sent its first chunk, so their frames interleave
+		stream := func(service string, wait <-chan struct{}) func(ctx context.Context, req []byte, send func(chunk []byte) error) error {
+			return StreamHandler(func(ctx context.Context, req *listRequest, send func(item interface{}) error) error {
+				if wait != nil {
+					<-wait
+				}
+				for i := 0; i < req.Count; i++ {
+					if err := send(&requests.GetUserResponse{Name: service}); err != nil {
+						return err
+					}
+					if i == 0 && wait == nil {
+						close(started)
+					}
+				}
+				return nil
+			})
+		}
+		bus := NewMemoryBus()
+		_ = NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "account"}).HandleStream(&listRequest{}, stream("account", nil))
+		_ = NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "payment"}).HandleStream(&listRequest{}, stream("payment", started))
+		client := NewMemoryRPC(bus, v, MemoryRPCConfig{Timeout: time.Second})
+
+		var names []string
+		err := StreamCall(context.Background(), client, &listRequest{Count: 5}, func(user *requests.GetUserResponse) error {
+			names = append(names, user.Name)
+			return nil
+		})
+		assert.NoError(t, err, "the frames of the other queue group are dropped rather than out of order")
+		assert.Equal(t, []string{"account", "account", "account", "account", "account"}, names)
+	}
+}
+
+func TestMemoryRPC_StreamPropagatesHandlerError(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	server := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "account"})
+	_ = server.HandleStream(&listRequest{}, func(ctx context.Context, req []byte, send func(chunk []byte) error) error {
+		if err := send([]byte(`{}`)); err != nil {
+			return err
+		}
+		return errs.NewForbiddenError("not allowed", nil)
+	})
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{Timeout: time.Second})
+	chunks := 0
+	err := client.Stream(context.Background(), &listRequest{Count: 1}, func(chunk []byte) error {
+		chunks++
+		return nil
+	})
+	assert.Equal(t, 1, chunks)
+	assert.Equal(t, 403, errs.HandleError(err).HttpCode)
+}
+
+func TestMemoryRPC_PropagatesCorrelationIDs(t *testing.T) {
+	bus := NewMemoryBus()
+	v := validator.NewValidator()
+	server := NewMemoryRPC(bus, v, MemoryRPCConfig{Group: "account"})
+	received := make(chan map[string]string, 1)
+	_ = server.Handle(&requests.GetUserRequest{}, Handler(func(ctx context.Context, req *requests.GetUserRequest) (interface{}, error) {
+		received <- logger.CorrelationIDs(ctx)
+		return &requests.GetUserResponse{ID: req.ID}, nil
+	}))
+	client := NewMemoryRPC(bus, v, MemoryRPCConfig{Timeout: time.Second})
+
+	ctx := logger.WithCorrelationID(context.Background(), logger.RequestIDKey, "req_1")
+	_, err := Call[requests.GetUserResponse](ctx, client, &requests.GetUserRequest{ID: "usr_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{logger.RequestIDKey: "req_1"}, <-received)
+}
+
+func TestParseReply_KeepsMessageWithoutWrappedError(t *testing.T) {
+	reply := NewReply(nil, errs.NewInternalError("unable to get user", errors.New("dial tcp 10.0.0.5:5432: connection refused")))
+	err := ParseReply(reply, nil)
+	customErr := errs.HandleError(err)
+	assert.Equal(t, "unable to get user", customErr.Message())
+	assert.Equal(t, 500, customErr.HttpCode)
+}
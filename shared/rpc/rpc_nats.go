@@ -1,7 +1,10 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/logger"
 	"github.com/abdelrahman146/zard/shared/provider"
 	"github.com/abdelrahman146/zard/shared/rpc/requests"
 	"github.com/abdelrahman146/zard/shared/validator"
@@ -28,26 +31,120 @@ func NewNatsRPC(nts provider.NatsProvider, v validator.Validator, config NatsRPC
 	}
 }
 
-func (n *natsRPC) Request(req requests.Request) (resp []byte, err error) {
-	if err = n.v.ValidateStruct(req); err != nil {
+// message encodes req along with the correlation IDs carried by ctx.
+func (n *natsRPC) message(ctx context.Context, req requests.Request) (*nats.Msg, error) {
+	if err := n.v.ValidateStruct(req); err != nil {
 		return nil, err
 	}
 	data, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
-	msg, err := n.nc.Request(req.Subject(), data, n.config.Timeout)
+	return &nats.Msg{Subject: req.Subject(), Data: data, Header: logger.CorrelationHeader(ctx)}, nil
+}
+
+func msgContext(msg *nats.Msg) context.Context {
+	return logger.ContextFromHeader(context.Background(), msg.Header)
+}
+
+func (n *natsRPC) Request(ctx context.Context, req requests.Request) (resp []byte, err error) {
+	msg, err := n.message(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ctx.Deadline(); ok {
+		msg, err = n.nc.RequestMsgWithContext(ctx, msg)
+	} else {
+		msg, err = n.nc.RequestMsg(msg, n.config.Timeout)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return msg.Data, nil
 }
 
-func (n *natsRPC) Handle(req requests.Request, handler func(req []byte) (resp []byte)) error {
+func (n *natsRPC) Handle(req requests.Request, handler func(ctx context.Context, req []byte) (resp []byte)) error {
 	_, err := n.nc.QueueSubscribe(req.Subject(), req.Consumer(n.config.Group), func(msg *nats.Msg) {
-		resp := handler(msg.Data)
+		resp := handler(msgContext(msg), msg.Data)
 		_ = msg.Respond(resp)
 	})
 
 	return err
 }
+
+func (n *natsRPC) Gather(ctx context.Context, req requests.Request, opts GatherOptions) (resps [][]byte, err error) {
+	msg, err := n.message(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	msg.Reply = n.nc.NewRespInbox()
+	sub, err := n.nc.SubscribeSync(msg.Reply)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = sub.Unsubscribe() }()
+	if err = n.nc.PublishMsg(msg); err != nil {
+		return nil, err
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = n.config.Timeout
+	}
+	gctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for opts.Max <= 0 || len(resps) < opts.Max {
+		msg, err := sub.NextMsgWithContext(gctx)
+		if ctx.Err() != nil {
+			return resps, gatherDone(ctx)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+		if err != nil {
+			return resps, err
+		}
+		resps = append(resps, msg.Data)
+	}
+	return resps, nil
+}
+
+func (n *natsRPC) Stream(ctx context.Context, req requests.Request, handler func(chunk []byte) error) error {
+	msg, err := n.message(ctx, req)
+	if err != nil {
+		return err
+	}
+	msg.Reply = n.nc.NewRespInbox()
+	sub, err := n.nc.SubscribeSync(msg.Reply)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = sub.Unsubscribe() }()
+	if err = n.nc.PublishMsg(msg); err != nil {
+		return err
+	}
+	return readStream(func() ([]byte, error) {
+		fctx, cancel := context.WithTimeout(ctx, n.config.Timeout)
+		defer cancel()
+		msg, err := sub.NextMsgWithContext(fctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case errors.Is(err, context.DeadlineExceeded):
+			return nil, nats.ErrTimeout
+		case err != nil:
+			return nil, err
+		}
+		return msg.Data, nil
+	}, handler)
+}
+
+func (n *natsRPC) HandleStream(req requests.Request, handler func(ctx context.Context, req []byte, send func(chunk []byte) error) error) error {
+	_, err := n.nc.QueueSubscribe(req.Subject(), req.Consumer(n.config.Group), func(msg *nats.Msg) {
+		w := newStreamWriter(func(frame []byte) error {
+			return n.nc.Publish(msg.Reply, frame)
+		})
+		_ = w.close(handler(msgContext(msg), msg.Data, w.send))
+	})
+
+	return err
+}
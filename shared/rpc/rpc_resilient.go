@@ -0,0 +1,233 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+	"github.com/nats-io/nats.go"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBreakerOpen  = errors.New("rpc: circuit breaker is open")
+	ErrBulkheadFull = errors.New("rpc: too many concurrent requests")
+)
+
+// ResilientRPC is an RPC client that protects callers from slow or failing
+// targets. Stats and breaker states are reported per subject.
+type ResilientRPC interface {
+	RPC
+	Stats() map[string]Stats
+	BreakerState(subject string) BreakerState
+}
+
+type RetryConfig struct {
+	MaxAttempts    int           // total attempts for idempotent requests, including the first one
+	InitialBackoff time.Duration // delay before the first retry
+	MaxBackoff     time.Duration // upper bound for the delay between retries
+	Multiplier     float64       // growth factor applied to the delay after each retry
+	Jitter         bool          // randomize delays between zero and the computed backoff
+}
+
+type ResilienceConfig struct {
+	Retry    RetryConfig
+	Breaker  BreakerConfig
+	Bulkhead BulkheadConfig
+	// IsFailure decides whether an error counts against the breaker and can be retried.
+	// Defaults to transport errors only, so validation errors never trip the breaker.
+	IsFailure func(err error) bool
+}
+
+type resilientRPC struct {
+	next    RPC
+	config  ResilienceConfig
+	mu      sync.Mutex
+	targets map[string]*target
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+type target struct {
+	breaker  *circuitBreaker
+	bulkhead *bulkhead
+	metrics  *metrics
+}
+
+func NewResilientRPC(next RPC, config ResilienceConfig) ResilientRPC {
+	if config.Retry.MaxAttempts <= 0 {
+		config.Retry.MaxAttempts = 1
+	}
+	if config.Retry.Multiplier < 1 {
+		config.Retry.Multiplier = 2
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = IsTransientError
+	}
+	return &resilientRPC{
+		next:    next,
+		config:  config,
+		targets: make(map[string]*target),
+		now:     time.Now,
+		sleep:   sleep,
+	}
+}
+
+// IsTransientError reports whether err was caused by the transport rather than the handler.
+// Requests with a deadline time out with context.DeadlineExceeded rather than nats.ErrTimeout,
+// yet the deadline of the caller's own context is never held against the target, see call.
+func IsTransientError(err error) bool {
+	return errors.Is(err, nats.ErrTimeout) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, nats.ErrNoResponders) ||
+		errors.Is(err, nats.ErrConnectionClosed) ||
+		errors.Is(err, nats.ErrConnectionDraining) ||
+		errors.Is(err, nats.ErrConnectionReconnecting)
+}
+
+func (r *resilientRPC) target(subject string) *target {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.targets[subject]
+	if !ok {
+		t = &target{
+			breaker:  newCircuitBreaker(r.config.Breaker, r.now),
+			bulkhead: newBulkhead(r.config.Bulkhead),
+			metrics:  &metrics{},
+		}
+		r.targets[subject] = t
+	}
+	return t
+}
+
+// call runs fn behind the target's bulkhead and circuit breaker. It reports
+// rejected when fn was not run because either of them refused the call.
+// Errors of a call whose ctx is done are the caller's, so they never trip the breaker.
+func (r *resilientRPC) call(ctx context.Context, subject string, t *target, fn func() error) (rejected bool, err error) {
+	if !t.bulkhead.acquire() {
+		t.metrics.rejected.Add(1)
+		return true, errs.NewServiceUnavailableError("too many concurrent requests to "+subject, ErrBulkheadFull)
+	}
+	defer t.bulkhead.release()
+	if !t.breaker.allow() {
+		t.metrics.rejected.Add(1)
+		return true, errs.NewServiceUnavailableError("circuit breaker is open for "+subject, ErrBreakerOpen)
+	}
+	t.metrics.inFlight.Add(1)
+	start := r.now()
+	err = fn()
+	t.metrics.observe(start)
+	t.metrics.inFlight.Add(-1)
+	t.breaker.done(r.isFailure(ctx, err))
+	return false, err
+}
+
+// isFailure reports whether err of a call made with ctx counts against its target.
+func (r *resilientRPC) isFailure(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() == nil && r.config.IsFailure(err)
+}
+
+func (r *resilientRPC) Request(ctx context.Context, req requests.Request) (resp []byte, err error) {
+	subject := req.Subject()
+	t := r.target(subject)
+	t.metrics.requests.Add(1)
+	attempts := 1
+	if ir, ok := req.(requests.IdempotentRequest); ok && ir.Idempotent() {
+		attempts = r.config.Retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		rejected, err := r.call(ctx, subject, t, func() (err error) {
+			resp, err = r.next.Request(ctx, req)
+			return err
+		})
+		if rejected {
+			return nil, err
+		}
+		if err == nil {
+			t.metrics.successes.Add(1)
+			return resp, nil
+		}
+		if !r.isFailure(ctx, err) || attempt >= attempts {
+			t.metrics.failures.Add(1)
+			return nil, err
+		}
+		t.metrics.retries.Add(1)
+		if err := r.sleep(ctx, r.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleep waits for d, or returns the error of ctx when it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *resilientRPC) backoff(attempt int) time.Duration {
+	conf := r.config.Retry
+	d := float64(conf.InitialBackoff) * math.Pow(conf.Multiplier, float64(attempt-1))
+	if conf.MaxBackoff > 0 && d > float64(conf.MaxBackoff) {
+		d = float64(conf.MaxBackoff)
+	}
+	if conf.Jitter && d > 0 {
+		d = rand.Float64() * d
+	}
+	return time.Duration(d)
+}
+
+func (r *resilientRPC) Handle(req requests.Request, handler func(ctx context.Context, req []byte) (resp []byte)) error {
+	return r.next.Handle(req, handler)
+}
+
+func (r *resilientRPC) Gather(ctx context.Context, req requests.Request, opts GatherOptions) (resps [][]byte, err error) {
+	return r.next.Gather(ctx, req, opts)
+}
+
+// Stream is guarded by the bulkhead and breaker but never retried, since
+// chunks may already have been handed to the caller.
+func (r *resilientRPC) Stream(ctx context.Context, req requests.Request, handler func(chunk []byte) error) error {
+	subject := req.Subject()
+	t := r.target(subject)
+	t.metrics.requests.Add(1)
+	rejected, err := r.call(ctx, subject, t, func() error {
+		return r.next.Stream(ctx, req, handler)
+	})
+	switch {
+	case rejected:
+	case err != nil:
+		t.metrics.failures.Add(1)
+	default:
+		t.metrics.successes.Add(1)
+	}
+	return err
+}
+
+func (r *resilientRPC) HandleStream(req requests.Request, handler func(ctx context.Context, req []byte, send func(chunk []byte) error) error) error {
+	return r.next.HandleStream(req, handler)
+}
+
+func (r *resilientRPC) Stats() map[string]Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make(map[string]Stats, len(r.targets))
+	for subject, t := range r.targets {
+		s := t.metrics.snapshot()
+		s.Breaker = t.breaker.currentState().String()
+		stats[subject] = s
+	}
+	return stats
+}
+
+func (r *resilientRPC) BreakerState(subject string) BreakerState {
+	return r.target(subject).breaker.currentState()
+}
@@ -0,0 +1,191 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeRPC struct {
+	mu      sync.Mutex
+	calls   int
+	results []error
+	block   chan struct{}
+}
+
+func (f *fakeRPC) Request(ctx context.Context, req requests.Request) ([]byte, error) {
+	f.mu.Lock()
+	i := f.calls
+	f.calls++
+	f.mu.Unlock()
+	if f.block != nil {
+		<-f.block
+	}
+	if i < len(f.results) && f.results[i] != nil {
+		return nil, f.results[i]
+	}
+	return []byte("ok"), nil
+}
+
+func (f *fakeRPC) Handle(req requests.Request, handler func(ctx context.Context, req []byte) (resp []byte)) error {
+	return nil
+}
+
+func (f *fakeRPC) Gather(ctx context.Context, req requests.Request, opts GatherOptions) ([][]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeRPC) Stream(ctx context.Context, req requests.Request, handler func(chunk []byte) error) error {
+	_, err := f.Request(ctx, req)
+	return err
+}
+
+func (f *fakeRPC) HandleStream(req requests.Request, handler func(ctx context.Context, req []byte, send func(chunk []byte) error) error) error {
+	return nil
+}
+
+type mutatingRequest struct{}
+
+func (r *mutatingRequest) Subject() string              { return "test.mutate" }
+func (r *mutatingRequest) Consumer(group string) string { return "test_mutate_" + group }
+
+func newTestResilientRPC(next RPC, config ResilienceConfig) (*resilientRPC, *time.Time) {
+	now := time.Now()
+	r := NewResilientRPC(next, config).(*resilientRPC)
+	r.now = func() time.Time { return now }
+	r.sleep = func(ctx context.Context, d time.Duration) error { return ctx.Err() }
+	return r, &now
+}
+
+func TestResilientRPC_RetriesIdempotentRequests(t *testing.T) {
+	next := &fakeRPC{results: []error{nats.ErrTimeout, nats.ErrNoResponders}}
+	r, _ := newTestResilientRPC(next, ResilienceConfig{Retry: RetryConfig{MaxAttempts: 3}})
+	resp, err := r.Request(context.Background(), &requests.GetUserRequest{ID: "usr_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ok"), resp)
+	assert.Equal(t, 3, next.calls)
+	stats := r.Stats()["identity.user.get"]
+	assert.Equal(t, int64(2), stats.Retries)
+	assert.Equal(t, int64(1), stats.Successes)
+}
+
+func TestResilientRPC_StopsRetryingWhenContextIsDone(t *testing.T) {
+	next := &fakeRPC{results: []error{nats.ErrTimeout, nats.ErrTimeout}}
+	r := NewResilientRPC(next, ResilienceConfig{Retry: RetryConfig{MaxAttempts: 3, InitialBackoff: time.Hour}}).(*resilientRPC)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := r.Request(ctx, &requests.GetUserRequest{ID: "usr_1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, next.calls, "the backoff is cut short instead of sleeping for an hour")
+}
+
+func TestResilientRPC_CallerDeadlinesDoNotTripTheBreaker(t *testing.T) {
+	next := &fakeRPC{results: []error{context.DeadlineExceeded, context.DeadlineExceeded}}
+	r, _ := newTestResilientRPC(next, ResilienceConfig{
+		Retry:   RetryConfig{MaxAttempts: 3},
+		Breaker: BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	_, err := r.Request(ctx, &requests.GetUserRequest{ID: "usr_1"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, next.calls, "the request is not retried once its deadline passed")
+	assert.Equal(t, BreakerClosed, r.BreakerState("identity.user.get"), "a short deadline of one caller is not held against the target")
+
+	_, err = r.Request(context.Background(), &requests.GetUserRequest{ID: "usr_1"})
+	assert.Error(t, err)
+	assert.Equal(t, BreakerOpen, r.BreakerState("identity.user.get"), "deadlines the caller did not set still do")
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, IsTransientError(nats.ErrTimeout))
+	assert.True(t, IsTransientError(fmt.Errorf("nats: %w", context.DeadlineExceeded)), "requests with a deadline time out with the context")
+	assert.False(t, IsTransientError(context.Canceled))
+	assert.False(t, IsTransientError(errors.New("validation failed")))
+}
+
+func TestResilientRPC_DoesNotRetryNonIdempotentRequests(t *testing.T) {
+	next := &fakeRPC{results: []error{nats.ErrTimeout}}
+	r, _ := newTestResilientRPC(next, ResilienceConfig{Retry: RetryConfig{MaxAttempts: 3}})
+	_, err := r.Request(context.Background(), &mutatingRequest{})
+	assert.ErrorIs(t, err, nats.ErrTimeout)
+	assert.Equal(t, 1, next.calls)
+}
+
+func TestResilientRPC_DoesNotRetryHandlerErrors(t *testing.T) {
+	invalid := errors.New("validation failed")
+	next := &fakeRPC{results: []error{invalid}}
+	r, _ := newTestResilientRPC(next, ResilienceConfig{
+		Retry:   RetryConfig{MaxAttempts: 3},
+		Breaker: BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second},
+	})
+	_, err := r.Request(context.Background(), &requests.GetUserRequest{ID: "usr_1"})
+	assert.Equal(t, invalid, err)
+	assert.Equal(t, 1, next.calls)
+	assert.Equal(t, BreakerClosed, r.BreakerState("identity.user.get"))
+}
+
+func TestResilientRPC_BreakerOpensAndProbes(t *testing.T) {
+	next := &fakeRPC{results: []error{nats.ErrTimeout, nats.ErrTimeout, nil, nil}}
+	r, now := newTestResilientRPC(next, ResilienceConfig{
+		Breaker: BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenProbes: 1},
+	})
+	req := &requests.GetUserRequest{ID: "usr_1"}
+	_, _ = r.Request(context.Background(), req)
+	_, _ = r.Request(context.Background(), req)
+	assert.Equal(t, BreakerOpen, r.BreakerState(req.Subject()))
+
+	_, err := r.Request(context.Background(), req)
+	customErr := errs.HandleError(err)
+	assert.Equal(t, 503, customErr.HttpCode)
+	assert.Equal(t, ErrBreakerOpen, customErr.Original)
+	assert.Equal(t, 2, next.calls)
+
+	*now = now.Add(time.Minute)
+	_, err = r.Request(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, BreakerClosed, r.BreakerState(req.Subject()))
+	assert.Equal(t, int64(1), r.Stats()[req.Subject()].Rejected)
+}
+
+func TestResilientRPC_HalfOpenFailureReopens(t *testing.T) {
+	next := &fakeRPC{results: []error{nats.ErrTimeout, nats.ErrTimeout}}
+	r, now := newTestResilientRPC(next, ResilienceConfig{
+		Breaker: BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute},
+	})
+	req := &requests.GetUserRequest{ID: "usr_1"}
+	_, _ = r.Request(context.Background(), req)
+	*now = now.Add(time.Minute)
+	_, err := r.Request(context.Background(), req)
+	assert.ErrorIs(t, err, nats.ErrTimeout)
+	assert.Equal(t, BreakerOpen, r.BreakerState(req.Subject()))
+}
+
+func TestResilientRPC_BulkheadLimitsConcurrency(t *testing.T) {
+	next := &fakeRPC{block: make(chan struct{})}
+	r, _ := newTestResilientRPC(next, ResilienceConfig{Bulkhead: BulkheadConfig{MaxConcurrent: 1}})
+	req := &requests.GetUserRequest{ID: "usr_1"}
+	done := make(chan struct{})
+	go func() {
+		_, _ = r.Request(context.Background(), req)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		return r.Stats()[req.Subject()].InFlight == 1
+	}, time.Second, time.Millisecond)
+
+	_, err := r.Request(context.Background(), req)
+	customErr := errs.HandleError(err)
+	assert.Equal(t, 503, customErr.HttpCode)
+	assert.Equal(t, ErrBulkheadFull, customErr.Original)
+
+	close(next.block)
+	<-done
+}
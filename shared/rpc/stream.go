@@ -0,0 +1,135 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/errs/report"
+	"github.com/abdelrahman146/zard/shared/rpc/requests"
+	"github.com/nats-io/nuid"
+)
+
+// streamFrame carries one chunk of a streamed response. The last frame of a
+// stream has End set and carries the handler's error, if any.
+type streamFrame struct {
+	Stream string      `json:"stream"` // tells apart the responders of the queue groups answering the request
+	Seq    int         `json:"seq"`
+	Data   []byte      `json:"data,omitempty"`
+	End    bool        `json:"end,omitempty"`
+	Error  *ReplyError `json:"error,omitempty"`
+}
+
+type streamWriter struct {
+	stream  string
+	seq     int
+	publish func(frame []byte) error
+}
+
+func newStreamWriter(publish func(frame []byte) error) *streamWriter {
+	return &streamWriter{stream: nuid.Next(), publish: publish}
+}
+
+func (w *streamWriter) send(chunk []byte) error {
+	data, err := json.Marshal(streamFrame{Stream: w.stream, Seq: w.seq, Data: chunk})
+	if err != nil {
+		return err
+	}
+	w.seq++
+	return w.publish(data)
+}
+
+func (w *streamWriter) close(err error) error {
+	frame := streamFrame{Stream: w.stream, Seq: w.seq, End: true}
+	if err != nil {
+		frame.Error = toReplyError(err)
+	}
+	data, _ := json.Marshal(frame)
+	return w.publish(data)
+}
+
+// readStream reads frames from next until the end of the stream and hands every chunk to handler.
+// When several queue groups answer the request, the stream of the first one to send a frame is
+// read and the frames of the others are dropped.
+func readStream(next func() ([]byte, error), handler func(chunk []byte) error) error {
+	var stream string
+	for seq := 0; ; {
+		data, err := next()
+		if err != nil {
+			return err
+		}
+		frame := streamFrame{}
+		if err = json.Unmarshal(data, &frame); err != nil {
+			return errs.NewInternalError("unable to decode rpc stream frame", err)
+		}
+		if stream == "" {
+			stream = frame.Stream
+		}
+		if frame.Stream != stream {
+			continue
+		}
+		if frame.Seq != seq {
+			return errs.NewInternalError("rpc stream frames received out of order", nil)
+		}
+		seq++
+		if frame.End {
+			if frame.Error != nil {
+				return fromReplyError(frame.Error)
+			}
+			return nil
+		}
+		if err = handler(frame.Data); err != nil {
+			return err
+		}
+	}
+}
+
+// StreamHandler adapts a typed stream handler to the raw handler expected by RPC.HandleStream.
+func StreamHandler[T any](handle func(ctx context.Context, req *T, send func(item interface{}) error) error) func(ctx context.Context, req []byte, send func(chunk []byte) error) error {
+	return func(ctx context.Context, data []byte, send func(chunk []byte) error) error {
+		req := new(T)
+		if err := json.Unmarshal(data, req); err != nil {
+			return errs.NewBadRequestError("invalid rpc request", err)
+		}
+		err := handle(ctx, req, func(item interface{}) error {
+			chunk, err := json.Marshal(item)
+			if err != nil {
+				return errs.NewInternalError("unable to encode rpc stream item", err)
+			}
+			return send(chunk)
+		})
+		report.Report(ctx, err)
+		return err
+	}
+}
+
+// StreamCall streams req and decodes every chunk into R before passing it to handler.
+func StreamCall[R any](ctx context.Context, r RPC, req requests.Request, handler func(item *R) error) error {
+	return r.Stream(ctx, req, func(chunk []byte) error {
+		item := new(R)
+		if err := json.Unmarshal(chunk, item); err != nil {
+			return errs.NewInternalError("unable to decode rpc stream item", err)
+		}
+		return handler(item)
+	})
+}
+
+// GatherCall gathers replies to req and decodes the successful ones into R.
+// The errors of the replies carrying one are joined into err, returned along with the results of the others.
+func GatherCall[R any](ctx context.Context, r RPC, req requests.Request, opts GatherOptions) ([]R, error) {
+	resps, err := r.Gather(ctx, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]R, 0, len(resps))
+	var failed []error
+	for _, resp := range resps {
+		var result R
+		if err = ParseReply(resp, &result); err != nil {
+			failed = append(failed, err)
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, errors.Join(failed...)
+}
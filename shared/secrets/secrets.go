@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+)
+
+type Secret struct {
+	Version string `json:"version"`
+	Value   string `json:"value"`
+}
+
+// Versions holds the current value of a secret and the values it replaced
+// that are still accepted while consumers rotate to the new one.
+type Versions struct {
+	Current  Secret   `json:"current"`
+	Previous []Secret `json:"previous,omitempty"`
+}
+
+// All returns every accepted version, the current one first.
+func (v *Versions) All() []Secret {
+	return append([]Secret{v.Current}, v.Previous...)
+}
+
+type Provider interface {
+	Get(name string) (*Versions, error)
+}
+
+type staticProvider struct {
+	secrets map[string]*Versions
+}
+
+// NewStaticProvider serves fixed secrets, e.g. ones already read from the config.
+func NewStaticProvider(secrets map[string]*Versions) Provider {
+	return &staticProvider{secrets: secrets}
+}
+
+func (p *staticProvider) Get(name string) (*Versions, error) {
+	versions, ok := p.secrets[name]
+	if !ok || versions.Current.Value == "" {
+		return nil, newNotFoundError(name)
+	}
+	return versions, nil
+}
+
+type chainProvider struct {
+	providers []Provider
+}
+
+// NewChainProvider returns the secret from the first provider that has it,
+// e.g. a mounted file overriding the environment.
+func NewChainProvider(providers ...Provider) Provider {
+	return &chainProvider{providers: providers}
+}
+
+func (p *chainProvider) Get(name string) (*Versions, error) {
+	for _, provider := range p.providers {
+		versions, err := provider.Get(name)
+		if err == nil {
+			return versions, nil
+		}
+		if !errors.Is(err, errs.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, newNotFoundError(name)
+}
+
+// Current returns the value new tokens, keys and hashes should be created with.
+func Current(p Provider, name string) (string, error) {
+	versions, err := p.Get(name)
+	if err != nil {
+		return "", err
+	}
+	return versions.Current.Value, nil
+}
+
+// Verify runs check against every accepted version of the secret, the current
+// one first, and returns the version it accepted.
+func Verify(p Provider, name string, check func(value string) bool) (secret Secret, ok bool, err error) {
+	versions, err := p.Get(name)
+	if err != nil {
+		return Secret{}, false, err
+	}
+	for _, s := range versions.All() {
+		if check(s.Value) {
+			return s, true, nil
+		}
+	}
+	return Secret{}, false, nil
+}
+
+func newNotFoundError(name string) error {
+	return errs.NewNotFoundError("secret "+name+" not found", nil)
+}
@@ -0,0 +1,42 @@
+package secrets
+
+import (
+	"sync"
+	"time"
+)
+
+type cachedEntry struct {
+	versions  *Versions
+	expiresAt time.Time
+}
+
+type cachedProvider struct {
+	next    Provider
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedEntry
+	now     func() time.Time
+}
+
+// NewCachedProvider keeps what next returns for ttl, so remote providers are not
+// queried on every verification. Rotated secrets are picked up once the entry expires.
+func NewCachedProvider(next Provider, ttl time.Duration) Provider {
+	return &cachedProvider{next: next, ttl: ttl, entries: make(map[string]cachedEntry), now: time.Now}
+}
+
+func (p *cachedProvider) Get(name string) (*Versions, error) {
+	p.mu.Lock()
+	entry, ok := p.entries[name]
+	p.mu.Unlock()
+	if ok && p.now().Before(entry.expiresAt) {
+		return entry.versions, nil
+	}
+	versions, err := p.next.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.entries[name] = cachedEntry{versions: versions, expiresAt: p.now().Add(p.ttl)}
+	p.mu.Unlock()
+	return versions, nil
+}
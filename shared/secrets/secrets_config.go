@@ -0,0 +1,29 @@
+package secrets
+
+import (
+	"github.com/abdelrahman146/zard/shared/config"
+	"strings"
+)
+
+type configProvider struct {
+	conf config.Config
+}
+
+// NewConfigProvider reads secrets from conf, e.g. app.secret, and the values they replaced from
+// <name>Previous as a comma separated list, newest first. It is the fallback of
+// services that have no dedicated provider, and follows config reloads.
+func NewConfigProvider(conf config.Config) Provider {
+	return &configProvider{conf: conf}
+}
+
+func (p *configProvider) Get(name string) (*Versions, error) {
+	current := p.conf.GetString(name)
+	if current == "" {
+		return nil, newNotFoundError(name)
+	}
+	versions := &Versions{Current: Secret{Version: "current", Value: current}}
+	if previous := p.conf.GetString(name + "Previous"); previous != "" {
+		versions.Previous = previousVersions(strings.Split(previous, ","))
+	}
+	return versions, nil
+}
@@ -0,0 +1,37 @@
+package secrets
+
+import (
+	"encoding/json"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/provider"
+	capi "github.com/hashicorp/consul/api"
+)
+
+type consulProvider struct {
+	kv     *capi.KV
+	prefix string
+}
+
+// NewConsulProvider reads secrets stored in Consul KV under <prefix>/<name> as a JSON
+// document: {"current": {"version": "2", "value": "..."}, "previous": [{"version": "1", "value": "..."}]}.
+func NewConsulProvider(c provider.ConsulProvider, prefix string) Provider {
+	return &consulProvider{kv: c.GetClient().KV(), prefix: prefix}
+}
+
+func (p *consulProvider) Get(name string) (*Versions, error) {
+	pair, _, err := p.kv.Get(p.prefix+"/"+name, nil)
+	if err != nil {
+		return nil, errs.NewServiceUnavailableError("unable to read secret "+name, err)
+	}
+	if pair == nil || len(pair.Value) == 0 {
+		return nil, newNotFoundError(name)
+	}
+	versions := &Versions{}
+	if err = json.Unmarshal(pair.Value, versions); err != nil {
+		return nil, errs.NewInternalError("unable to parse secret "+name, err)
+	}
+	if versions.Current.Value == "" {
+		return nil, newNotFoundError(name)
+	}
+	return versions, nil
+}
@@ -0,0 +1,41 @@
+package secrets
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type envProvider struct {
+	prefix string
+	lookup func(key string) (string, bool)
+}
+
+// NewEnvProvider reads app.secret from <prefix>APP_SECRET and the values it
+// replaced from <prefix>APP_SECRET_PREVIOUS as a comma separated list, newest first.
+func NewEnvProvider(prefix string) Provider {
+	return &envProvider{prefix: prefix, lookup: os.LookupEnv}
+}
+
+func (p *envProvider) Get(name string) (*Versions, error) {
+	key := p.prefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_", "/", "_").Replace(name))
+	current, ok := p.lookup(key)
+	if !ok || current == "" {
+		return nil, newNotFoundError(name)
+	}
+	versions := &Versions{Current: Secret{Version: "current", Value: current}}
+	if previous, ok := p.lookup(key + "_PREVIOUS"); ok {
+		versions.Previous = previousVersions(strings.Split(previous, ","))
+	}
+	return versions, nil
+}
+
+func previousVersions(values []string) []Secret {
+	var previous []Secret
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			previous = append(previous, Secret{Version: "previous-" + strconv.Itoa(len(previous)+1), Value: value})
+		}
+	}
+	return previous
+}
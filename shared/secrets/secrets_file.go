@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"errors"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type fileProvider struct {
+	dir string
+}
+
+// NewFileProvider reads secrets mounted as files, e.g. Docker or Kubernetes secrets under /run/secrets.
+// The current value of app.secret is read from <dir>/app.secret and the values it replaced
+// from <dir>/app.secret.previous, one per line, newest first.
+func NewFileProvider(dir string) Provider {
+	return &fileProvider{dir: dir}
+}
+
+func (p *fileProvider) Get(name string) (*Versions, error) {
+	current, err := p.read(name)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && current == "") {
+		return nil, newNotFoundError(name)
+	}
+	if err != nil {
+		return nil, errs.NewInternalError("unable to read secret "+name, err)
+	}
+	versions := &Versions{Current: Secret{Version: "current", Value: current}}
+	previous, err := p.read(name + ".previous")
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, errs.NewInternalError("unable to read previous versions of secret "+name, err)
+	default:
+		versions.Previous = previousVersions(strings.Split(previous, "\n"))
+	}
+	return versions, nil
+}
+
+func (p *fileProvider) read(file string) (string, error) {
+	if file != filepath.Base(file) {
+		return "", fs.ErrNotExist
+	}
+	data, err := os.ReadFile(filepath.Join(p.dir, file))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
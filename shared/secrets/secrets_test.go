@@ -0,0 +1,194 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/config"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"github.com/abdelrahman146/zard/shared/provider"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestEnvProvider(env map[string]string) Provider {
+	return &envProvider{prefix: "ZARD_", lookup: func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}}
+}
+
+func TestEnvProvider(t *testing.T) {
+	p := newTestEnvProvider(map[string]string{
+		"ZARD_APP_SECRET":          "new",
+		"ZARD_APP_SECRET_PREVIOUS": "old, older",
+	})
+	versions, err := p.Get("app.secret")
+	assert.NoError(t, err)
+	assert.Equal(t, []Secret{
+		{Version: "current", Value: "new"},
+		{Version: "previous-1", Value: "old"},
+		{Version: "previous-2", Value: "older"},
+	}, versions.All())
+
+	_, err = p.Get("app.missing")
+	assert.Equal(t, 404, errs.HandleError(err).HttpCode)
+}
+
+func TestConfigProvider(t *testing.T) {
+	conf := config.NewViperConfig()
+	conf.Set("app.secret", "new")
+	conf.Set("app.secretPrevious", "old")
+	versions, err := NewConfigProvider(conf).Get("app.secret")
+	assert.NoError(t, err)
+	assert.Equal(t, []Secret{{Version: "current", Value: "new"}, {Version: "previous-1", Value: "old"}}, versions.All())
+
+	_, err = NewConfigProvider(conf).Get("app.missing")
+	assert.Equal(t, 404, errs.HandleError(err).HttpCode)
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.secret"), []byte("new\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.secret.previous"), []byte("old\n\n"), 0o600))
+
+	p := NewFileProvider(dir)
+	versions, err := p.Get("app.secret")
+	assert.NoError(t, err)
+	assert.Equal(t, Secret{Version: "current", Value: "new"}, versions.Current)
+	assert.Equal(t, []Secret{{Version: "previous-1", Value: "old"}}, versions.Previous)
+
+	_, err = p.Get("../app.secret")
+	assert.Equal(t, 404, errs.HandleError(err).HttpCode)
+}
+
+func TestConsulProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/secrets/app.secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		value := `{"current": {"version": "2", "value": "new"}, "previous": [{"version": "1", "value": "old"}]}`
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `[{"Key":"secrets/app.secret","Value":%q}]`, base64.StdEncoding.EncodeToString([]byte(value)))
+	}))
+	defer srv.Close()
+
+	p := NewConsulProvider(provider.InitConsulProvider(srv.URL), "secrets")
+	versions, err := p.Get("app.secret")
+	assert.NoError(t, err)
+	assert.Equal(t, []Secret{{Version: "2", Value: "new"}, {Version: "1", Value: "old"}}, versions.All())
+
+	_, err = p.Get("app.missing")
+	assert.Equal(t, 404, errs.HandleError(err).HttpCode)
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" || r.URL.Path != "/v1/secret/data/app.secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Query().Get("version") {
+		case "", "3":
+			_, _ = fmt.Fprint(w, `{"data": {"data": {"value": "v3"}, "metadata": {"version": 3}}}`)
+		case "2":
+			_, _ = fmt.Fprint(w, `{"data": {"data": null, "metadata": {"version": 2, "deletion_time": "2024-01-01T00:00:00Z"}}}`)
+		case "1":
+			_, _ = fmt.Fprint(w, `{"data": {"data": {"value": "v1"}, "metadata": {"version": 1}}}`)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewVaultProvider(VaultConfig{Address: srv.URL, Token: "root", Keep: 2})
+	versions, err := p.Get("app.secret")
+	assert.NoError(t, err)
+	assert.Equal(t, []Secret{{Version: "3", Value: "v3"}, {Version: "1", Value: "v1"}}, versions.All())
+
+	_, err = p.Get("app.missing")
+	assert.Equal(t, 404, errs.HandleError(err).HttpCode)
+}
+
+func TestVaultProvider_NestedNamesAndDeletedLatestVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/v1/secret/data/app/db%20password" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"errors": []}`)
+			return
+		}
+		switch r.URL.Query().Get("version") {
+		case "", "3":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"data": {"data": null, "metadata": {"version": 3, "deletion_time": "2024-01-01T00:00:00Z"}}}`)
+		case "2":
+			_, _ = fmt.Fprint(w, `{"data": {"data": {"value": "v2"}, "metadata": {"version": 2}}}`)
+		case "1":
+			_, _ = fmt.Fprint(w, `{"data": {"data": {"value": "v1"}, "metadata": {"version": 1}}}`)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewVaultProvider(VaultConfig{Address: srv.URL, Token: "root"})
+	versions, err := p.Get("app/db password")
+	assert.NoError(t, err)
+	assert.Equal(t, []Secret{{Version: "2", Value: "v2"}, {Version: "1", Value: "v1"}}, versions.All(), "the version before the deleted one is current")
+
+	_, err = p.Get("app/missing")
+	assert.Equal(t, 404, errs.HandleError(err).HttpCode)
+}
+
+func TestVerify(t *testing.T) {
+	p := newTestEnvProvider(map[string]string{
+		"ZARD_APP_SECRET":          "new",
+		"ZARD_APP_SECRET_PREVIOUS": "old",
+	})
+	secret, ok, err := Verify(p, "app.secret", func(value string) bool { return value == "old" })
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "previous-1", secret.Version)
+
+	_, ok, err = Verify(p, "app.secret", func(value string) bool { return false })
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	current, err := Current(p, "app.secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", current)
+}
+
+func TestChainProvider(t *testing.T) {
+	p := NewChainProvider(
+		newTestEnvProvider(map[string]string{"ZARD_DB_PASSWORD": "from-env"}),
+		NewStaticProvider(map[string]*Versions{
+			"app.secret":  {Current: Secret{Version: "current", Value: "fallback"}},
+			"db.password": {Current: Secret{Version: "current", Value: "ignored"}},
+		}),
+	)
+	value, err := Current(p, "db.password")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+	value, err = Current(p, "app.secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "fallback", value)
+	_, err = p.Get("app.missing")
+	assert.Equal(t, 404, errs.HandleError(err).HttpCode)
+}
+
+func TestCachedProvider(t *testing.T) {
+	env := map[string]string{"ZARD_APP_SECRET": "v1"}
+	now := time.Now()
+	p := &cachedProvider{next: newTestEnvProvider(env), ttl: time.Minute, entries: make(map[string]cachedEntry), now: func() time.Time { return now }}
+
+	value, _ := Current(p, "app.secret")
+	assert.Equal(t, "v1", value)
+	env["ZARD_APP_SECRET"] = "v2"
+	value, _ = Current(p, "app.secret")
+	assert.Equal(t, "v1", value)
+	now = now.Add(2 * time.Minute)
+	value, _ = Current(p, "app.secret")
+	assert.Equal(t, "v2", value)
+}
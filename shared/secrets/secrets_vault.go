@@ -0,0 +1,129 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/abdelrahman146/zard/shared/errs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type VaultConfig struct {
+	Address string // e.g. https://vault:8200
+	Token   string
+	Mount   string // KV v2 mount, defaults to "secret"
+	Field   string // field of the secret data holding the value, defaults to "value"
+	Keep    int    // how many previous versions are still accepted, defaults to 1
+	Client  *http.Client
+}
+
+type vaultProvider struct {
+	config VaultConfig
+}
+
+type vaultResponse struct {
+	Data struct {
+		Data     map[string]string `json:"data"`
+		Metadata struct {
+			Version      int    `json:"version"`
+			DeletionTime string `json:"deletion_time"`
+			Destroyed    bool   `json:"destroyed"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+// NewVaultProvider reads secrets from a Vault KV v2 engine, where every write creates
+// a new version. The latest version still readable is current and the ones before it are previous.
+// Names may be nested paths such as app/db.
+func NewVaultProvider(config VaultConfig) Provider {
+	if config.Mount == "" {
+		config.Mount = "secret"
+	}
+	if config.Field == "" {
+		config.Field = "value"
+	}
+	if config.Keep <= 0 {
+		config.Keep = 1
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	config.Address = strings.TrimSuffix(config.Address, "/")
+	return &vaultProvider{config: config}
+}
+
+func (p *vaultProvider) Get(name string) (*Versions, error) {
+	latest, version, err := p.read(name, 0)
+	if err != nil {
+		return nil, err
+	}
+	versions := &Versions{}
+	found := latest != nil
+	if found {
+		versions.Current = *latest
+	}
+	// a deleted latest version leaves the one before it current
+	for v := version - 1; v > 0 && len(versions.Previous) < p.config.Keep; v-- {
+		secret, _, err := p.read(name, v)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case secret == nil:
+		case !found:
+			versions.Current, found = *secret, true
+		default:
+			versions.Previous = append(versions.Previous, *secret)
+		}
+	}
+	if !found {
+		return nil, newNotFoundError(name)
+	}
+	return versions, nil
+}
+
+// read returns the given version of the secret, or the latest one when version is 0, along
+// with the number of the version read. Missing, deleted and destroyed versions are returned
+// as nil; the number of deleted and destroyed ones is still returned.
+func (p *vaultProvider) read(name string, version int) (*Secret, int, error) {
+	u := fmt.Sprintf("%s/v1/%s/data/%s", p.config.Address, p.config.Mount, secretPath(name))
+	if version > 0 {
+		u += "?version=" + strconv.Itoa(version)
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, errs.NewInternalError("unable to read secret "+name, err)
+	}
+	req.Header.Set("X-Vault-Token", p.config.Token)
+	resp, err := p.config.Client.Do(req)
+	if err != nil {
+		return nil, 0, errs.NewServiceUnavailableError("unable to read secret "+name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return nil, 0, errs.NewServiceUnavailableError("unable to read secret "+name, fmt.Errorf("vault responded with %s", resp.Status))
+	}
+	// Vault answers deleted versions with a 404 that still carries their metadata
+	body := &vaultResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, 0, errs.NewInternalError("unable to parse secret "+name, err)
+	}
+	meta := body.Data.Metadata
+	value := body.Data.Data[p.config.Field]
+	if resp.StatusCode == http.StatusNotFound || meta.Destroyed || meta.DeletionTime != "" || value == "" {
+		return nil, meta.Version, nil
+	}
+	return &Secret{Version: strconv.Itoa(meta.Version), Value: value}, meta.Version, nil
+}
+
+// secretPath escapes every segment of name on its own, so the slashes of nested names are kept.
+func secretPath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
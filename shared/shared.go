@@ -5,7 +5,9 @@ import (
 	"github.com/abdelrahman146/zard/shared/cache"
 	"github.com/abdelrahman146/zard/shared/config"
 	"github.com/abdelrahman146/zard/shared/pubsub"
+	"github.com/abdelrahman146/zard/shared/query"
 	"github.com/abdelrahman146/zard/shared/rpc"
+	"github.com/abdelrahman146/zard/shared/secrets"
 	"github.com/abdelrahman146/zard/shared/utils"
 	"github.com/abdelrahman146/zard/shared/validator"
 )
@@ -19,11 +21,22 @@ type Toolkit struct {
 	Cache     cache.Cache
 	Conf      config.Config
 	Validator validator.Validator
+	Secrets   secrets.Provider
 }
 
 type List[T any] struct {
-	Items []T   `json:"items"`
-	Page  int   `json:"page"`
-	Limit int   `json:"limit"`
-	Total int64 `json:"total"`
+	Items      []T    `json:"items"`
+	Page       int    `json:"page,omitempty"` // not set when paginating by cursor
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// NewList returns the page of items spec asked for, with the cursor of the next page.
+func NewList[T any](items []T, total int64, spec query.Spec) *List[T] {
+	list := &List[T]{Items: items, Page: spec.Page, Limit: spec.Limit, Total: total, NextCursor: query.NextCursor(spec, items)}
+	if spec.IsCursor() {
+		list.Page = 0
+	}
+	return list
 }
@@ -24,10 +24,7 @@ func (Struct) Decrypt(encrypted string, secret string) (string, error) {
 	return string(decrypted), nil
 }
 
+// Compare reports whether encrypted is the Encrypt result of txt with secret.
 func (a Struct) Compare(encrypted string, txt string, secret string) bool {
-	decrypted, err := a.Decrypt(encrypted, secret)
-	if err != nil {
-		return false
-	}
-	return decrypted == txt
+	return hmac.Equal([]byte(a.Encrypt(txt, secret)), []byte(encrypted))
 }